@@ -0,0 +1,46 @@
+// Accuracy reports how far the mean and standard deviation calculated by each summation of the Go engine, the
+// textbook one-pass formula, Welford's algorithm and DuckDB stray from the exact ones, calculated with math/big, for
+// datasets which are hard to sum in floating point: values huge next to their spread, and magnitudes cancelling out.
+// Errors are relative to the exact values; the float64 nearest a value is within about 1.1e-16 of it.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	N    int    `arg:"-n" default:"1000000" help:"number of values in each dataset"`
+	Seed uint64 `arg:"--seed" default:"1" help:"seed for the uniform values each dataset is made from"`
+}
+
+func main() {
+	var args args
+	p := arg.MustParse(&args)
+	if args.N < 1 {
+		p.Fail("-n must be positive")
+	}
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+
+	report, err := duckbench.AccuracyReport(context.Background(), duckbench.AccuracyDatasets, args.N, args.Seed)
+	if err != nil {
+		logging.Fatal("calculating accuracy", err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATASET\tMETHOD\tMEAN ERROR\tSTDDEV ERROR")
+	for _, a := range report {
+		fmt.Fprintf(tw, "%s\t%s\t%.2g\t%.2g\n", a.Dataset, a.Method, a.MeanError, a.StdDevError)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,163 @@
+// Basic is a tour of querying DuckDB through database/sql with go-duckdb, to copy from: opening a database,
+// passing values as positional, numbered and named parameters rather than formatting them into the SQL, preparing
+// a statement to run many times in a transaction, and checking the error of every step, including reading each row.
+//
+// Values are never formatted into the SQL string: a parameter is sent to DuckDB separately from the statement, so
+// it cannot change what the statement does, whatever it contains (such as the quote in "it's" below), and it keeps
+// its Go type instead of being parsed back out of text.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/alexflint/go-arg"
+	_ "github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+var labels = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "it's nine"}
+
+// insertRows inserts the first rows with positional ? parameters, which take the arguments in order.
+func insertRows(ctx context.Context, db *sql.DB) error {
+	for i, label := range labels[:5] {
+		if _, err := db.ExecContext(ctx, "INSERT INTO t VALUES (?, ?)", i, label); err != nil {
+			return fmt.Errorf("inserting row %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// insertPrepared inserts the rest of the rows with a statement prepared once and run for each, in a transaction
+// which is rolled back if any insert fails, so the table gets all of them or none.
+func insertPrepared(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	// a no-op once the transaction is committed
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO t VALUES (?, ?)")
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	defer stmt.Close()
+	for i := 5; i < len(labels); i++ {
+		if _, err := stmt.ExecContext(ctx, i, labels[i]); err != nil {
+			return fmt.Errorf("inserting row %d: %w", i, err)
+		}
+	}
+	// a NULL is a nil argument
+	if _, err := stmt.ExecContext(ctx, nil, "no number"); err != nil {
+		return fmt.Errorf("inserting NULL row: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing: %w", err)
+	}
+	return nil
+}
+
+// queryRows reads every row between lo and hi with numbered $1 and $2 parameters, which can be repeated or used out
+// of order, and checks the error of the query, of each Scan and of the iteration as a whole.
+func queryRows(ctx context.Context, db *sql.DB, lo, hi int) error {
+	rows, err := db.QueryContext(ctx, "SELECT i, i - $1, label FROM t WHERE i BETWEEN $1 AND $2 ORDER BY i", lo, hi)
+	if err != nil {
+		return fmt.Errorf("querying rows: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i, offset int
+		var label string
+		if err := rows.Scan(&i, &offset, &label); err != nil {
+			return fmt.Errorf("scanning row: %w", err)
+		}
+		slog.Info("row", "i", i, "offset", offset, "label", label)
+	}
+	// the error which stopped rows.Next, if any, rather than the end of the rows
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading rows: %w", err)
+	}
+	return nil
+}
+
+// queryNamed counts the rows between lo and hi with named $lo and $hi parameters, which go-duckdb binds by name, so
+// the sql.Named arguments may be passed in any order.
+func queryNamed(ctx context.Context, db *sql.DB, lo, hi int) (int, error) {
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM t WHERE i >= $lo AND i <= $hi",
+		sql.Named("lo", lo), sql.Named("hi", hi)).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("counting rows: %w", err)
+	}
+	return n, nil
+}
+
+// lookup returns the number of label, telling a label with no row, sql.ErrNoRows, from one whose number is NULL,
+// which only scans into a nullable type such as sql.NullInt64.
+func lookup(ctx context.Context, db *sql.DB, label string) (sql.NullInt64, error) {
+	var i sql.NullInt64
+	err := db.QueryRowContext(ctx, "SELECT i FROM t WHERE label = ?", label).Scan(&i)
+	return i, err
+}
+
+func main() {
+	var args logging.Args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+
+	// an empty DSN is an in-memory database; sql.Open only checks the DSN, and the first query connects
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		logging.Fatal("opening database", err)
+	}
+	defer db.Close()
+	if err := db.PingContext(ctx); err != nil {
+		logging.Fatal("connecting to database", err)
+	}
+	if _, err := db.ExecContext(ctx, "CREATE TABLE t (i INTEGER, label VARCHAR)"); err != nil {
+		logging.Fatal("creating table", err)
+	}
+
+	if err := insertRows(ctx, db); err != nil {
+		logging.Fatal("inserting rows", err)
+	}
+	if err := insertPrepared(ctx, db); err != nil {
+		logging.Fatal("inserting prepared rows", err)
+	}
+	if err := queryRows(ctx, db, 2, 9); err != nil {
+		logging.Fatal("reading rows", err)
+	}
+	n, err := queryNamed(ctx, db, 3, 6)
+	if err != nil {
+		logging.Fatal("counting rows", err)
+	}
+	slog.Info("rows from 3 to 6", "count", n)
+
+	for _, label := range []string{"it's nine", "no number", "ten"} {
+		switch i, err := lookup(ctx, db, label); {
+		case errors.Is(err, sql.ErrNoRows):
+			slog.Info("no row", "label", label)
+		case err != nil:
+			logging.Fatal("looking up "+label, err)
+		case !i.Valid:
+			slog.Info("NULL number", "label", label)
+		default:
+			slog.Info("number", "label", label, "i", i.Int64)
+		}
+	}
+
+	// errors come back from DuckDB with the statement, here for a parameter it cannot convert
+	var i int
+	if err := db.QueryRowContext(ctx, "SELECT ?::INTEGER", "ten").Scan(&i); err != nil {
+		slog.Info("expected error", "err", err)
+	} else {
+		logging.Fatal("converting a parameter", errors.New("'ten' converted to an INTEGER"))
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/compare"
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// compareCmd loads the same records into each storage engine and times calculating their statistics, checking every
+// engine agrees with the Go implementation.
+type compareCmd struct {
+	dataArgs
+
+	N       int
+	Engines []string
+}
+
+func newCompareCmd() *cobra.Command {
+	c := new(compareCmd)
+	cmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Time loading the records into each storage engine and calculating their statistics",
+		Args:  cobra.NoArgs,
+		RunE:  func(cmd *cobra.Command, _ []string) error { return c.run(cmd.Context()) },
+	}
+	fs := cmd.Flags()
+	c.dataArgs.addFlags(fs)
+	fs.IntVarP(&c.N, "n", "n", 100000, "number of records to generate when no dataset is given")
+	fs.StringArrayVar(&c.Engines, "engine", nil, "engine to compare (repeatable) [default: all]")
+	return cmd
+}
+
+func (c *compareCmd) run(ctx context.Context) error {
+	records, err := c.records(ctx, c.N)
+	if err != nil {
+		return fmt.Errorf("loading records: %w", err)
+	}
+	want, err := duckbench.StatisticsFromRecords(records)
+	if err != nil {
+		return fmt.Errorf("calculating statistics in Go: %w", err)
+	}
+
+	results, err := compare.Run(ctx, records, c.Engines...)
+	if err != nil {
+		return fmt.Errorf("selecting engines: %w", err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENGINE\tLOAD\tQUERY\tMEAN\tMEDIAN\tSTDDEV\tMIN\tMAX\tAGREES")
+	for _, r := range results {
+		if errors.Is(r.Err, compare.ErrUnavailable) {
+			fmt.Fprintf(tw, "%s\t\t\t\t\t\t\t\tunavailable\n", r.Engine)
+			continue
+		}
+		if r.Err != nil {
+			fmt.Fprintf(tw, "%s\t\t\t\t\t\t\t\terror: %v\n", r.Engine, r.Err)
+			continue
+		}
+		agrees := "yes"
+		if m := duckbench.CompareStats(r.Stats, want, duckbench.StatTolerance); len(m) > 0 {
+			agrees = fmt.Sprintf("no: %s", m[0].Stat)
+		}
+		s := r.Stats
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%s\n", r.Engine, r.Load, r.Query, s.Mean, s.Median, s.StdDev, s.Min, s.Max, agrees)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// textValue is a flag of a string type checked by its UnmarshalText, such as duckbench.Distribution.
+type textValue[T ~string, P interface {
+	*T
+	encoding.TextUnmarshaler
+}] struct{ p P }
+
+func (v textValue[T, P]) Set(s string) error { return v.p.UnmarshalText([]byte(s)) }
+func (v textValue[T, P]) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return string(*v.p)
+}
+func (v textValue[T, P]) Type() string { return "string" }
+
+// textSliceValue is a repeatable textValue, the values of which replace its default.
+type textSliceValue[T ~string, P interface {
+	*T
+	encoding.TextUnmarshaler
+}] struct {
+	p   *[]T
+	set bool
+}
+
+func (v *textSliceValue[T, P]) Set(s string) error {
+	var value T
+	if err := P(&value).UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	if !v.set {
+		*v.p, v.set = nil, true
+	}
+	*v.p = append(*v.p, value)
+	return nil
+}
+
+func (v *textSliceValue[T, P]) String() string {
+	// empty, so an unset flag has no default in its usage
+	if v.p == nil || len(*v.p) == 0 {
+		return ""
+	}
+	values := make([]string, len(*v.p))
+	for i, value := range *v.p {
+		values[i] = string(value)
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+func (v *textSliceValue[T, P]) Type() string { return "strings" }
+
+// textVar defines a flag of a string type checked by its UnmarshalText.
+func textVar[T ~string, P interface {
+	*T
+	encoding.TextUnmarshaler
+}](fs *pflag.FlagSet, p P, name string, value T, usage string) {
+	*p = value
+	fs.Var(textValue[T, P]{p}, name, usage)
+}
+
+// textSliceVar defines a repeatable flag of a string type checked by its UnmarshalText.
+func textSliceVar[T ~string, P interface {
+	*T
+	encoding.TextUnmarshaler
+}](fs *pflag.FlagSet, p *[]T, name string, usage string) {
+	fs.Var(&textSliceValue[T, P]{p: p}, name, usage)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/results"
+)
+
+// historyCmd reports the trend of each step's timing over the runs saved to a results database with stats
+// --results-db, flagging those whose latest run regressed.
+type historyCmd struct {
+	ResultsDB string
+	Window    int
+	Threshold float64
+	Fail      bool
+}
+
+func newHistoryCmd() *cobra.Command {
+	c := new(historyCmd)
+	cmd := &cobra.Command{
+		Use:   "history RESULTS_DB",
+		Short: "Show the trend of each timing saved with stats --results-db, flagging regressions",
+		Long: "Show the trend of each timing saved with stats --results-db to the results database RESULTS_DB, flagging\n" +
+			"the steps whose latest run regressed.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.ResultsDB = args[0]
+			return c.run(cmd.Context())
+		},
+	}
+	fs := cmd.Flags()
+	fs.IntVar(&c.Window, "window", 5, "previous runs whose median the latest run is compared with")
+	fs.Float64Var(&c.Threshold, "threshold", 0.1, "fraction slower than the baseline beyond which the latest run is a regression")
+	fs.BoolVar(&c.Fail, "fail", false, "exit with an error if any step regressed, e.g. in CI")
+	return cmd
+}
+
+func (c *historyCmd) run(ctx context.Context) error {
+	if _, err := os.Stat(c.ResultsDB); err != nil {
+		return err
+	}
+	store, err := results.Open(ctx, c.ResultsDB)
+	if err != nil {
+		return fmt.Errorf("opening results database: %w", err)
+	}
+	defer store.Close()
+	trends, err := store.Trends(ctx, c.Window)
+	if err != nil {
+		return fmt.Errorf("querying trends: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "N\tPARAMETERS\tSTEP\tNAME\tRUNS\tLATEST\tBASELINE\tCHANGE\tSHA\t")
+	var regressions int
+	for _, t := range trends {
+		baseline, change, flag := "-", "-", ""
+		if !math.IsNaN(t.Change()) {
+			baseline, change = t.Baseline.String(), fmt.Sprintf("%+.1f%%", 100*t.Change())
+		}
+		if t.Regressed(c.Threshold) {
+			flag = "REGRESSION"
+			regressions++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%v\t%s\t%s\t%.12s\t%s\n", t.N, t.Parameters, t.Step, t.Name, t.Runs, t.Latest,
+			baseline, change, t.LatestSHA, flag)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if regressions > 0 {
+		slog.Warn("timings regressed", "steps", regressions, "threshold", c.Threshold, "window", c.Window)
+		if c.Fail {
+			return fmt.Errorf("%d steps regressed by more than %.0f%%", regressions, 100*c.Threshold)
+		}
+	}
+	return nil
+}
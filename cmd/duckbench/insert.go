@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/profiling"
+)
+
+type insertCmd struct {
+	dataArgs
+	connArgs
+	insertArgs
+	profileArgs
+
+	N          int
+	SkipVerify bool
+	Readers    int
+}
+
+func newInsertCmd() *cobra.Command {
+	c := new(insertCmd)
+	cmd := &cobra.Command{
+		Use:   "insert",
+		Short: "Time inserting records into DuckDB with each insert method",
+		Args:  cobra.NoArgs,
+		RunE:  func(cmd *cobra.Command, _ []string) error { return c.run(cmd.Context()) },
+	}
+	fs := cmd.Flags()
+	c.dataArgs.addFlags(fs)
+	c.connArgs.addFlags(fs)
+	c.insertArgs.addFlags(fs)
+	c.profileArgs.addFlags(fs)
+	fs.IntVarP(&c.N, "n", "n", 1000000, "number of records to generate")
+	fs.BoolVar(&c.SkipVerify, "skip-verify", false, "don't read the table back after each insert to check it holds exactly the records")
+	fs.IntVar(&c.Readers, "readers", 0, "goroutines running an aggregate query in a loop during each insert, timing queries under the write load")
+	return cmd
+}
+
+// run times inserting the records with each strategy into its own in-memory database.
+func (c *insertCmd) run(ctx context.Context) error {
+	records, err := c.records(ctx, c.N)
+	if err != nil {
+		return fmt.Errorf("loading records: %w", err)
+	}
+	boot, err := c.boot()
+	if err != nil {
+		return fmt.Errorf("parsing connection settings: %w", err)
+	}
+	c.servePprof()
+	profiler, err := c.profiler()
+	if err != nil {
+		return fmt.Errorf("creating profiles: %w", err)
+	}
+	if err := profiler.Start(); err != nil {
+		return fmt.Errorf("starting profiler: %w", err)
+	}
+	defer func() {
+		if err := profiler.Stop(); err != nil {
+			slog.Error("writing profiles", "err", err)
+		}
+	}()
+
+	slog.Info("inserting records into DuckDB", "rows", len(records), "dist", c.Distribution, "seed", c.Seed)
+	for _, strategy := range c.strategies() {
+		// label and trace each strategy, so cmd/flamegraph --label method splits the profile between them
+		ctx, unlabel := profiling.Label(ctx, "method", strategy.Name())
+		ctx, end := profiling.Task(ctx, strategy.Name())
+		r, err := c.insert(ctx, duckbench.DBOptions{OnConnect: boot, Pool: c.pool()}, records, strategy)
+		end()
+		unlabel()
+		if err != nil {
+			return fmt.Errorf("inserting records with %s: %w", strategy.Name(), err)
+		}
+		slog.Info("phase complete", "phase", duckbench.PhaseInsert, "method", r.Name, "rows", r.Rows, "duration", r.Duration,
+			"rows_per_sec", r.RowsPerSecond())
+	}
+	return nil
+}
+
+func (c *insertCmd) insert(ctx context.Context, opts duckbench.DBOptions, records []duckbench.Record, strategy duckbench.InsertStrategy) (duckbench.BenchmarkResult, error) {
+	db, err := duckbench.CreateDB(ctx, opts)
+	if err != nil {
+		return duckbench.BenchmarkResult{}, err
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		return duckbench.BenchmarkResult{}, err
+	}
+	var r duckbench.BenchmarkResult
+	if c.Readers > 0 {
+		reads, err := duckbench.ReadDuringInsert(ctx, db, records, strategy, c.Readers)
+		if err != nil {
+			return reads.Insert, err
+		}
+		r = reads.Insert
+		slog.Info("queries during insert", "method", r.Name, "readers", c.Readers, "queries", reads.Queries,
+			"latency", reads.Latency, "p99", reads.P99, "snapshots", len(reads.Snapshots))
+	} else {
+		r, err = duckbench.Measure(strategy.Name(), len(records), func() error {
+			return strategy.Insert(ctx, records, db)
+		})
+	}
+	if err != nil || c.SkipVerify {
+		return r, err
+	}
+	return r, duckbench.VerifyIngestion(ctx, db, records)
+}
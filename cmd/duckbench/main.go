@@ -0,0 +1,198 @@
+// Duckbench compares the performance of DuckDB with native Go code: timing inserting records into DuckDB, calculating
+// their statistics in Go and in DuckDB, and loading them into other storage engines.
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/compare"
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+	"github.com/rpep/duckdb-go-experiments/pkg/profiling"
+)
+
+// description is the help of the duckbench command itself.
+func description() string {
+	return fmt.Sprintf("Duckbench compares the performance of DuckDB with native Go code.\n\ncompare engines: %v\n\n"+
+		"postgres and mysql connect to the server in $POSTGRES_DSN and $MYSQL_DSN, or, built with -tags testcontainers,\n"+
+		"start one with Docker, and clickhouse-local runs $CLICKHOUSE or the clickhouse binary on the PATH; each is\n"+
+		"reported as unavailable without one.", compare.Engines())
+}
+
+// dataArgs are the flags choosing the records a command runs on.
+type dataArgs struct {
+	Distribution duckbench.Distribution
+	Seed         uint64
+	Dataset      string
+}
+
+func (a *dataArgs) addFlags(fs *pflag.FlagSet) {
+	textVar(fs, &a.Distribution, "dist", duckbench.DistSequential, "distribution of the generated values: sequential, uniform, normal, exponential, zipf or timeseries")
+	fs.Uint64Var(&a.Seed, "seed", 1, "seed for the random distributions, so a run can be reproduced")
+	fs.StringVar(&a.Dataset, "dataset", "", "replay the records saved in this .csv or .parquet file instead of generating them")
+}
+
+// records loads the dataset, or generates n records.
+func (a dataArgs) records(ctx context.Context, n int) ([]duckbench.Record, error) {
+	if a.Dataset != "" {
+		return duckbench.LoadDataset(ctx, a.Dataset)
+	}
+	return duckbench.GenerateDistribution(n, a.Distribution, a.Seed)
+}
+
+// connArgs are the flags setting up each new DuckDB connection, and the pool they are kept in.
+type connArgs struct {
+	OnConnect       []string
+	Load            []string
+	Set             []string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+func (a *connArgs) addFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&a.OnConnect, "on-connect", nil, "SQL statement to run on every new connection (repeatable)")
+	fs.StringSliceVar(&a.Load, "load", nil, "extensions to LOAD on every new connection (comma-separated or repeatable)")
+	fs.StringSliceVar(&a.Set, "set", nil, "settings to SET on every new connection, as name=value (comma-separated or repeatable)")
+	fs.IntVar(&a.MaxOpenConns, "max-open-conns", 0, "most connections the pool opens at once [default: no limit]")
+	fs.IntVar(&a.MaxIdleConns, "max-idle-conns", 0, "connections the pool keeps open between queries, or -1 for none [default: 2]")
+	fs.DurationVar(&a.ConnMaxLifetime, "conn-max-lifetime", 0, "close pooled connections once they are this old, e.g. 1s [default: never]")
+}
+
+func (a connArgs) boot() ([]string, error) {
+	return duckbench.BootStatements(a.OnConnect, a.Load, a.Set)
+}
+
+func (a connArgs) pool() duckbench.Pool {
+	return duckbench.Pool{MaxOpenConns: a.MaxOpenConns, MaxIdleConns: a.MaxIdleConns, ConnMaxLifetime: a.ConnMaxLifetime}
+}
+
+// insertArgs are the flags choosing the insert strategies to time.
+type insertArgs struct {
+	Inserts     []duckbench.InsertMethod
+	ValuesBatch []int
+	CommitEvery []int
+	Workers     []int
+}
+
+func (a *insertArgs) addFlags(fs *pflag.FlagSet) {
+	textSliceVar(fs, &a.Inserts, "insert", "insert method to time, standard, appender, values, csv or arrow (repeatable) [default: all, in that order]")
+	fs.IntSliceVar(&a.ValuesBatch, "values-batch", nil, "rows per multi-row INSERT for the values method (repeatable, to sweep batch sizes) [default: 1000]")
+	fs.IntSliceVar(&a.CommitEvery, "commit-every", nil, "rows per transaction for the standard method, committing as it goes (repeatable, to sweep commit sizes, e.g. 1, 100, 10000 and 1000000) [default: one transaction]")
+	fs.IntSliceVar(&a.Workers, "workers", nil, "goroutines inserting shards of the records at once, each over its own connection (repeatable, to see how ingestion scales) [default: 1]")
+}
+
+func (a insertArgs) strategies() []duckbench.InsertStrategy {
+	methods := a.Inserts
+	if len(methods) == 0 {
+		methods = duckbench.InsertMethods
+	}
+	var strategies []duckbench.InsertStrategy
+	for _, method := range methods {
+		if method == duckbench.InsertValues && len(a.ValuesBatch) > 0 {
+			for _, size := range a.ValuesBatch {
+				strategies = append(strategies, duckbench.ValuesInsert{BatchSize: size})
+			}
+			continue
+		}
+		if method == duckbench.InsertStandard && len(a.CommitEvery) > 0 {
+			for _, size := range a.CommitEvery {
+				strategies = append(strategies, duckbench.CommitInsert{CommitEvery: size})
+			}
+			continue
+		}
+		strategies = append(strategies, method)
+	}
+	if len(a.Workers) == 0 {
+		return strategies
+	}
+	var sharded []duckbench.InsertStrategy
+	for _, s := range strategies {
+		for _, workers := range a.Workers {
+			if workers == 1 {
+				sharded = append(sharded, s)
+			} else {
+				sharded = append(sharded, duckbench.ConcurrentInsert{Workers: workers, Strategy: s})
+			}
+		}
+	}
+	return sharded
+}
+
+// profileArgs are the flags profiling a command's Go code, to see where its time goes between the benchmark and the
+// driver.
+type profileArgs struct {
+	CPUProfile   string
+	MemProfile   string
+	BlockProfile string
+	Trace        string
+	PprofAddr    string
+}
+
+func (a *profileArgs) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&a.CPUProfile, "cpuprofile", "", "write a CPU profile to this file")
+	fs.StringVar(&a.MemProfile, "memprofile", "", "write a heap profile to this file")
+	fs.StringVar(&a.BlockProfile, "blockprofile", "", "write a goroutine blocking profile to this file")
+	fs.StringVar(&a.Trace, "trace", "", "write an execution trace to this file, for go tool trace")
+	fs.StringVar(&a.PprofAddr, "pprof-addr", "", "serve live profiles on /debug/pprof/ at this address while running, e.g. localhost:6060")
+}
+
+func (a profileArgs) profiler() (*profiling.Profiler, error) {
+	return profiling.New(profiling.Options{
+		CPU:   a.CPUProfile,
+		Mem:   a.MemProfile,
+		Block: a.BlockProfile,
+		Trace: a.Trace,
+	})
+}
+
+// servePprof serves profiling.Handler in the background, if --pprof-addr is set.
+func (a profileArgs) servePprof() {
+	if a.PprofAddr == "" {
+		return
+	}
+	go func() {
+		logging.Fatal("serving pprof", http.ListenAndServe(a.PprofAddr, profiling.Handler()))
+	}()
+}
+
+func main() {
+	var log logging.Args
+	root := &cobra.Command{
+		Use:           "duckbench",
+		Short:         "Compare the performance of DuckDB with native Go code",
+		Long:          description(),
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			// the flags parsed, so an error from here on is the command's rather than its usage's
+			cmd.SilenceUsage = true
+			if err := log.Setup(); err != nil {
+				return fmt.Errorf("configuring logging: %w", err)
+			}
+			return nil
+		},
+	}
+	log.AddFlags(root.PersistentFlags())
+	root.AddCommand(newInsertCmd(), newStatsCmd(&log), newCompareCmd(), newSweepCmd(), newHistoryCmd(), newPoolCmd())
+
+	// the first interrupt cancels the command, interrupting any DuckDB query in progress; a second exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if cmd, err := root.ExecuteContextC(ctx); err != nil {
+		logging.Fatal("running "+cmd.Name(), err)
+	}
+}
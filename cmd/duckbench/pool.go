@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+type namedPool struct {
+	name string
+	pool duckbench.Pool
+}
+
+// poolPresets are the pools compared by default: database/sql's defaults, a single connection serialising every
+// query, one connection per query, enough idle connections for every client, and connections recycled quickly.
+var poolPresets = []namedPool{
+	{"default", duckbench.Pool{}},
+	{"1 connection", duckbench.Pool{MaxOpenConns: 1}},
+	{"no idle", duckbench.Pool{MaxIdleConns: -1}},
+	{"64 idle", duckbench.Pool{MaxIdleConns: 64}},
+	{"64 idle, 10ms lifetime", duckbench.Pool{MaxIdleConns: 64, ConnMaxLifetime: 10 * time.Millisecond}},
+}
+
+// poolCmd times concurrent queries against one in-memory database under each pool configuration.
+type poolCmd struct {
+	dataArgs
+	connArgs
+
+	N        int
+	Clients  []int
+	Duration time.Duration
+	Query    string
+}
+
+func newPoolCmd() *cobra.Command {
+	c := new(poolCmd)
+	cmd := &cobra.Command{
+		Use:   "pool",
+		Short: "Time concurrent queries under each database/sql connection pool configuration",
+		Args:  cobra.NoArgs,
+		RunE:  func(cmd *cobra.Command, _ []string) error { return c.run(cmd.Context()) },
+	}
+	fs := cmd.Flags()
+	c.dataArgs.addFlags(fs)
+	c.connArgs.addFlags(fs)
+	fs.IntVarP(&c.N, "n", "n", 1000000, "number of records to generate")
+	fs.IntSliceVar(&c.Clients, "clients", nil, "goroutines querying at once (repeatable) [default: 1, 4 and 16]")
+	fs.DurationVar(&c.Duration, "duration", 2*time.Second, "how long each client count runs under each pool")
+	fs.StringVar(&c.Query, "query", "SELECT COUNT(*), AVG(value) FROM records WHERE id % 10 = 0", "query each client runs in a loop")
+	return cmd
+}
+
+// run loads the records into a database for each pool, then times the query at each client count. Setting any of
+// --max-open-conns, --max-idle-conns or --conn-max-lifetime times that pool instead of the presets.
+func (c *poolCmd) run(ctx context.Context) error {
+	if len(c.Clients) == 0 {
+		c.Clients = []int{1, 4, 16}
+	}
+	records, err := c.records(ctx, c.N)
+	if err != nil {
+		return fmt.Errorf("loading records: %w", err)
+	}
+	boot, err := c.boot()
+	if err != nil {
+		return fmt.Errorf("parsing connection settings: %w", err)
+	}
+	pools := poolPresets
+	if pool := c.pool(); pool != (duckbench.Pool{}) {
+		pools = []namedPool{{"flags", pool}}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "POOL\tCLIENTS\tQUERIES/S\tMEDIAN\tP99\tWAITS\tWAITED\tCLOSED")
+	for _, p := range pools {
+		slog.Info("loading records", "pool", p.name, "rows", len(records))
+		results, err := c.load(ctx, duckbench.DBOptions{OnConnect: boot, Pool: p.pool}, records)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+		for _, r := range results {
+			fmt.Fprintf(tw, "%s\t%d\t%.0f\t%v\t%v\t%d\t%v\t%d\n", p.name, r.Clients, r.QueriesPerSecond(),
+				r.Latency.Median.Round(time.Microsecond), r.P99.Round(time.Microsecond), r.Waits,
+				r.WaitDuration.Round(time.Millisecond), r.Closed)
+		}
+	}
+	return tw.Flush()
+}
+
+// load runs the query at each client count against a fresh database holding records.
+func (c *poolCmd) load(ctx context.Context, opts duckbench.DBOptions, records []duckbench.Record) ([]duckbench.LoadResult, error) {
+	db, err := duckbench.CreateDB(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		return nil, fmt.Errorf("creating table: %w", err)
+	}
+	if err := duckbench.AppenderInsert(ctx, records, db); err != nil {
+		return nil, fmt.Errorf("inserting records: %w", err)
+	}
+	var results []duckbench.LoadResult
+	for _, clients := range c.Clients {
+		r, err := duckbench.QueryLoad(ctx, db, c.Query, clients, c.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("%d clients: %w", clients, err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/compare"
+	"github.com/rpep/duckdb-go-experiments/pkg/dashboard"
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+	"github.com/rpep/duckdb-go-experiments/pkg/metrics"
+	"github.com/rpep/duckdb-go-experiments/pkg/profiling"
+	"github.com/rpep/duckdb-go-experiments/pkg/report"
+	"github.com/rpep/duckdb-go-experiments/pkg/results"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+	"github.com/rpep/duckdb-go-experiments/pkg/telemetry"
+	"github.com/rpep/duckdb-go-experiments/pkg/verify"
+)
+
+// statsCmd aims to do some basic performance comparison between calculating statistics in Go and in DuckDB. It
+// times inserting the records into DuckDB with each insert method first, calculating the statistics on the table the
+// last one inserted.
+type statsCmd struct {
+	dataArgs
+	connArgs
+	insertArgs
+
+	LogSQL      bool
+	AuditLog    string
+	MetricsAddr string
+	ResultsDB   string
+
+	profileArgs
+	ProfilePhase string
+
+	OTLP bool
+
+	SampleInterval time.Duration
+	DuckDBProfile  bool
+	Explain        bool
+
+	InfluxFile string
+	InfluxURL  string
+
+	SkipVerify  bool
+	Epsilon     float64
+	Summation   duckbench.Summation
+	Threads     int
+	Percentiles []float64
+
+	Workloads     []string
+	HistogramBins int
+	TopK          int
+	Bucket        time.Duration
+	Groups        int
+
+	Timeout time.Duration
+	Repeat  int
+	Warmup  int
+
+	N           int
+	NullRatio   float64
+	SaveDataset string
+	ChunkSize   int
+
+	Format string
+
+	Dashboard bool
+
+	DBFile string
+}
+
+func newStatsCmd(log *logging.Args) *cobra.Command {
+	c := new(statsCmd)
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Time calculating statistics in Go and in DuckDB, after inserting the records",
+		Args:  cobra.NoArgs,
+		RunE:  func(cmd *cobra.Command, _ []string) error { return c.run(cmd.Context(), *log) },
+	}
+	fs := cmd.Flags()
+	c.dataArgs.addFlags(fs)
+	c.connArgs.addFlags(fs)
+	c.insertArgs.addFlags(fs)
+	fs.BoolVar(&c.LogSQL, "log-sql", false, "log every SQL statement with its duration, rows affected and arguments")
+	fs.StringVar(&c.AuditLog, "audit-log", "", "write every SQL statement with its arguments, duration and outcome to this file as JSON lines, marking those preparing or verifying what is timed as setup")
+	fs.StringVar(&c.MetricsAddr, "metrics-addr", "", "serve Prometheus metrics on /metrics and per-statement latencies on /debug/queries at this address, e.g. :9090")
+	fs.StringVar(&c.ResultsDB, "results-db", "", "append the results of this run to a DuckDB results database at this path")
+	c.profileArgs.addFlags(fs)
+	fs.StringVar(&c.ProfilePhase, "profile-phase", "", "only profile and trace this phase: generate, insert, stats or query [default: the whole run]")
+	fs.BoolVar(&c.OTLP, "otlp", false, "export OpenTelemetry spans for the run, its phases and statements, configured by OTEL_EXPORTER_OTLP_* variables")
+	fs.DurationVar(&c.SampleInterval, "sample-interval", 100*time.Millisecond, "how often to sample Go and DuckDB memory usage, 0 to disable")
+	fs.BoolVar(&c.DuckDBProfile, "duckdb-profile", false, "capture DuckDB's operator-level profile of the statistics query")
+	fs.BoolVar(&c.Explain, "explain", false, "run EXPLAIN ANALYZE on the statistics query, printing DuckDB's operator timings on stderr next to the wall-clock time in Go")
+	fs.StringVar(&c.InfluxFile, "influx-file", "", "write the run's metrics to this file in InfluxDB line protocol")
+	fs.StringVar(&c.InfluxURL, "influx-url", "", "push the run's metrics in line protocol to this write endpoint, authenticating with $INFLUX_TOKEN")
+	fs.BoolVar(&c.SkipVerify, "skip-verify", false, "don't read the table back after ingestion to check it holds exactly the generated records")
+	fs.Float64Var(&c.Epsilon, "epsilon", 1e-9, "relative difference allowed between the Go and DuckDB statistics, beyond which the run fails")
+	textVar(fs, &c.Summation, "summation", duckbench.SumPairwise, "how the Go engine sums values: naive, pairwise or neumaier")
+	fs.IntVar(&c.Threads, "threads", 0, "goroutines for the Go engine's statistics, also set as DuckDB's threads so both have the same parallelism [default: Go single-threaded, DuckDB a thread per CPU]")
+	fs.Func("percentile", "`percentile` for both engines to calculate, between 0 and 1 (repeatable) [default: 0.25, 0.75, 0.95 and 0.99]", func(s string) error {
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		c.Percentiles = append(c.Percentiles, p)
+		return nil
+	})
+	fs.StringSliceVar(&c.Workloads, "workload", nil, "workload to run in both engines after the statistics: histogram, correlation, regression, grouped, topk, distinct, approx_distinct, approx_quantile or time_bucket (repeatable) [default: all, time_bucket only for --dist timeseries]")
+	fs.IntVar(&c.HistogramBins, "histogram-bins", 20, "bins of the fixed-width histogram workload")
+	fs.IntVar(&c.TopK, "top-k", 10, "most frequent values found by the topk workload")
+	fs.DurationVar(&c.Bucket, "bucket", time.Minute, "width of the buckets of the time_bucket workload")
+	fs.IntVar(&c.Groups, "groups", 10, "categories the generated records are assigned to for the grouped workload, e.g. 10, 1000 or 1000000")
+	fs.DurationVar(&c.Timeout, "timeout", 0, "cancel the benchmark if it runs for longer than this, e.g. 10m")
+	fs.IntVar(&c.Repeat, "repeat", 1, "time each insert method and engine this many times, reporting the median and spread")
+	fs.IntVar(&c.Warmup, "warmup", 0, "untimed runs of each insert method and engine before those repeated")
+	fs.IntVarP(&c.N, "n", "n", 1000000, "number of records to generate")
+	fs.Float64Var(&c.NullRatio, "null-ratio", 0, "fraction of the generated values to make NULL, which both engines skip and count separately from the non-finite values")
+	fs.StringVar(&c.SaveDataset, "save-dataset", "", "save the generated records to this .csv or .parquet file, for replaying with --dataset")
+	fs.IntVar(&c.ChunkSize, "chunk-size", 0, "generate the records this many at a time, streaming them through the inserts and a single-pass Go engine, for an -n too large for memory; leaves out the median, percentiles, gonum and workloads")
+	fs.StringVar(&c.Format, "format", "text", "output format: text logs only, or json, markdown or html to also print a report of the run's timings and statistics on stdout")
+	fs.BoolVar(&c.Dashboard, "dashboard", false, "show the current phase, throughput, memory usage and ETA on stderr while running")
+	fs.StringVar(&c.DBFile, "dbfile", "", "run against a new database file at this path, after an in-memory run to compare it with")
+	return cmd
+}
+
+// workloadNames lists the workloads of --workload, in the order they run by default.
+var workloadNames = []string{"histogram", "correlation", "regression", "grouped", "topk", "distinct", "approx_distinct", "approx_quantile", "time_bucket"}
+
+// workloads returns the workloads named by --workload, or all of them.
+func (c *statsCmd) workloads() ([]duckbench.Workload, error) {
+	available := map[string]duckbench.Workload{
+		"histogram":   duckbench.Histogram{Bins: c.HistogramBins},
+		"correlation": duckbench.Correlation{},
+		"regression":  duckbench.Regression{},
+		"grouped":     duckbench.GroupedStatistics{},
+		"topk":        duckbench.TopK{K: c.TopK},
+		"distinct":    duckbench.Distinct{},
+		// the sketches estimate the same percentiles as the exact statistics
+		"approx_distinct": duckbench.ApproxDistinct{},
+		"approx_quantile": duckbench.ApproxQuantiles{Quantiles: c.Percentiles},
+		"time_bucket":     duckbench.TimeBuckets{Width: c.Bucket},
+	}
+	names := c.Workloads
+	if len(names) == 0 && c.ChunkSize > 0 {
+		// the workloads need every record in memory
+		return nil, nil
+	}
+	if len(names) == 0 {
+		names = workloadNames
+		// only the time series has timestamps to bucket
+		if c.Distribution != duckbench.DistTimeSeries {
+			names = slices.DeleteFunc(slices.Clone(names), func(name string) bool { return name == "time_bucket" })
+		}
+	}
+	var workloads []duckbench.Workload
+	for _, name := range names {
+		w, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown workload %q, expected one of %v", name, workloadNames)
+		}
+		workloads = append(workloads, w)
+	}
+	return workloads, nil
+}
+
+// parameters are the flags which change what a run measures, under which duckbench history tells runs apart.
+func (c *statsCmd) parameters() map[string]string {
+	p := map[string]string{
+		"dist":      string(c.Distribution),
+		"seed":      fmt.Sprint(c.Seed),
+		"summation": string(c.Summation),
+		"threads":   fmt.Sprint(c.Threads),
+		"groups":    fmt.Sprint(c.Groups),
+	}
+	if c.NullRatio > 0 {
+		p["null_ratio"] = fmt.Sprint(c.NullRatio)
+	}
+	if c.Dataset != "" {
+		p["dataset"] = c.Dataset
+	}
+	if c.DBFile != "" {
+		p["storage"] = "file"
+	}
+	if len(c.Set) > 0 {
+		p["set"] = strings.Join(c.Set, ",")
+	}
+	return p
+}
+
+// profilePhase returns a hook which runs p for the duration of a single phase.
+func profilePhase(p *profiling.Profiler, phase duckbench.Phase) duckbench.PhaseHook {
+	return func(ctx context.Context, current duckbench.Phase) (context.Context, func()) {
+		if current != phase {
+			return ctx, func() {}
+		}
+		if err := p.Start(); err != nil {
+			slog.Error("starting profiler", "phase", phase, "err", err)
+			return ctx, func() {}
+		}
+		return ctx, func() {
+			if err := p.Stop(); err != nil {
+				slog.Error("writing profiles", "phase", phase, "err", err)
+			}
+		}
+	}
+}
+
+func (c *statsCmd) run(ctx context.Context, log logging.Args) error {
+	if !slices.Contains([]string{"text", "json", "markdown", "html"}, c.Format) {
+		return fmt.Errorf("selecting output format: unknown format %q, expected text, json, markdown or html", c.Format)
+	}
+
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	cfg := duckbench.Config{
+		N:              c.N,
+		Distribution:   c.Distribution,
+		Seed:           c.Seed,
+		Groups:         c.Groups,
+		NullRatio:      c.NullRatio,
+		SampleInterval: c.SampleInterval,
+		Metrics:        metrics.Default,
+		ProfileQueries: c.DuckDBProfile,
+		ExplainAnalyze: c.Explain,
+		LogPlans:       log.LogLevel <= slog.LevelDebug,
+		Summation:      c.Summation,
+		Percentiles:    c.Percentiles,
+		Threads:        c.Threads,
+		Repeat:         c.Repeat,
+		Warmup:         c.Warmup,
+		SkipVerify:     c.SkipVerify,
+		ChunkSize:      c.ChunkSize,
+		Inserts:        c.strategies(),
+	}
+	if conns := cfg.Conns(); c.MaxOpenConns > 0 && c.MaxOpenConns < conns {
+		return fmt.Errorf("--max-open-conns %d is fewer than the %d connections the run holds at once", c.MaxOpenConns, conns)
+	}
+	if c.ChunkSize == 0 {
+		// gonum/stat is a tuned library to cross-check the hand-rolled Go engine and DuckDB with
+		cfg.Engines = []duckbench.StatisticsEngine{{Name: "gonum", Statistics: compare.GonumStatistics}}
+	}
+	workloads, err := c.workloads()
+	if err != nil {
+		return fmt.Errorf("selecting workloads: %w", err)
+	}
+	cfg.Workloads = workloads
+	if c.LogSQL {
+		cfg.DB.Hooks = append(cfg.DB.Hooks, sqlwrap.Logger(slog.Default()))
+	}
+	if c.AuditLog != "" {
+		f, err := os.Create(c.AuditLog)
+		if err != nil {
+			return fmt.Errorf("creating audit log: %w", err)
+		}
+		audit := sqlwrap.NewAudit(f)
+		cfg.DB.Hooks = append(cfg.DB.Hooks, audit)
+		defer func() {
+			err := audit.Err()
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				slog.Error("writing audit log", "err", err)
+			}
+		}()
+	}
+	if c.MetricsAddr != "" {
+		shapes := sqlwrap.NewShapes(metrics.Default)
+		cfg.DB.Hooks = append(cfg.DB.Hooks, sqlwrap.Metrics(metrics.Default), shapes)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Default)
+		mux.Handle("/debug/queries", shapes)
+		go func() {
+			logging.Fatal("serving metrics", http.ListenAndServe(c.MetricsAddr, mux))
+		}()
+	}
+	boot, err := c.boot()
+	if err != nil {
+		return fmt.Errorf("parsing connection settings: %w", err)
+	}
+	cfg.DB.OnConnect = boot
+	cfg.DB.Pool = c.pool()
+	cfg.SaveDataset = c.SaveDataset
+	if c.Dataset != "" {
+		cfg.Records, err = duckbench.LoadDataset(ctx, c.Dataset)
+		if err != nil {
+			return fmt.Errorf("loading dataset: %w", err)
+		}
+		cfg.N = len(cfg.Records)
+	}
+
+	// the baseline only repeats the workload, without the observability of the run being compared with it
+	var baseline *duckbench.Results
+	if c.DBFile != "" {
+		if _, err := os.Stat(c.DBFile); err == nil {
+			return fmt.Errorf("opening database file: %s already exists", c.DBFile)
+		}
+		slog.Info("running in-memory baseline", "rows", cfg.N)
+		res, err := duckbench.Run(ctx, duckbench.Config{
+			N:            cfg.N,
+			Distribution: cfg.Distribution,
+			Seed:         cfg.Seed,
+			Groups:       cfg.Groups,
+			NullRatio:    cfg.NullRatio,
+			Records:      cfg.Records,
+			Inserts:      cfg.Inserts,
+			Summation:    cfg.Summation,
+			Percentiles:  cfg.Percentiles,
+			Threads:      cfg.Threads,
+			Repeat:       cfg.Repeat,
+			Warmup:       cfg.Warmup,
+			SkipVerify:   cfg.SkipVerify,
+			ChunkSize:    cfg.ChunkSize,
+			DB:           duckbench.DBOptions{OnConnect: cfg.DB.OnConnect, Pool: cfg.DB.Pool},
+		})
+		if err != nil {
+			return fmt.Errorf("running in-memory baseline: %w", err)
+		}
+		baseline = &res
+		cfg.DB.Path = c.DBFile
+	}
+
+	c.servePprof()
+	profiler, err := c.profiler()
+	if err != nil {
+		return fmt.Errorf("creating profiles: %w", err)
+	}
+	wholeRun := profiler.Enabled() && c.ProfilePhase == ""
+	if profiler.Enabled() && !wholeRun {
+		phase := duckbench.Phase(c.ProfilePhase)
+		if !slices.Contains(duckbench.Phases, phase) {
+			return fmt.Errorf("selecting profile phase: unknown phase %q, expected one of %v", phase, duckbench.Phases)
+		}
+		cfg.PhaseHooks = append(cfg.PhaseHooks, profilePhase(profiler, phase))
+	}
+	if wholeRun && c.CPUProfile != "" {
+		// label samples by phase, so cmd/flamegraph can split the profile per phase
+		cfg.PhaseHooks = append(cfg.PhaseHooks, func(ctx context.Context, phase duckbench.Phase) (context.Context, func()) {
+			return profiling.Label(ctx, "phase", string(phase))
+		})
+	}
+	// Tasks are registered after profilePhase, so a phase's task begins once its trace has started.
+	if profiler.Tracing() {
+		cfg.PhaseHooks = append(cfg.PhaseHooks, func(ctx context.Context, phase duckbench.Phase) (context.Context, func()) {
+			return profiling.Task(ctx, string(phase))
+		})
+	}
+
+	if c.OTLP {
+		shutdown, err := telemetry.Setup(ctx, "duckbench")
+		if err != nil {
+			return fmt.Errorf("setting up tracing: %w", err)
+		}
+		defer func() {
+			if err := shutdown(context.Background()); err != nil {
+				slog.Error("flushing spans", "err", err)
+			}
+		}()
+		cfg.DB.Hooks = append(cfg.DB.Hooks, sqlwrap.Tracing(otel.GetTracerProvider()))
+		cfg.PhaseHooks = append(cfg.PhaseHooks, func(ctx context.Context, phase duckbench.Phase) (context.Context, func()) {
+			return telemetry.Span(ctx, string(phase))
+		})
+		var end func()
+		ctx, end = telemetry.Span(ctx, "run")
+		defer end()
+	}
+
+	slog.Info("inserting records into DuckDB", "rows", cfg.N, "dist", cfg.Distribution, "seed", cfg.Seed)
+	if wholeRun {
+		if err := profiler.Start(); err != nil {
+			return fmt.Errorf("starting profiler: %w", err)
+		}
+	}
+	var dash *dashboard.Dashboard
+	if c.Dashboard {
+		cfg.Progress = new(duckbench.Progress)
+		dash = &dashboard.Dashboard{W: os.Stderr, Progress: cfg.Progress}
+		if cfg.SampleInterval > 0 {
+			dash.Registry = cfg.Metrics
+		}
+		dash.Start()
+	}
+	res, err := duckbench.Run(ctx, cfg)
+	if dash != nil {
+		dash.Stop()
+	}
+	if wholeRun {
+		if err := profiler.Stop(); err != nil {
+			slog.Error("writing profiles", "err", err)
+		}
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("benchmark cancelled: %w", err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("benchmark exceeded --timeout: %w", err)
+	case err != nil:
+		return fmt.Errorf("running benchmark: %w", err)
+	}
+	inserts := make(map[string]time.Duration)
+	for _, r := range res.Inserts {
+		slog.Info("phase complete", "phase", duckbench.PhaseInsert, "method", r.Name, "rows", r.Rows, "duration", r.Duration,
+			"rows_per_sec", r.RowsPerSecond())
+		inserts[r.Name] = r.Duration
+	}
+	if standard, appender := inserts["standard"], inserts["appender"]; standard > 0 && appender > 0 {
+		slog.Info("insert speedup", "appender_over_standard", standard.Seconds()/appender.Seconds())
+	}
+	slog.Info("phase complete", "phase", duckbench.PhaseStats, "method", "go", "rows", res.N, "duration", res.GoDuration, "stats", res.GoStats)
+	slog.Info("phase complete", "phase", duckbench.PhaseQuery, "method", "duckdb", "rows", res.N, "duration", res.DBDuration, "stats", res.DBStats,
+		"total_with_insert", res.DBDuration+res.InsertDuration)
+	for _, r := range res.Engines {
+		slog.Info("phase complete", "phase", duckbench.PhaseStats, "method", r.Name, "rows", r.Rows, "duration", r.Duration, "stats", *r.Stats)
+	}
+	for _, w := range res.Workloads {
+		slog.Info("workload complete", "workload", w.Name, "go", w.Go.Duration, "duckdb", w.DuckDB.Duration,
+			"go_over_duckdb", w.Go.Duration.Seconds()/w.DuckDB.Duration.Seconds())
+		slog.Debug("workload results", "workload", w.Name, "go", w.GoResult, "duckdb", w.DBResult)
+		if w.Exact != nil {
+			slog.Info("estimation error", "workload", w.Name, "go", w.GoError, "duckdb", w.DBError, "accuracy", w.Accuracy)
+		}
+	}
+	for _, r := range slices.Concat(res.Inserts, res.Report().Statistics) {
+		if len(r.Samples) > 0 {
+			slog.Info("repeat timing", "method", r.Name, "samples", len(r.Samples), "timing", r.Timing())
+		}
+	}
+
+	if baseline != nil {
+		for i, r := range res.Inserts {
+			memory := baseline.Inserts[i].Duration
+			slog.Info("storage comparison", "step", "insert", "method", r.Name, "memory", memory, "file", r.Duration,
+				"file_over_memory", r.Duration.Seconds()/memory.Seconds())
+		}
+		slog.Info("storage comparison", "step", "checkpoint", "file", res.CheckpointDuration)
+		slog.Info("storage comparison", "step", "query", "memory", baseline.DBDuration, "file", res.DBDuration,
+			"file_over_memory", res.DBDuration.Seconds()/baseline.DBDuration.Seconds())
+	}
+
+	slog.Info("engine drift", "summation", c.Summation,
+		"mean_rel_diff", floatcmp.RelDiff(res.GoStats.Mean, res.DBStats.Mean),
+		"stddev_rel_diff", floatcmp.RelDiff(res.GoStats.StdDev, res.DBStats.StdDev))
+
+	if p := res.QueryProfile; p != nil {
+		slog.Info("duckdb profile", "query", p.Query, "timing", p.Timing, "wall_clock", res.DBDuration)
+		for _, op := range p.Operators {
+			slog.Info("duckdb operator", "name", op.Name, "depth", op.Depth, "timing", op.Timing, "cardinality", op.Cardinality)
+		}
+	}
+
+	if e := res.Explain; e != nil {
+		fmt.Fprintln(os.Stderr, e.Plan)
+		slog.Info("explain analyze", "duckdb_total", e.Total, "wall_clock", e.WallClock, "outside_duckdb", e.WallClock-e.Total)
+	}
+
+	for _, phase := range duckbench.Phases {
+		if m, ok := res.Memory[phase]; ok {
+			slog.Info("memory usage", "phase", phase, "memory", m)
+		}
+	}
+	for _, phase := range duckbench.Phases {
+		if peak, ok := res.PeakMemory[phase]; ok {
+			slog.Info("peak memory", "phase", phase, "go_heap_bytes", peak.GoHeapAlloc,
+				"duckdb_memory_bytes", peak.DuckDBMemory, "duckdb_temp_bytes", peak.DuckDBTempStorage)
+		}
+	}
+	for _, phase := range duckbench.Phases {
+		if u, ok := res.Process[phase]; ok {
+			slog.Info("process usage", "phase", phase, "cpu_percent", u.CPUPercent(), "cpu_time", u.CPUTime,
+				"peak_rss_bytes", u.PeakRSS, "read_bytes", u.ReadBytes, "write_bytes", u.WriteBytes)
+		}
+	}
+	for _, phase := range duckbench.Phases {
+		if gc, ok := res.GC[phase]; ok {
+			slog.Info("gc impact", "phase", phase, "cycles", gc.Cycles, "pauses", gc.Pauses, "pause_total", gc.PauseTotal,
+				"gc_cpu", gc.CPU, "alloc_bytes", gc.AllocBytes, "alloc_objects", gc.AllocObjects, "alloc_bytes_per_sec", gc.AllocRate())
+		}
+	}
+	if len(res.Samples) > 0 {
+		slog.Info("resource usage", "samples", len(res.Samples), "gc_cycles", res.Samples[len(res.Samples)-1].GoNumGC)
+	}
+
+	title := fmt.Sprintf("duckbench: %s values, seed %d", c.Distribution, c.Seed)
+	if c.Dataset != "" {
+		title = "duckbench: " + c.Dataset
+	}
+	var reportErr error
+	switch c.Format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		reportErr = enc.Encode(struct {
+			duckbench.Report
+			Distribution duckbench.Distribution `json:"distribution"`
+			Seed         uint64                 `json:"seed"`
+			Dataset      string                 `json:"dataset,omitempty"`
+		}{res.Report(), c.Distribution, c.Seed, c.Dataset})
+	case "markdown":
+		reportErr = report.WriteMarkdown(os.Stdout, res.Report(), title)
+	case "html":
+		reportErr = report.WriteHTML(os.Stdout, res.Report(), title)
+	}
+	if reportErr != nil {
+		return fmt.Errorf("writing report: %w", reportErr)
+	}
+
+	tags := metrics.Labels{"n": fmt.Sprint(res.N)}
+	if c.InfluxFile != "" {
+		f, err := os.Create(c.InfluxFile)
+		if err != nil {
+			return fmt.Errorf("creating line protocol file: %w", err)
+		}
+		err = metrics.Default.WriteLineProtocol(f, res.Started, tags)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("writing line protocol: %w", err)
+		}
+	}
+	if c.InfluxURL != "" {
+		if err := metrics.Default.PushLineProtocol(ctx, c.InfluxURL, os.Getenv("INFLUX_TOKEN"), res.Started, tags); err != nil {
+			return fmt.Errorf("pushing metrics: %w", err)
+		}
+	}
+
+	if c.ResultsDB != "" {
+		store, err := results.Open(ctx, c.ResultsDB)
+		if err != nil {
+			return fmt.Errorf("opening results database: %w", err)
+		}
+		defer store.Close()
+		if err := store.Save(ctx, res, results.Run{GitSHA: results.GitSHA(), Parameters: c.parameters()}); err != nil {
+			return fmt.Errorf("saving results: %w", err)
+		}
+	}
+
+	// checked last, so a failing run's results are still recorded for investigation
+	if err := verify.Results(res, floatcmp.Rel(c.Epsilon)); err != nil {
+		return fmt.Errorf("verifying statistics: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// sweepCmd repeats the statistics benchmark under each combination of DuckDB settings, to show how they move the
+// point at which DuckDB overtakes the Go engine.
+type sweepCmd struct {
+	dataArgs
+	connArgs
+
+	N            int
+	Insert       duckbench.InsertMethod
+	Threads      []int
+	MemoryLimits []string
+	Summation    duckbench.Summation
+}
+
+func newSweepCmd() *cobra.Command {
+	c := new(sweepCmd)
+	cmd := &cobra.Command{
+		Use:   "sweep",
+		Short: "Repeat the statistics benchmark under each combination of DuckDB threads and memory_limit settings",
+		Args:  cobra.NoArgs,
+		RunE:  func(cmd *cobra.Command, _ []string) error { return c.run(cmd.Context()) },
+	}
+	fs := cmd.Flags()
+	c.dataArgs.addFlags(fs)
+	c.connArgs.addFlags(fs)
+	fs.IntVarP(&c.N, "n", "n", 1000000, "number of records to generate")
+	textVar(fs, &c.Insert, "insert", duckbench.InsertAppender, "insert method to load the records with before each run: standard, appender, values, csv or arrow")
+	fs.IntSliceVar(&c.Threads, "threads", nil, "DuckDB threads setting to run with (repeatable) [default: 1 up to the number of CPUs]")
+	fs.StringArrayVar(&c.MemoryLimits, "memory-limit", nil, "DuckDB memory_limit setting to run with, e.g. 512MB (repeatable) [default: DuckDB's default]")
+	textVar(fs, &c.Summation, "summation", duckbench.SumPairwise, "how the Go engine sums values: naive, pairwise or neumaier")
+	return cmd
+}
+
+func (c *sweepCmd) run(ctx context.Context) error {
+	if len(c.Threads) == 0 {
+		for t := 1; t <= runtime.NumCPU(); t++ {
+			c.Threads = append(c.Threads, t)
+		}
+	}
+	boot, err := c.boot()
+	if err != nil {
+		return fmt.Errorf("parsing connection settings: %w", err)
+	}
+	cfg := duckbench.Config{
+		N:            c.N,
+		Distribution: c.Distribution,
+		Seed:         c.Seed,
+		Inserts:      []duckbench.InsertStrategy{c.Insert},
+		Summation:    c.Summation,
+		DB:           duckbench.DBOptions{OnConnect: boot, Pool: c.pool()},
+	}
+	if c.Dataset != "" {
+		if cfg.Records, err = duckbench.LoadDataset(ctx, c.Dataset); err != nil {
+			return fmt.Errorf("loading dataset: %w", err)
+		}
+	}
+	settings := duckbench.SweepSettings(c.Threads, c.MemoryLimits)
+	slog.Info("sweeping DuckDB settings", "runs", len(settings), "rows", c.N, "dist", c.Distribution, "seed", c.Seed)
+	sweep, err := duckbench.Sweep(ctx, cfg, settings)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "THREADS\tMEMORY_LIMIT\tGO\tDUCKDB\tGO/DUCKDB")
+	for _, s := range sweep {
+		threads, limit := "default", "default"
+		if s.Settings.Threads > 0 {
+			threads = fmt.Sprint(s.Settings.Threads)
+		}
+		if s.Settings.MemoryLimit != "" {
+			limit = s.Settings.MemoryLimit
+		}
+		res := s.Results
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%v\t%.2f\n", threads, limit, res.GoDuration, res.DBDuration,
+			res.GoDuration.Seconds()/res.DBDuration.Seconds())
+	}
+	return tw.Flush()
+}
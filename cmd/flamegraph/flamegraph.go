@@ -0,0 +1,86 @@
+// This program converts a CPU profile captured with --cpuprofile into folded stacks and flame graph SVGs, one per
+// benchmark phase, so the split between Go, the driver and the DuckDB engine is obvious at a glance.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/google/pprof/profile"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/flamegraph"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	Profile string `arg:"positional,required" help:"CPU profile to convert"`
+	Out     string `arg:"--out" default:"." help:"directory to write the .folded and .svg files to"`
+	Label   string `arg:"--label" default:"phase" help:"pprof label to split the profile by, empty for a single graph"`
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+
+	f, err := os.Open(args.Profile)
+	if err != nil {
+		logging.Fatal("opening profile", err)
+	}
+	p, err := profile.Parse(f)
+	f.Close()
+	if err != nil {
+		logging.Fatal("parsing profile", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(args.Profile), filepath.Ext(args.Profile))
+	groups := flamegraph.Fold(p, args.Label)
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stacks := groups[name]
+		file, title := base, base
+		switch {
+		case args.Label == "":
+		case name == "":
+			file += "-unlabelled"
+			title += " (no " + args.Label + ")"
+		default:
+			file += "-" + name
+			title += fmt.Sprintf(" (%s %s)", args.Label, name)
+		}
+		if err := write(filepath.Join(args.Out, file+".folded"), stacks.WriteFolded); err != nil {
+			logging.Fatal("writing folded stacks", err)
+		}
+		svg := filepath.Join(args.Out, file+".svg")
+		if err := write(svg, func(w io.Writer) error { return stacks.WriteSVG(w, title) }); err != nil {
+			logging.Fatal("writing flame graph", err)
+		}
+		slog.Info("wrote flame graph", "group", name, "path", svg, "stacks", len(stacks), "total", stacks.Total())
+	}
+}
+
+func write(path string, fn func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
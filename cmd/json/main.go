@@ -0,0 +1,107 @@
+// JSON stores a JSON document per generated record in a DuckDB JSON column, ingested one at a time from Go
+// structs or maps, and times aggregating them per category with json_extract in DuckDB against reading them back
+// and unmarshalling and aggregating them in Go, and against the same aggregate over typed columns.
+//
+// The json extension is bundled with go-duckdb v1.8.3, so it is only loaded, with no network access.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+	"github.com/rpep/duckdb-go-experiments/pkg/verify"
+)
+
+type args struct {
+	logging.Args
+
+	N            int                    `arg:"-n" default:"1000000" help:"number of documents to generate"`
+	Distribution duckbench.Distribution `arg:"--dist" default:"normal" help:"distribution of the generated values: sequential, uniform, normal, exponential, zipf or timeseries"`
+	Seed         uint64                 `arg:"--seed" default:"1" help:"seed for the random distributions"`
+	Groups       int                    `arg:"--groups" default:"10" help:"categories the documents are assigned to, or 0 for none"`
+	Epsilon      float64                `arg:"--epsilon" default:"1e-9" help:"relative difference allowed between the statistics of each engine"`
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+
+	records, err := duckbench.GenerateDistribution(args.N, args.Distribution, args.Seed)
+	if err != nil {
+		logging.Fatal("generating records", err)
+	}
+	duckbench.AssignCategories(records, args.Groups, args.Seed)
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{OnConnect: duckbench.JSONExtension})
+	if err != nil {
+		logging.Fatal("creating DuckDB database with the json extension", err)
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		logging.Fatal("creating records table", err)
+	}
+	if err := duckbench.AppenderInsert(ctx, records, db); err != nil {
+		logging.Fatal("inserting records", err)
+	}
+
+	var steps []duckbench.BenchmarkResult
+	timed := func(name string, fn func() error) {
+		step, err := duckbench.Measure(name, len(records), fn)
+		steps = append(steps, step)
+		if err != nil {
+			logging.Fatal(name, err)
+		}
+	}
+	results := make(map[string]duckbench.Groups)
+
+	var want duckbench.Groups
+	timed("duckdb (typed columns)", func() error {
+		res, err := duckbench.GroupedStatistics{}.DuckDB(ctx, db)
+		want, _ = res.(duckbench.Groups)
+		return err
+	})
+	for _, format := range duckbench.DocumentFormats {
+		if err := duckbench.CreateDocumentsTable(ctx, db); err != nil {
+			logging.Fatal("creating documents table", err)
+		}
+		timed(fmt.Sprintf("ingest (from %s)", format), func() error {
+			return duckbench.InsertDocuments(ctx, db, records, format)
+		})
+	}
+	timed("duckdb (json_extract)", func() (err error) {
+		results["duckdb json_extract"], err = duckbench.DocumentGroupsFromDB(ctx, db)
+		return err
+	})
+	for _, format := range duckbench.DocumentFormats {
+		name := fmt.Sprintf("go (unmarshal into %s)", format)
+		timed(name, func() (err error) {
+			results[name], err = duckbench.DocumentGroups(ctx, db, format)
+			return err
+		})
+	}
+	// a category's mean can be near zero, so small absolute differences are allowed too
+	tol := floatcmp.Tolerance{Rel: args.Epsilon, Abs: args.Epsilon}
+	for name, groups := range results {
+		if m := want.Compare(groups, tol); len(m) > 0 {
+			logging.Fatal("verifying "+name, &verify.DivergenceError{A: "typed columns", B: name, Tolerance: tol, Mismatches: m})
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tDURATION\tROWS/S")
+	for _, s := range steps {
+		fmt.Fprintf(tw, "%s\t%v\t%.0f\n", s.Name, s.Duration, s.RowsPerSecond())
+	}
+	tw.Flush()
+	fmt.Printf("the statistics of %d categories of %d documents agree within %g\n", len(want), len(records), args.Epsilon)
+}
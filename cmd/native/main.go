@@ -0,0 +1,124 @@
+// Native times the same row by row inserts and full table scans through database/sql and straight through the
+// go-duckdb driver connection beneath it, which it is handed by sql.Conn.Raw, to put a number on what database/sql
+// costs on top of the driver. Each step is repeated and the median timing reported, taking turns so that neither
+// side runs on a warmer database.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	Inserts      int                    `arg:"--inserts" default:"100000" help:"number of records to insert a row at a time"`
+	N            int                    `arg:"-n" default:"1000000" help:"number of records to scan"`
+	Distribution duckbench.Distribution `arg:"--dist" default:"timeseries" help:"distribution of the generated values: sequential, uniform, normal, exponential, zipf or timeseries"`
+	Seed         uint64                 `arg:"--seed" default:"1" help:"seed for the random distributions"`
+	Groups       int                    `arg:"--groups" default:"10" help:"number of categories to give the records, or 0 for none"`
+	Repeat       int                    `arg:"--repeat" default:"3" help:"times to repeat each step"`
+}
+
+// pair is a step timed through database/sql and through the driver.
+type pair struct {
+	name        string
+	rows        int
+	sql, native []time.Duration
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+	if args.Repeat < 1 {
+		args.Repeat = 1
+	}
+
+	records, err := duckbench.GenerateDistribution(max(args.N, args.Inserts), args.Distribution, args.Seed)
+	if err != nil {
+		logging.Fatal("generating records", err)
+	}
+	duckbench.AssignCategories(records, args.Groups, args.Seed)
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer db.Close()
+
+	timed := func(name string, rows int, fn func() error) time.Duration {
+		res, err := duckbench.Measure(name, rows, fn)
+		if err != nil {
+			logging.Fatal(name, err)
+		}
+		return res.Duration
+	}
+
+	// every insert goes into an empty table
+	fresh := func() {
+		if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS records; DROP SEQUENCE IF EXISTS seq_records_id"); err != nil {
+			logging.Fatal("dropping records table", err)
+		}
+		if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+			logging.Fatal("creating records table", err)
+		}
+	}
+
+	insert := pair{name: "insert (prepared, row by row)", rows: args.Inserts}
+	for range args.Repeat {
+		for _, side := range []struct {
+			name    string
+			fn      func(context.Context, []duckbench.Record, *sql.DB) error
+			samples *[]time.Duration
+		}{
+			{"database/sql", duckbench.PreparedInsert, &insert.sql},
+			{"driver", duckbench.NativeInsert, &insert.native},
+		} {
+			fresh()
+			*side.samples = append(*side.samples, timed("inserting through "+side.name, args.Inserts, func() error {
+				return side.fn(ctx, records[:args.Inserts], db)
+			}))
+		}
+	}
+
+	fresh()
+	if err := duckbench.AppenderInsert(ctx, records[:args.N], db); err != nil {
+		logging.Fatal("inserting records", err)
+	}
+	scan := pair{name: "scan", rows: args.N}
+	var want, got duckbench.ScanSummary
+	for range args.Repeat {
+		scan.sql = append(scan.sql, timed("scanning through database/sql", args.N, func() (err error) {
+			want, err = duckbench.ScanRecords(ctx, db)
+			return err
+		}))
+		scan.native = append(scan.native, timed("scanning through the driver", args.N, func() (err error) {
+			got, err = duckbench.NativeScanRecords(ctx, db)
+			return err
+		}))
+	}
+	if got != want || want.Rows != args.N {
+		logging.Fatal("checking scans", fmt.Errorf("the driver read %+v and database/sql %+v of %d records", got, want, args.N))
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tDATABASE/SQL\tDRIVER\tROWS/S (DATABASE/SQL)\tROWS/S (DRIVER)\tOVERHEAD")
+	for _, p := range []pair{insert, scan} {
+		s, n := duckbench.SummarizeTimings(p.sql).Median, duckbench.SummarizeTimings(p.native).Median
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%.0f\t%.0f\t%+.1f%%\n", p.name, s, n,
+			float64(p.rows)/s.Seconds(), float64(p.rows)/n.Seconds(), 100*(s.Seconds()/n.Seconds()-1))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,205 @@
+// Nested inserts Go slices, structs and maps into LIST, STRUCT and MAP columns through go-duckdb and queries them
+// back, printing the Go types the driver scans each into and checking that the round trip is exact.
+//
+// go-duckdb v1.8.3 maps the composite types as follows:
+//
+//   - The Appender takes any Go slice for a LIST, a Go struct or a map[string]any for a STRUCT, whose field names
+//     must match the STRUCT's exactly, and a duckdb.Map for a MAP, but not a typed Go map.
+//   - Query parameters cannot be slices, structs or maps at all; database/sql rejects them before the driver sees
+//     them.
+//   - Scanning into an any returns a []any for a LIST, a map[string]any for a STRUCT and a duckdb.Map, a
+//     map[any]any, for a MAP, nested as deep as the type is. duckdb.Composite[T] decodes any of them into a typed
+//     Go value instead.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"reflect"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+type args struct {
+	logging.Args
+
+	N    int    `arg:"-n" default:"1000" help:"number of items to insert"`
+	Seed uint64 `arg:"--seed" default:"1" help:"seed for the generated items"`
+}
+
+// point is stored as a STRUCT(X DOUBLE, Y DOUBLE), its fields named as the Appender expects.
+type point struct {
+	X, Y float64
+}
+
+// item is a row of the nested table.
+type item struct {
+	ID    int32
+	Tags  []string
+	Point point
+	Attrs map[string]int32
+}
+
+const schema = `CREATE TABLE nested (id INTEGER, tags VARCHAR[], point STRUCT(X DOUBLE, Y DOUBLE), attrs MAP(VARCHAR, INTEGER))`
+
+// generate returns n items with up to three tags and attributes each, the same for the same seed.
+func generate(n int, seed uint64) []item {
+	r := rand.New(rand.NewPCG(seed, seed))
+	items := make([]item, n)
+	for i := range items {
+		it := item{ID: int32(i), Tags: []string{}, Point: point{r.NormFloat64(), r.NormFloat64()}, Attrs: map[string]int32{}}
+		for range r.IntN(4) {
+			it.Tags = append(it.Tags, "t"+strconv.Itoa(r.IntN(10)))
+		}
+		for range r.IntN(4) {
+			it.Attrs["a"+strconv.Itoa(r.IntN(5))] = r.Int32N(100)
+		}
+		items[i] = it
+	}
+	return items
+}
+
+// insert appends items to the nested table, their attributes copied into a duckdb.Map.
+func insert(ctx context.Context, db *sql.DB, items []item) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(dc any) error {
+		appender, err := duckdb.NewAppenderFromConn(sqlwrap.Unwrap(dc).(driver.Conn), "", "nested")
+		if err != nil {
+			return fmt.Errorf("creating appender: %w", err)
+		}
+		for _, it := range items {
+			attrs := make(duckdb.Map, len(it.Attrs))
+			for k, v := range it.Attrs {
+				attrs[k] = v
+			}
+			if err := appender.AppendRow(it.ID, it.Tags, it.Point, attrs); err != nil {
+				appender.Close()
+				return fmt.Errorf("appending item %d: %w", it.ID, err)
+			}
+		}
+		return appender.Close()
+	})
+}
+
+// query reads the items back through duckdb.Composite.
+func query(ctx context.Context, db *sql.DB) ([]item, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, tags, point, attrs FROM nested ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []item
+	for rows.Next() {
+		var it item
+		var tags duckdb.Composite[[]string]
+		var p duckdb.Composite[point]
+		var attrs duckdb.Composite[map[string]int32]
+		if err := rows.Scan(&it.ID, &tags, &p, &attrs); err != nil {
+			return nil, err
+		}
+		it.Tags, it.Point, it.Attrs = tags.Get(), p.Get(), attrs.Get()
+		if it.Tags == nil {
+			it.Tags = []string{}
+		}
+		if it.Attrs == nil {
+			it.Attrs = map[string]int32{}
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// printScanTypes prints the type of each column, the type the driver reports it scans into, and what scanning the
+// row with the most tags into an any returns.
+func printScanTypes(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `SELECT tags, point, attrs FROM nested ORDER BY len(tags) DESC, id LIMIT 1`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	values := make([]any, len(types))
+	dest := make([]any, len(types))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if !rows.Next() {
+		return fmt.Errorf("no rows: %w", rows.Err())
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLUMN\tDUCKDB TYPE\tSCAN TYPE\tVALUE")
+	for i, t := range types {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%v\n", t.Name(), t.DatabaseTypeName(), t.ScanType(), values[i])
+	}
+	return tw.Flush()
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		logging.Fatal("creating table", err)
+	}
+
+	items := generate(args.N, args.Seed)
+	if _, err := db.ExecContext(ctx, `INSERT INTO nested (id, tags) VALUES (?, ?)`, -1, []string{"t0"}); err != nil {
+		fmt.Printf("binding a []string parameter fails: %v\n\n", err)
+	}
+	if err := insert(ctx, db, items); err != nil {
+		logging.Fatal("inserting items", err)
+	}
+	if err := printScanTypes(ctx, db); err != nil {
+		logging.Fatal("querying scan types", err)
+	}
+
+	got, err := query(ctx, db)
+	if err != nil {
+		logging.Fatal("querying items", err)
+	}
+	if !reflect.DeepEqual(got, items) {
+		logging.Fatal("verifying items", fmt.Errorf("the %d items read back differ from those inserted", len(got)))
+	}
+
+	// nested values can be unpacked in SQL as well as in Go
+	var tags, withA0 int64
+	var meanX float64
+	err = db.QueryRowContext(ctx, `
+		SELECT SUM(len(tags)), AVG(point.X), COUNT(*) FILTER (WHERE len(element_at(attrs, 'a0')) > 0)
+		FROM nested`).Scan(&tags, &meanX, &withA0)
+	if err != nil {
+		logging.Fatal("querying nested values", err)
+	}
+	fmt.Printf("\n%d items round trip exactly, with %d tags, a mean X of %.4f and %d with attribute a0\n",
+		len(items), tags, meanX, withA0)
+}
@@ -0,0 +1,103 @@
+// Parquet times a round trip of the generated records through a Parquet file, writing them out with COPY TO and
+// reading them back with read_parquet, against inserting them into an in-memory table with the Appender.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	N            int                    `arg:"-n" default:"1000000" help:"number of records to generate"`
+	Distribution duckbench.Distribution `arg:"--dist" default:"sequential" help:"distribution of the generated values: sequential, uniform, normal, exponential, zipf or timeseries"`
+	Seed         uint64                 `arg:"--seed" default:"1" help:"seed for the random distributions"`
+	File         string                 `arg:"--file" help:"Parquet file to write, which is kept [default: a temporary file]"`
+	Compression  string                 `arg:"--compression" default:"snappy" help:"Parquet compression: uncompressed, snappy, gzip or zstd"`
+}
+
+// newRecordsDB opens an in-memory database with an empty records table.
+func newRecordsDB(ctx context.Context) (*sql.DB, error) {
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+
+	path := args.File
+	if path == "" {
+		dir, err := os.MkdirTemp("", "parquet-*")
+		if err != nil {
+			logging.Fatal("creating temporary directory", err)
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "records.parquet")
+	}
+	records, err := duckbench.GenerateDistribution(args.N, args.Distribution, args.Seed)
+	if err != nil {
+		logging.Fatal("generating records", err)
+	}
+
+	var steps []duckbench.BenchmarkResult
+	timed := func(name string, fn func() error) {
+		step, err := duckbench.Measure(name, len(records), fn)
+		steps = append(steps, step)
+		if err != nil {
+			logging.Fatal(name, err)
+		}
+	}
+
+	src, err := newRecordsDB(ctx)
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer src.Close()
+	timed("insert in memory (appender)", func() error { return duckbench.AppenderInsert(ctx, records, src) })
+	timed("export (COPY TO)", func() error { return duckbench.ExportParquet(ctx, src, path, args.Compression) })
+
+	dst, err := newRecordsDB(ctx)
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer dst.Close()
+	timed("import (read_parquet)", func() error { return duckbench.InsertDataset(ctx, dst, path) })
+	if err := duckbench.VerifyIngestion(ctx, dst, records); err != nil {
+		logging.Fatal("verifying imported records", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		logging.Fatal("reading file size", err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tDURATION\tROWS/S")
+	for _, s := range steps {
+		fmt.Fprintf(tw, "%s\t%v\t%.0f\n", s.Name, s.Duration, s.RowsPerSecond())
+	}
+	tw.Flush()
+	fmt.Printf("%d records, %s Parquet file of %d bytes (%.1f bytes per row)\n",
+		len(records), args.Compression, info.Size(), float64(info.Size())/float64(len(records)))
+}
@@ -0,0 +1,279 @@
+// Readonly opens a persistent database with access_mode=read_only: first through the DSN given to sql.Open, then
+// through duckdb.NewConnector, whose DSN takes any other global setting alongside and whose init function runs on
+// every new connection. It then reads the file from several processes at once, each with its own read-only handle,
+// and shows what fails against it.
+//
+// DuckDB locks the file of a read-write database for a single process, while any number of processes may share it
+// read-only, so a process opening it read-write fails as long as a reader holds it. The lock is taken per process:
+// DuckDB does not notice two handles on one file within a single process, which must not open it read-write more
+// than once.
+//
+// A read-only handle rejects every statement changing the database, but not temporary tables, which are kept in
+// memory, nor settings. go-duckdb v1.8.3's Appender is the exception to look out for: it does not fail, and the
+// handle sees its rows, but they are never written to the file.
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alexflint/go-arg"
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	Path    string `arg:"--path" help:"database file to create and open read-only, which must not exist [default: a file in a temporary directory, removed afterwards]"`
+	N       int    `arg:"-n" default:"100000" help:"number of records to write to the database"`
+	Readers int    `arg:"--readers" default:"4" help:"processes reading the database at once"`
+	Child   string `arg:"--child" help:"read the database of this DSN and print its row count and sum, as one of the --readers processes"`
+}
+
+// summaryQuery is what each reader runs, and prints as the row count and sum of values, 0 for an empty table.
+const summaryQuery = "SELECT COUNT(*), COALESCE(SUM(value), 0) FROM records"
+
+// create writes n records to a new database at path, closing it again to release its lock.
+func create(ctx context.Context, path string, n int) error {
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{Path: path})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		return fmt.Errorf("creating table: %w", err)
+	}
+	if err := duckbench.AppenderInsert(ctx, duckbench.GenerateRecords(n), db); err != nil {
+		return fmt.Errorf("inserting records: %w", err)
+	}
+	return db.Close()
+}
+
+// openConnector opens path read-only through duckdb.NewConnector, with threads set in the DSN, which only takes
+// global settings, and a per-connection setting in the init function.
+func openConnector(ctx context.Context, path string) (*sql.DB, error) {
+	connector, err := duckdb.NewConnector(path+"?access_mode=read_only&threads=2", func(execer driver.ExecerContext) error {
+		_, err := execer.ExecContext(ctx, "SET enable_progress_bar = false", nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(connector)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// summary returns the output of summaryQuery.
+func summary(ctx context.Context, db *sql.DB) (string, error) {
+	var n int
+	var sum float64
+	if err := db.QueryRowContext(ctx, summaryQuery).Scan(&n, &sum); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %g", n, sum), nil
+}
+
+// child runs as one of the --readers processes, printing the summary of the database of dsn.
+func child(ctx context.Context, dsn string) error {
+	db, err := sql.Open("duckdb", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	s, err := summary(ctx, db)
+	if err != nil {
+		return err
+	}
+	fmt.Println(s)
+	return nil
+}
+
+// spawn runs this program as a child reading dsn, returning what it printed, or its logs if it failed.
+func spawn(ctx context.Context, dsn string, logArgs logging.Args) (string, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, self, "--child", dsn, "--log-format", logArgs.LogFormat, "--log-level", logArgs.LogLevel.String())
+	out, err := cmd.Output()
+	if exitErr := (*exec.ExitError)(nil); errors.As(err, &exitErr) {
+		return "", fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	return strings.TrimSpace(string(out)), err
+}
+
+// readers reads dsn from n child processes at once, checking that each saw want.
+func readers(ctx context.Context, dsn string, n int, want string, logArgs logging.Args) error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := spawn(ctx, dsn, logArgs)
+			if err == nil && got != want {
+				err = fmt.Errorf("read %q, want %q", got, want)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("reader %d: %w", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// appendRow appends a record to the read-only db, which go-duckdb lets through.
+func appendRow(ctx context.Context, db *sql.DB) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn any) error {
+		appender, err := duckdb.NewAppenderFromConn(driverConn.(driver.Conn), "", "records")
+		if err != nil {
+			return err
+		}
+		if err := appender.AppendRow(int32(-1), 1.0, 1.0, "appended", nil); err != nil {
+			appender.Close()
+			return err
+		}
+		return appender.Close()
+	})
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+	if args.Child != "" {
+		if err := child(ctx, args.Child); err != nil {
+			logging.Fatal("reading "+args.Child, err)
+		}
+		return
+	}
+	if err := run(ctx, args); err != nil {
+		logging.Fatal("demonstrating read-only access", err)
+	}
+}
+
+// run creates the database and demonstrates what its read-only handles allow, returning an error if any of it does
+// not behave as expected, so that the temporary directory is removed either way.
+func run(ctx context.Context, args args) error {
+	path := args.Path
+	if path == "" {
+		dir, err := os.MkdirTemp("", "readonly")
+		if err != nil {
+			return fmt.Errorf("creating temporary directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "records.db")
+	} else if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("creating database: %s already exists", path)
+	}
+	if err := create(ctx, path, args.N); err != nil {
+		return fmt.Errorf("creating database: %w", err)
+	}
+	slog.Info("created database", "path", path, "rows", args.N)
+
+	// the DSN is the path, then DuckDB settings as query parameters
+	readOnly := path + "?access_mode=read_only"
+	db, err := sql.Open("duckdb", readOnly)
+	if err != nil {
+		return fmt.Errorf("opening database read-only: %w", err)
+	}
+	defer db.Close()
+	var mode string
+	if err := db.QueryRowContext(ctx, "SELECT current_setting('access_mode')").Scan(&mode); err != nil {
+		return fmt.Errorf("reading access_mode: %w", err)
+	}
+	want, err := summary(ctx, db)
+	if err != nil {
+		return fmt.Errorf("reading database: %w", err)
+	}
+	slog.Info("opened with sql.Open", "dsn", readOnly, "access_mode", mode, "summary", want)
+
+	viaConnector, err := openConnector(ctx, path)
+	if err != nil {
+		return fmt.Errorf("opening database with a connector: %w", err)
+	}
+	defer viaConnector.Close()
+	var threads, progressBar string
+	err = viaConnector.QueryRowContext(ctx,
+		"SELECT current_setting('access_mode'), current_setting('threads'), current_setting('enable_progress_bar')").
+		Scan(&mode, &threads, &progressBar)
+	if err != nil {
+		return fmt.Errorf("reading settings: %w", err)
+	}
+	slog.Info("opened with duckdb.NewConnector", "access_mode", mode, "threads", threads, "enable_progress_bar", progressBar)
+	// read-only never creates the file, and go-duckdb opens the database in sql.Open, so that is where it fails
+	if _, err := sql.Open("duckdb", path+".missing?access_mode=read_only"); err != nil {
+		slog.Info("expected error opening a missing file read-only", "err", err)
+	} else {
+		return errors.New("opening a missing file read-only created the database")
+	}
+
+	// every process may hold the file read-only at once, while this one keeps its own handles open
+	if err := readers(ctx, readOnly, args.Readers, want, args.Args); err != nil {
+		return fmt.Errorf("reading from other processes: %w", err)
+	}
+	slog.Info("read from other processes at once", "readers", args.Readers, "summary", want)
+	// but none may open it read-write
+	if _, err := spawn(ctx, path, args.Args); err != nil {
+		slog.Info("expected error opening read-write from another process", "err", err)
+	} else {
+		return errors.New("another process opened the database read-write")
+	}
+
+	// DuckDB rejects each statement which would change the database by its type
+	for _, stmt := range []string{
+		"INSERT INTO records (value) VALUES (1)",
+		"UPDATE records SET value = 0",
+		"DELETE FROM records",
+		"CREATE TABLE copy AS SELECT * FROM records",
+		"DROP TABLE records",
+	} {
+		_, err := db.ExecContext(ctx, stmt)
+		if err == nil || !strings.Contains(err.Error(), "read-only mode") {
+			return fmt.Errorf("running %s: want a read-only error, got %v", stmt, err)
+		}
+		slog.Info("expected error", "stmt", stmt, "err", err)
+	}
+	// whereas temporary tables live in memory, for scratch results
+	_, err = db.ExecContext(ctx, "CREATE TEMP TABLE big AS SELECT * FROM records WHERE value > 0.5")
+	if err != nil {
+		return fmt.Errorf("creating temporary table: %w", err)
+	}
+	slog.Info("created temporary table in the read-only database")
+
+	// and go-duckdb's Appender slips through, its rows seen by the handle until it is closed
+	if err := appendRow(ctx, db); err != nil {
+		slog.Info("appending to the read-only database failed", "err", err)
+	} else if got, err := summary(ctx, db); err != nil {
+		return fmt.Errorf("reading database: %w", err)
+	} else {
+		slog.Warn("the Appender did not fail on the read-only database", "summary", got, "on_disk", want)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Remote queries a Parquet file by URL through DuckDB's httpfs extension, timing the first, cold, read against the
+// warm reads repeated after it. The file is the view remote, which --query runs against.
+//
+// httpfs is not bundled with go-duckdb v1.8.3, so it is installed and loaded on connect like any other extension,
+// which needs network access anyway. s3:// URLs are read with the credentials of the standard AWS environment
+// variables, as a DuckDB secret: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION (or
+// AWS_DEFAULT_REGION) and, for S3-compatible stores, AWS_ENDPOINT_URL.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	URL    string `arg:"positional,required" help:"http(s):// or s3:// URL of the Parquet file, which may be a glob for s3://"`
+	Query  string `arg:"--query" default:"SELECT COUNT(*) FROM remote" help:"query to time, against the view remote of the file"`
+	Repeat int    `arg:"--repeat" default:"5" help:"warm reads after the cold one"`
+	Cache  bool   `arg:"--cache" default:"true" help:"cache HTTP metadata and Parquet footers between reads (enable_http_metadata_cache and enable_object_cache)"`
+}
+
+// literal quotes s as a SQL string.
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// s3Secret returns the CREATE SECRET statement for the AWS credentials in the environment, if there are any.
+func s3Secret() (string, bool) {
+	key, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if key == "" || secret == "" {
+		return "", false
+	}
+	params := []string{"TYPE S3", "KEY_ID " + literal(key), "SECRET " + literal(secret)}
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		params = append(params, "SESSION_TOKEN "+literal(token))
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region != "" {
+		params = append(params, "REGION "+literal(region))
+	}
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		// DuckDB takes the host alone, and whether to use TLS separately
+		if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+			params = append(params, "ENDPOINT "+literal(u.Host), fmt.Sprintf("USE_SSL %t", u.Scheme != "http"), "URL_STYLE 'path'")
+		} else {
+			params = append(params, "ENDPOINT "+literal(endpoint))
+		}
+	}
+	return "CREATE SECRET remote (" + strings.Join(params, ", ") + ")", true
+}
+
+// run runs query, reading every row so the whole result is fetched.
+func run(ctx context.Context, db *sql.DB, query string) (rows int, err error) {
+	r, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	for r.Next() {
+		rows++
+	}
+	return rows, r.Err()
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+
+	boot := duckbench.InstallExtension("httpfs")
+	if args.Cache {
+		boot = append(boot, "SET enable_http_metadata_cache = true", "SET enable_object_cache = true")
+	}
+	start := time.Now()
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{OnConnect: boot})
+	if err != nil {
+		logging.Fatal("loading httpfs", err)
+	}
+	defer db.Close()
+	load := time.Since(start)
+	if secret, ok := s3Secret(); ok {
+		// secrets belong to the database, so every connection sees it; the error is not wrapped, as it would
+		// repeat the credentials
+		if _, err := db.ExecContext(ctx, secret); err != nil {
+			logging.Fatal("creating S3 secret", errors.New("CREATE SECRET failed, check the AWS environment variables"))
+		}
+	} else if strings.HasPrefix(args.URL, "s3://") {
+		fmt.Fprintln(os.Stderr, "no AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, reading anonymously")
+	}
+	if _, err := db.ExecContext(ctx, "CREATE VIEW remote AS SELECT * FROM read_parquet("+literal(args.URL)+")"); err != nil {
+		logging.Fatal("creating view of "+args.URL, err)
+	}
+
+	var rows int
+	cold, err := duckbench.Measure("cold", 0, func() (err error) {
+		rows, err = run(ctx, db, args.Query)
+		return err
+	})
+	if err != nil {
+		logging.Fatal("running query", err)
+	}
+	var warm []time.Duration
+	for range args.Repeat {
+		res, err := duckbench.Measure("warm", 0, func() error {
+			_, err := run(ctx, db, args.Query)
+			return err
+		})
+		if err != nil {
+			logging.Fatal("running query", err)
+		}
+		warm = append(warm, res.Duration)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tDURATION")
+	fmt.Fprintf(tw, "install and load httpfs\t%v\n", load)
+	fmt.Fprintf(tw, "cold read\t%v\n", cold.Duration)
+	if len(warm) > 0 {
+		t := duckbench.SummarizeTimings(warm)
+		fmt.Fprintf(tw, "warm read (median of %d)\t%v\n", len(warm), t.Median)
+		fmt.Fprintf(tw, "warm read (min to max)\t%v to %v\n", t.Min, t.Max)
+	}
+	tw.Flush()
+	fmt.Printf("%q returned %d rows from %s\n", args.Query, rows, args.URL)
+}
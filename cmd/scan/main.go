@@ -0,0 +1,115 @@
+// Scan times reading all N records back out of DuckDB into Go Records with each scan method: rows.Scan into a
+// variable per column, rows.Scan into a slice allocated up front, pkg/sqlscan into tagged structs, and go-duckdb's
+// Arrow interface a record batch at a time. Every method's records are checked against those inserted.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	N            int                    `arg:"-n" default:"1000000" help:"number of records to generate"`
+	Distribution duckbench.Distribution `arg:"--dist" default:"timeseries" help:"distribution of the generated values: sequential, uniform, normal, exponential, zipf or timeseries"`
+	Seed         uint64                 `arg:"--seed" default:"1" help:"seed for the random distributions"`
+	Groups       int                    `arg:"--groups" default:"10" help:"number of categories to give the records, or 0 for none"`
+	Methods      []duckbench.ScanMethod `arg:"--method,separate" help:"scan method to time, variables, preallocated, struct or arrow (repeatable) [default: all]"`
+	Repeat       int                    `arg:"--repeat" default:"3" help:"times to repeat each scan"`
+}
+
+// step is the timings of a scan method.
+type step struct {
+	method  duckbench.ScanMethod
+	samples []time.Duration
+	// allocs are the bytes each scan allocated.
+	allocs []uint64
+}
+
+// allocated returns the bytes allocated on the heap so far.
+func allocated() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.TotalAlloc
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+	methods := args.Methods
+	if len(methods) == 0 {
+		methods = duckbench.ScanMethods
+	}
+	if args.Repeat < 1 {
+		args.Repeat = 1
+	}
+
+	records, err := duckbench.GenerateDistribution(args.N, args.Distribution, args.Seed)
+	if err != nil {
+		logging.Fatal("generating records", err)
+	}
+	duckbench.AssignCategories(records, args.Groups, args.Seed)
+	want := duckbench.ChecksumRecords(records)
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		logging.Fatal("creating records table", err)
+	}
+	if err := duckbench.AppenderInsert(ctx, records, db); err != nil {
+		logging.Fatal("inserting records", err)
+	}
+	records = nil
+
+	steps := make([]step, len(methods))
+	// the methods take turns, so that none runs only on a warm cache or a grown heap
+	for range args.Repeat {
+		for i, method := range methods {
+			runtime.GC()
+			before := allocated()
+			var got []duckbench.Record
+			res, err := duckbench.Measure(string(method), args.N, func() (err error) {
+				got, err = duckbench.ReadRecords(ctx, db, method)
+				return err
+			})
+			if err != nil {
+				logging.Fatal("scanning with "+string(method), err)
+			}
+			if sum := duckbench.ChecksumRecords(got); sum != want {
+				logging.Fatal("checking scan with "+string(method), fmt.Errorf("%w: read %d records", duckbench.ErrIntegrity, len(got)))
+			}
+			steps[i].method = method
+			steps[i].samples = append(steps[i].samples, res.Duration)
+			steps[i].allocs = append(steps[i].allocs, allocated()-before)
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tDURATION\tROWS/S\tALLOCATED")
+	for _, s := range steps {
+		// the allocations of each run barely differ, so the least is shown
+		alloc := s.allocs[0]
+		for _, a := range s.allocs {
+			alloc = min(alloc, a)
+		}
+		d := duckbench.SummarizeTimings(s.samples).Median
+		fmt.Fprintf(tw, "%s\t%v\t%.0f\t%.1f MiB\n", s.method, d, float64(args.N)/d.Seconds(), float64(alloc)/(1<<20))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,124 @@
+// Types times inserting, aggregating and scanning the generated values stored as each of INTEGER, BIGINT,
+// DECIMAL(18,3), FLOAT and DOUBLE, with each way of reading the column into Go, and how far the aggregates of each
+// drift from those of the DOUBLE values.
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	N            int                    `arg:"-n" default:"1000000" help:"number of records to generate"`
+	Distribution duckbench.Distribution `arg:"--dist" default:"sequential" help:"distribution of the generated values: sequential, uniform, normal, exponential, zipf or timeseries; the integer types round them"`
+	Seed         uint64                 `arg:"--seed" default:"1" help:"seed for the random distributions"`
+	Types        []duckbench.ColumnType `arg:"--type,separate" help:"column type to time, double, float, integer, bigint or decimal (repeatable) [default: all]"`
+	Epsilon      float64                `arg:"--epsilon" default:"1e-9" help:"relative difference allowed between the sum DuckDB calculates and that of the values each scan mapping reads, per value"`
+}
+
+// step is a timing of one column type.
+type step struct {
+	typ duckbench.ColumnType
+	duckbench.BenchmarkResult
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+	types := args.Types
+	if len(types) == 0 {
+		types = duckbench.ColumnTypes
+	}
+
+	records, err := duckbench.GenerateDistribution(args.N, args.Distribution, args.Seed)
+	if err != nil {
+		logging.Fatal("generating records", err)
+	}
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		logging.Fatal("creating records table", err)
+	}
+	// the source of the casting inserts
+	if err := duckbench.AppenderInsert(ctx, records, db); err != nil {
+		logging.Fatal("inserting records", err)
+	}
+
+	var steps []step
+	timed := func(typ duckbench.ColumnType, name string, fn func() error) {
+		res, err := duckbench.Measure(name, len(records), fn)
+		if err != nil {
+			logging.Fatal(fmt.Sprintf("%s: %s", typ, name), err)
+		}
+		steps = append(steps, step{typ, res})
+	}
+	aggregates := make(map[duckbench.ColumnType]duckbench.TypedAggregate)
+	for _, typ := range types {
+		if err := duckbench.CreateTypedTable(ctx, db, typ); err != nil {
+			logging.Fatal("creating typed table", err)
+		}
+		timed(typ, "insert (appender)", func() error { return duckbench.TypedAppenderInsert(ctx, db, typ, records) })
+		if err := duckbench.CreateTypedTable(ctx, db, typ); err != nil {
+			logging.Fatal("creating typed table", err)
+		}
+		timed(typ, "insert (cast from DOUBLE)", func() error { return duckbench.TypedCastInsert(ctx, db, typ) })
+
+		var agg duckbench.TypedAggregate
+		timed(typ, "aggregate", func() (err error) {
+			agg, err = duckbench.TypedAggregateFromDB(ctx, db)
+			return err
+		})
+		aggregates[typ] = agg
+		// a sum can be near zero when its values are not, so differences relative to the largest value are allowed
+		// too, for each value summed
+		scale := math.Max(math.Abs(agg.Min), math.Abs(agg.Max))
+		tol := floatcmp.Tolerance{Rel: args.Epsilon, Abs: args.Epsilon * scale * float64(agg.Count)}
+		for _, m := range duckbench.ScanMappings(typ) {
+			timed(typ, "scan into "+m.Name, func() error {
+				sum, err := m.Scan(ctx, db)
+				if err == nil && !tol.Equal(sum, agg.Sum) {
+					err = fmt.Errorf("read values summing to %v, but DuckDB sums them to %v", sum, agg.Sum)
+				}
+				return err
+			})
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tSTEP\tDURATION\tROWS/S")
+	for _, s := range steps {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%.0f\n", s.typ.SQL(), s.Name, s.Duration, s.RowsPerSecond())
+	}
+	tw.Flush()
+
+	// the drift of each type from the values as generated
+	exact, err := duckbench.StatisticsFromRecords(records)
+	if err != nil {
+		logging.Fatal("calculating statistics", err)
+	}
+	fmt.Println()
+	fmt.Fprintln(tw, "TYPE\tMEAN\tREL DIFF\tMIN\tMAX")
+	for _, typ := range types {
+		agg := aggregates[typ]
+		fmt.Fprintf(tw, "%s\t%v\t%.3g\t%v\t%v\n", typ.SQL(), agg.Mean, floatcmp.RelDiff(agg.Mean, exact.Mean), agg.Min, agg.Max)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,98 @@
+// UDF registers a Go function with DuckDB as a scalar UDF and times summing it over the value column in SQL against
+// the same transformation written as a DuckDB expression and against applying it to the records in a Go loop.
+// DuckDB calls a Go UDF through cgo for every row, converting each value, so it pays per row for what its own
+// expressions do over whole vectors. Each step is repeated and the median timing reported.
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexflint/go-arg"
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	N            int                    `arg:"-n" default:"1000000" help:"number of records"`
+	Distribution duckbench.Distribution `arg:"--dist" default:"normal" help:"distribution of the generated values: sequential, uniform, normal, exponential, zipf or timeseries"`
+	Seed         uint64                 `arg:"--seed" default:"1" help:"seed for the random distributions"`
+	Repeat       int                    `arg:"--repeat" default:"3" help:"times to repeat each step"`
+}
+
+// udfName is what SignedLog is registered as.
+const udfName = "signed_log"
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+	if args.Repeat < 1 {
+		args.Repeat = 1
+	}
+
+	records, err := duckbench.GenerateDistribution(args.N, args.Distribution, args.Seed)
+	if err != nil {
+		logging.Fatal("generating records", err)
+	}
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{
+		ScalarFuncs: map[string]duckdb.ScalarFunc{udfName: duckbench.DoubleFunc(duckbench.SignedLog)},
+	})
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		logging.Fatal("creating records table", err)
+	}
+	if err := duckbench.AppenderInsert(ctx, records, db); err != nil {
+		logging.Fatal("inserting records", err)
+	}
+
+	steps := []struct {
+		name string
+		sum  func() (float64, error)
+	}{
+		{"SQL expression", func() (float64, error) { return duckbench.SumOf(ctx, db, duckbench.SignedLogSQL) }},
+		{"Go UDF in SQL", func() (float64, error) { return duckbench.SumOf(ctx, db, udfName+"(value)") }},
+		{"Go loop", func() (float64, error) { return duckbench.SumFunc(records, duckbench.SignedLog), nil }},
+	}
+	timings := make([][]time.Duration, len(steps))
+	sums := make([]float64, len(steps))
+	for range args.Repeat {
+		for i, step := range steps {
+			res, err := duckbench.Measure(step.name, args.N, func() (err error) {
+				sums[i], err = step.sum()
+				return err
+			})
+			if err != nil {
+				logging.Fatal(step.name, err)
+			}
+			timings[i] = append(timings[i], res.Duration)
+		}
+	}
+	for i, sum := range sums[1:] {
+		if math.Abs(sum-sums[0]) > 1e-9*max(math.Abs(sums[0]), 1) {
+			logging.Fatal("checking sums", fmt.Errorf("%s summed %v, but %s %v", steps[i+1].name, sum, steps[0].name, sums[0]))
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tDURATION\tROWS/S\tSUM")
+	for i, step := range steps {
+		d := duckbench.SummarizeTimings(timings[i]).Median
+		fmt.Fprintf(tw, "%s\t%v\t%.0f\t%g\n", step.name, d, float64(args.N)/d.Seconds(), sums[i])
+	}
+	tw.Flush()
+}
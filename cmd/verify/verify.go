@@ -0,0 +1,137 @@
+// Verify calculates every statistic with both the Go and DuckDB engines on the same dataset and prints how far
+// apart they are, exiting with status 1 if they differ from each other or from gonum/stat by more than the
+// tolerance. Percentiles are only compared between the two engines.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/compare"
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+)
+
+type args struct {
+	logging.Args
+
+	Dataset     string              `arg:"--dataset" help:"verify the records saved in this .csv or .parquet file [default: generated records]"`
+	N           int                 `arg:"-n" default:"1000000" help:"number of records to generate when no dataset is given"`
+	Summation   duckbench.Summation `arg:"--summation" default:"pairwise" help:"how the Go engine sums values: naive, pairwise or neumaier"`
+	Percentiles []float64           `arg:"--percentile,separate" help:"percentile to verify as well, between 0 and 1 (repeatable) [default: 0.25, 0.75, 0.95 and 0.99]"`
+
+	Rel float64 `arg:"--rel" default:"1e-9" help:"relative tolerance"`
+	Abs float64 `arg:"--abs" help:"absolute tolerance"`
+	ULP uint64  `arg:"--ulp" help:"tolerance in units in the last place"`
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+
+	path := args.Dataset
+	var records []duckbench.Record
+	var err error
+	if path != "" {
+		records, err = duckbench.LoadDataset(ctx, path)
+		if err != nil {
+			logging.Fatal("loading dataset", err)
+		}
+	} else {
+		records = duckbench.GenerateRecords(args.N)
+		// going through a saved dataset loads DuckDB in bulk rather than row by row
+		f, err := os.CreateTemp("", "verify-*.csv")
+		if err != nil {
+			logging.Fatal("creating dataset", err)
+		}
+		f.Close()
+		path = f.Name()
+		defer os.Remove(path)
+		if err := duckbench.SaveDataset(ctx, records, path); err != nil {
+			logging.Fatal("saving dataset", err)
+		}
+	}
+
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		logging.Fatal("creating records table", err)
+	}
+	if err := duckbench.InsertDataset(ctx, db, path); err != nil {
+		logging.Fatal("loading records", err)
+	}
+	if err := duckbench.VerifyIngestion(ctx, db, records); err != nil {
+		logging.Fatal("verifying loaded records", err)
+	}
+
+	if len(args.Percentiles) == 0 {
+		args.Percentiles = duckbench.DefaultPercentiles
+	}
+	goStats, err := duckbench.StatisticsWithSummation(records, args.Summation, args.Percentiles...)
+	if err != nil {
+		logging.Fatal("calculating statistics in Go", err)
+	}
+	dbStats, err := duckbench.StatisticsFromDB(ctx, db, args.Percentiles...)
+	if err != nil {
+		logging.Fatal("calculating statistics in DuckDB", err)
+	}
+	refStats, err := compare.GonumStatistics(records, args.Percentiles...)
+	if err != nil {
+		logging.Fatal("calculating statistics with gonum", err)
+	}
+
+	tol := floatcmp.Tolerance{Abs: args.Abs, Rel: args.Rel, ULP: args.ULP}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAT\tGO\tDUCKDB\tGONUM\tABS DELTA\tREL DELTA\tULPS\tRESULT")
+	failed := false
+	goValues, dbValues, refValues := goStats.Values(), dbStats.Values(), refStats.Values()
+	for i, stat := range duckbench.StatNames {
+		a, b, ref := goValues[i], dbValues[i], refValues[i]
+		result := "pass"
+		switch {
+		case !tol.Equal(a, b):
+			result = "FAIL"
+		case !tol.Equal(a, ref):
+			result = "FAIL (go vs gonum)"
+		case !tol.Equal(b, ref):
+			result = "FAIL (duckdb vs gonum)"
+		}
+		failed = failed || result != "pass"
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%.3g\t%.3g\t%d\t%s\n", stat, a, b, ref,
+			floatcmp.AbsDiff(a, b), floatcmp.RelDiff(a, b), floatcmp.ULPDiff(a, b), result)
+	}
+	for i, p := range goStats.Percentiles {
+		a, b, ref := p.Value, dbStats.Percentiles[i].Value, refStats.Percentiles[i].Value
+		result := "pass"
+		switch {
+		case !tol.Equal(a, b):
+			result = "FAIL"
+		case !tol.Equal(a, ref):
+			result = "FAIL (go vs gonum)"
+		case !tol.Equal(b, ref):
+			result = "FAIL (duckdb vs gonum)"
+		}
+		failed = failed || result != "pass"
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%.3g\t%.3g\t%d\t%s\n", p.Name(), a, b, ref,
+			floatcmp.AbsDiff(a, b), floatcmp.RelDiff(a, b), floatcmp.ULPDiff(a, b), result)
+	}
+	tw.Flush()
+	fmt.Printf("%d records, %d non-finite skipped, tolerance %+v\n", len(records), goStats.NonFinite, tol)
+	if failed {
+		db.Close()
+		os.Remove(path)
+		os.Exit(1)
+	}
+}
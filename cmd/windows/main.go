@@ -0,0 +1,91 @@
+// Windows times rolling statistics over a window of rows, with DuckDB's window functions against a ring buffer in
+// Go, and checks that they agree.
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/logging"
+	"github.com/rpep/duckdb-go-experiments/pkg/verify"
+)
+
+type args struct {
+	logging.Args
+
+	N            int                    `arg:"-n" default:"1000000" help:"number of records to generate"`
+	Distribution duckbench.Distribution `arg:"--dist" default:"sequential" help:"distribution of the generated values: sequential, uniform, normal, exponential, zipf or timeseries"`
+	Seed         uint64                 `arg:"--seed" default:"1" help:"seed for the random distributions"`
+	Window       int                    `arg:"-w,--window" default:"100" help:"rows in the window, ending at each row, to calculate the rolling statistics over"`
+	Epsilon      float64                `arg:"--epsilon" default:"1e-9" help:"relative difference allowed between the Go and DuckDB rolling statistics"`
+}
+
+func main() {
+	var args args
+	arg.MustParse(&args)
+	if err := args.Setup(); err != nil {
+		logging.Fatal("configuring logging", err)
+	}
+	ctx := context.Background()
+
+	records, err := duckbench.GenerateDistribution(args.N, args.Distribution, args.Seed)
+	if err != nil {
+		logging.Fatal("generating records", err)
+	}
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		logging.Fatal("creating DuckDB database", err)
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		logging.Fatal("creating records table", err)
+	}
+	if err := duckbench.AppenderInsert(ctx, records, db); err != nil {
+		logging.Fatal("inserting records", err)
+	}
+
+	var goStats, dbStats []duckbench.RollingStat
+	steps := make([]duckbench.BenchmarkResult, 2)
+	steps[0], err = duckbench.Measure("go (ring buffer)", len(records), func() (err error) {
+		goStats, err = duckbench.RollingStatistics(records, args.Window)
+		return err
+	})
+	if err != nil {
+		logging.Fatal("calculating rolling statistics in Go", err)
+	}
+	// the rows are read back, as the Go engine's are in a slice
+	steps[1], err = duckbench.Measure("duckdb (window functions)", len(records), func() (err error) {
+		dbStats, err = duckbench.RollingStatisticsFromDB(ctx, db, args.Window)
+		return err
+	})
+	if err != nil {
+		logging.Fatal("calculating rolling statistics in DuckDB", err)
+	}
+
+	// a window's mean can be near zero when its values are not, so differences relative to the largest value are
+	// allowed too
+	var scale float64
+	for _, r := range records {
+		scale = math.Max(scale, math.Abs(r.Value))
+	}
+	tol := floatcmp.Tolerance{Rel: args.Epsilon, Abs: args.Epsilon * scale}
+	if m := duckbench.CompareRolling(goStats, dbStats, tol, 10); len(m) > 0 {
+		logging.Fatal("verifying rolling statistics", &verify.DivergenceError{A: "go", B: "duckdb", Tolerance: tol, Mismatches: m})
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENGINE\tDURATION\tROWS/S")
+	for _, s := range steps {
+		fmt.Fprintf(tw, "%s\t%v\t%.0f\n", s.Name, s.Duration, s.RowsPerSecond())
+	}
+	tw.Flush()
+	fmt.Printf("rolling mean and stddev of %d %s records over %d rows agree within %g\n",
+		len(records), args.Distribution, args.Window, args.Epsilon)
+}
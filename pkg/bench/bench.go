@@ -0,0 +1,5 @@
+// Package bench runs the workloads timed by cmd/duckbench as Go benchmarks, so go test -bench reports ns/op,
+// allocations and repeated samples of each step, e.g. for benchstat:
+//
+//	go test ./pkg/bench -bench . -benchmem -count 10 -rows 100000
+package bench
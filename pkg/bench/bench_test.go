@@ -0,0 +1,259 @@
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/compare"
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+var rows = flag.Int("rows", 10000, "number of records each benchmark operation processes")
+var groups = flag.Int("groups", 10, "categories the records are assigned to for the grouped workload")
+
+func records(b *testing.B) []duckbench.Record {
+	b.Helper()
+	records, err := duckbench.GenerateDistribution(*rows, duckbench.DistUniform, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	duckbench.AssignCategories(records, *groups, 1)
+	return records
+}
+
+// reportRows reports the throughput of b as well as its time per operation.
+func reportRows(b *testing.B) {
+	b.ReportMetric(float64(*rows)*float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func recordsDB(b *testing.B) *sql.DB {
+	b.Helper()
+	ctx := context.Background()
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for _, dist := range duckbench.Distributions {
+		b.Run(string(dist), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := duckbench.GenerateDistribution(*rows, dist, uint64(i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportRows(b)
+		})
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	ctx := context.Background()
+	records := records(b)
+	for _, method := range duckbench.InsertMethods {
+		b.Run(method.Name(), func(b *testing.B) {
+			db := recordsDB(b)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := method.Insert(ctx, records, db); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				if _, err := db.ExecContext(ctx, "TRUNCATE records"); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+			reportRows(b)
+		})
+	}
+}
+
+// BenchmarkConcurrentInsert shards the appender over more writers, to see whether ingestion scales with them.
+func BenchmarkConcurrentInsert(b *testing.B) {
+	ctx := context.Background()
+	records := records(b)
+	for _, workers := range []int{1, 2, 4, 8} {
+		c := duckbench.ConcurrentInsert{Workers: workers, Strategy: duckbench.InsertAppender}
+		b.Run(c.Name(), func(b *testing.B) {
+			db := recordsDB(b)
+			for i := 0; i < b.N; i++ {
+				if err := c.Insert(ctx, records, db); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				if _, err := db.ExecContext(ctx, "TRUNCATE records"); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+			reportRows(b)
+		})
+	}
+}
+
+func BenchmarkStatisticsGo(b *testing.B) {
+	records := records(b)
+	for _, sum := range duckbench.Summations {
+		b.Run(string(sum), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := duckbench.StatisticsWithSummation(records, sum); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportRows(b)
+		})
+	}
+}
+
+func BenchmarkStatisticsParallel(b *testing.B) {
+	records := records(b)
+	counts := []int{1, 2, 4, runtime.GOMAXPROCS(0)}
+	slices.Sort(counts)
+	for _, threads := range slices.Compact(counts) {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := duckbench.ParallelStatistics(records, duckbench.SumPairwise, threads); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportRows(b)
+		})
+	}
+}
+
+// BenchmarkStatisticsStreaming is the single pass Welford alternative to BenchmarkStatisticsGo, which needs no
+// copy of the values but cannot find the median.
+func BenchmarkStatisticsStreaming(b *testing.B) {
+	records := records(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := duckbench.StreamingStatistics(records); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportRows(b)
+}
+
+func BenchmarkStatisticsDuckDB(b *testing.B) {
+	ctx := context.Background()
+	db := recordsDB(b)
+	if err := duckbench.AppenderInsert(ctx, records(b), db); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := duckbench.StatisticsFromDB(ctx, db); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportRows(b)
+}
+
+// BenchmarkEngine times the query workload of each engine duckbench compare compares, loaded once per engine.
+func BenchmarkEngine(b *testing.B) {
+	ctx := context.Background()
+	records := records(b)
+	for _, name := range compare.Engines() {
+		b.Run(name, func(b *testing.B) {
+			e, err := compare.New(name)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := e.Open(ctx); err != nil {
+				b.Skip(err)
+			}
+			b.Cleanup(func() { e.Close() })
+			if err := e.Load(ctx, records); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := e.RunQueryWorkload(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportRows(b)
+		})
+	}
+}
+
+// workloads are timed by BenchmarkWorkload, as by duckbench stats.
+var workloads = []duckbench.Workload{duckbench.Histogram{Bins: 20}, duckbench.Correlation{}, duckbench.Regression{}, duckbench.GroupedStatistics{},
+	duckbench.TopK{K: 10}, duckbench.Distinct{}, duckbench.ApproxDistinct{}, duckbench.ApproxQuantiles{}}
+
+func BenchmarkWorkload(b *testing.B) {
+	ctx := context.Background()
+	records := records(b)
+	db := recordsDB(b)
+	if err := duckbench.AppenderInsert(ctx, records, db); err != nil {
+		b.Fatal(err)
+	}
+	for _, w := range workloads {
+		b.Run(w.Name()+"/go", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := w.Go(records); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportRows(b)
+		})
+		b.Run(w.Name()+"/duckdb", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := w.DuckDB(ctx, db); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportRows(b)
+		})
+	}
+}
+
+// BenchmarkTimeBuckets times per-minute averages of a time series, which the records of the other benchmarks are
+// not.
+func BenchmarkTimeBuckets(b *testing.B) {
+	ctx := context.Background()
+	records := duckbench.GenerateTimeSeries(*rows, duckbench.DefaultTimeSeries, 1)
+	db := recordsDB(b)
+	if err := duckbench.AppenderInsert(ctx, records, db); err != nil {
+		b.Fatal(err)
+	}
+	w := duckbench.TimeBuckets{Width: time.Minute}
+	b.Run("go", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := w.Go(records); err != nil {
+				b.Fatal(err)
+			}
+		}
+		reportRows(b)
+	})
+	b.Run("duckdb", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := w.DuckDB(ctx, db); err != nil {
+				b.Fatal(err)
+			}
+		}
+		reportRows(b)
+	})
+}
@@ -0,0 +1,125 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/compute"
+	arrowmath "github.com/apache/arrow-go/v18/arrow/math"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/arrow/scalar"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// arrowCompute holds the values in an Arrow array and calculates the statistics with vectorized Arrow kernels, the
+// in-process Go counterpart to DuckDB's vectorized execution. Go's compute package has no aggregate or sort
+// kernels, so sums use the SIMD arrow/math package and the median is found by sorting the filtered values.
+type arrowCompute struct {
+	mem    memory.Allocator
+	values *array.Float64
+}
+
+func (*arrowCompute) Name() string { return "arrow" }
+
+func (e *arrowCompute) Open(context.Context) error {
+	e.mem = memory.NewGoAllocator()
+	return nil
+}
+
+func (e *arrowCompute) Load(_ context.Context, records []duckbench.Record) error {
+	b := array.NewFloat64Builder(e.mem)
+	defer b.Release()
+	b.Reserve(len(records))
+	for _, r := range records {
+		if r.Null {
+			b.UnsafeAppendBoolToBitmap(false)
+		} else {
+			b.UnsafeAppend(r.Value)
+		}
+	}
+	e.values = b.NewFloat64Array()
+	return nil
+}
+
+func (e *arrowCompute) RunQueryWorkload(ctx context.Context) (duckbench.Stats, error) {
+	var s duckbench.Stats
+	ctx = compute.WithAllocator(ctx, e.mem)
+
+	abs, err := e.call(ctx, "abs_unchecked", e.values)
+	if err != nil {
+		return s, err
+	}
+	defer abs.Release()
+	// |value| < +Inf is false for NaN as well as for infinities, and NULL for NULL, which the filter drops
+	finite, err := e.call(ctx, "less", abs, scalar.NewFloat64Scalar(math.Inf(1)))
+	if err != nil {
+		return s, err
+	}
+	defer finite.Release()
+	values, err := compute.FilterArray(ctx, e.values, finite, *compute.DefaultFilterOptions())
+	if err != nil {
+		return s, err
+	}
+	defer values.Release()
+	s.Nulls = int64(e.values.NullN())
+	s.NonFinite = int64(e.values.Len()-values.Len()) - s.Nulls
+	if values.Len() == 0 {
+		return s, duckbench.ErrNoValues
+	}
+
+	n := float64(values.Len())
+	s.Mean = arrowmath.Float64.Sum(values.(*array.Float64)) / n
+	deviations, err := e.call(ctx, "subtract_unchecked", values, scalar.NewFloat64Scalar(s.Mean))
+	if err != nil {
+		return s, err
+	}
+	defer deviations.Release()
+	squares, err := e.call(ctx, "multiply_unchecked", deviations, deviations)
+	if err != nil {
+		return s, err
+	}
+	defer squares.Release()
+	s.StdDev = math.Sqrt(arrowmath.Float64.Sum(squares.(*array.Float64)) / n)
+
+	sorted := slices.Clone(values.(*array.Float64).Float64Values())
+	slices.Sort(sorted)
+	s.Min, s.Max = sorted[0], sorted[len(sorted)-1]
+	if len(sorted)%2 == 0 {
+		s.Median = sorted[len(sorted)/2-1]/2 + sorted[len(sorted)/2]/2
+	} else {
+		s.Median = sorted[len(sorted)/2]
+	}
+	return s, nil
+}
+
+// call runs the named compute function over array or scalar arguments, returning an array.
+func (e *arrowCompute) call(ctx context.Context, name string, args ...any) (arrow.Array, error) {
+	datums := make([]compute.Datum, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case arrow.Array:
+			datums[i] = compute.NewDatumWithoutOwning(arg)
+		case scalar.Scalar:
+			datums[i] = compute.NewDatum(arg)
+		default:
+			return nil, fmt.Errorf("%s: argument %d is %T", name, i, arg)
+		}
+	}
+	out, err := compute.CallFunction(ctx, name, nil, datums...)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*compute.ArrayDatum).MakeArray(), nil
+}
+
+func (e *arrowCompute) Close() error {
+	if e.values != nil {
+		e.values.Release()
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// clickhouseLocal runs the workload with clickhouse-local over the dataset exported as Parquet, the closest
+// ClickHouse comes to an embedded engine. It uses $CLICKHOUSE, or else clickhouse-local or clickhouse on the PATH,
+// and is unavailable if there is none.
+type clickhouseLocal struct {
+	command []string
+	dir     string
+	path    string
+}
+
+func (*clickhouseLocal) Name() string { return "clickhouse-local" }
+
+func (e *clickhouseLocal) Open(context.Context) error {
+	switch bin, err := exec.LookPath("clickhouse-local"); {
+	case os.Getenv("CLICKHOUSE") != "":
+		e.command = []string{os.Getenv("CLICKHOUSE"), "local"}
+	case err == nil:
+		e.command = []string{bin}
+	default:
+		bin, err := exec.LookPath("clickhouse")
+		if err != nil {
+			return fmt.Errorf("%w: no clickhouse-local or clickhouse binary found", ErrUnavailable)
+		}
+		e.command = []string{bin, "local"}
+	}
+	var err error
+	e.dir, err = os.MkdirTemp("", "duckbench-clickhouse-*")
+	return err
+}
+
+// Load exports the records to Parquet, which clickhouse-local reads in place.
+func (e *clickhouseLocal) Load(ctx context.Context, records []duckbench.Record) error {
+	e.path = filepath.Join(e.dir, "records.parquet")
+	return duckbench.SaveDataset(ctx, records, e.path)
+}
+
+// clickhouseStatisticsQuery mirrors the DuckDB query. median is approximate in ClickHouse, so the median is
+// quantileExactInclusive, which interpolates between the middle two values as DuckDB's MEDIAN does.
+const clickhouseStatisticsQuery = `
+	SELECT
+		avgIf(value, isFinite(value)),
+		quantileExactInclusiveIf(0.5)(value, isFinite(value)),
+		stddevPopIf(value, isFinite(value)),
+		minIf(value, isFinite(value)),
+		maxIf(value, isFinite(value)),
+		countIf(NOT isFinite(value)),
+		countIf(isFinite(value)),
+		countIf(value IS NULL)
+	FROM file(%s, Parquet)
+	FORMAT TSVRaw`
+
+func (e *clickhouseLocal) RunQueryWorkload(ctx context.Context) (duckbench.Stats, error) {
+	var s duckbench.Stats
+	query := fmt.Sprintf(clickhouseStatisticsQuery, "'"+strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(e.path)+"'")
+	args := append(e.command[1:], "--query", query)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.command[0], args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return s, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	fields := strings.Fields(string(out))
+	if len(fields) != 8 {
+		return s, fmt.Errorf("unexpected clickhouse-local output %q", out)
+	}
+	if s.NonFinite, err = strconv.ParseInt(fields[5], 10, 64); err != nil {
+		return s, err
+	}
+	if s.Nulls, err = strconv.ParseInt(fields[7], 10, 64); err != nil {
+		return s, err
+	}
+	if fields[6] == "0" {
+		return s, duckbench.ErrNoValues
+	}
+	for i, dest := range []*float64{&s.Mean, &s.Median, &s.StdDev, &s.Min, &s.Max} {
+		if *dest, err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+func (e *clickhouseLocal) Close() error {
+	if e.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(e.dir)
+}
@@ -0,0 +1,177 @@
+package compare
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// columnar is a hand-written column store: the values in a single float64 slice, aggregated in parallel chunks
+// with unrolled loops the compiler can keep in registers. It is an upper bound on what native Go can do, without
+// the cgo and SQL overheads of DuckDB.
+type columnar struct {
+	values []float64
+	nulls  int64
+}
+
+func (*columnar) Name() string { return "columnar" }
+
+func (*columnar) Open(context.Context) error { return nil }
+
+func (e *columnar) Load(_ context.Context, records []duckbench.Record) error {
+	e.values = make([]float64, 0, len(records))
+	for _, r := range records {
+		if r.Null {
+			e.nulls++
+			continue
+		}
+		e.values = append(e.values, r.Value)
+	}
+	return nil
+}
+
+// chunk is the aggregate of one chunk of values.
+type chunk struct {
+	sum, min, max float64
+	n, nonFinite  int
+}
+
+func (e *columnar) RunQueryWorkload(context.Context) (duckbench.Stats, error) {
+	var s duckbench.Stats
+	chunks := parallel(e.values, func(values []float64) chunk {
+		c := chunk{min: math.Inf(1), max: math.Inf(-1)}
+		for _, v := range values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				c.nonFinite++
+				continue
+			}
+			c.sum += v
+			c.min = math.Min(c.min, v)
+			c.max = math.Max(c.max, v)
+			c.n++
+		}
+		return c
+	})
+	total := chunk{min: math.Inf(1), max: math.Inf(-1)}
+	for _, c := range chunks {
+		total.sum += c.sum
+		total.min = math.Min(total.min, c.min)
+		total.max = math.Max(total.max, c.max)
+		total.n += c.n
+		total.nonFinite += c.nonFinite
+	}
+	s.NonFinite, s.Nulls = int64(total.nonFinite), e.nulls
+	if total.n == 0 {
+		return s, duckbench.ErrNoValues
+	}
+	n := float64(total.n)
+	s.Mean, s.Min, s.Max = total.sum/n, total.min, total.max
+
+	var squares float64
+	for _, c := range parallel(e.values, func(values []float64) float64 { return sumSquares(values, s.Mean) }) {
+		squares += c
+	}
+	s.StdDev = math.Sqrt(squares / n)
+
+	finite := make([]float64, 0, total.n)
+	for _, v := range e.values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			finite = append(finite, v)
+		}
+	}
+	mid := len(finite) / 2
+	s.Median = nthElement(finite, mid)
+	if len(finite)%2 == 0 {
+		// after selecting mid, the values below it are all in finite[:mid], the largest of which is its neighbour
+		below := finite[0]
+		for _, v := range finite[1:mid] {
+			below = math.Max(below, v)
+		}
+		s.Median = below/2 + s.Median/2
+	}
+	return s, nil
+}
+
+func (*columnar) Close() error { return nil }
+
+// parallel splits values into a chunk per CPU and aggregates each in its own goroutine.
+func parallel[T any](values []float64, aggregate func([]float64) T) []T {
+	workers := runtime.GOMAXPROCS(0)
+	size := (len(values) + workers - 1) / workers
+	if size < 4096 {
+		size = 4096
+	}
+	// results are kept in chunk order so the totals, and their rounding, are the same on every run
+	results := make([]T, (len(values)+size-1)/size)
+	var wg sync.WaitGroup
+	for i := range results {
+		part := values[i*size : min((i+1)*size, len(values))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = aggregate(part)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// sumSquares sums the squared deviations of the finite values from mean with four independent accumulators, so
+// the additions need not wait on each other.
+func sumSquares(values []float64, mean float64) float64 {
+	var a, b, c, d float64
+	i := 0
+	for ; i+4 <= len(values); i += 4 {
+		a += finite(values[i]-mean) * finite(values[i]-mean)
+		b += finite(values[i+1]-mean) * finite(values[i+1]-mean)
+		c += finite(values[i+2]-mean) * finite(values[i+2]-mean)
+		d += finite(values[i+3]-mean) * finite(values[i+3]-mean)
+	}
+	for ; i < len(values); i++ {
+		a += finite(values[i]-mean) * finite(values[i]-mean)
+	}
+	return (a + b) + (c + d)
+}
+
+// finite returns v, or 0 if it is NaN or infinite.
+func finite(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+// nthElement partially sorts values so values[k] is the k-th smallest, with no larger value before it, and returns
+// it, in linear time on average.
+func nthElement(values []float64, k int) float64 {
+	lo, hi := 0, len(values)-1
+	for lo < hi {
+		pivot := values[lo+(hi-lo)/2]
+		i, j := lo, hi
+		for i <= j {
+			for values[i] < pivot {
+				i++
+			}
+			for values[j] > pivot {
+				j--
+			}
+			if i <= j {
+				values[i], values[j] = values[j], values[i]
+				i++
+				j--
+			}
+		}
+		switch {
+		case k <= j:
+			hi = j
+		case k >= i:
+			lo = i
+		default:
+			return values[k]
+		}
+	}
+	return values[k]
+}
@@ -0,0 +1,148 @@
+// Package compare runs the same dataset and statistics workload through several storage engines, so DuckDB can be
+// compared with the alternatives someone might reach for instead.
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// An Engine loads records and runs the query workload over them, calculating their statistics. An instance is
+// only used once: Open is called before Load, Load before RunQueryWorkload, and Close at the end whether or not
+// the others succeeded. Engines should skip NULL values, counting them in Stats.Nulls, and NaN and infinite values,
+// counting them in Stats.NonFinite, and return duckbench.ErrNoValues when there are no others.
+type Engine interface {
+	Name() string
+	Open(ctx context.Context) error
+	Load(ctx context.Context, records []duckbench.Record) error
+	RunQueryWorkload(ctx context.Context) (duckbench.Stats, error)
+	Close() error
+}
+
+// ErrUnavailable is returned by engines which are optional (e.g. an external binary or server) and not available.
+var ErrUnavailable = errors.New("engine not available")
+
+var (
+	mu         sync.Mutex
+	registered []func() Engine
+)
+
+// Register adds an engine to those run by Run, after the built in ones. newEngine is called for a fresh instance
+// each time the engine is run, and must return engines with a unique name.
+func Register(newEngine func() Engine) {
+	mu.Lock()
+	defer mu.Unlock()
+	registered = append(registered, newEngine)
+}
+
+// engines returns a fresh instance of every engine, in the order they are reported.
+func engines() []Engine {
+	mu.Lock()
+	defer mu.Unlock()
+	all := []Engine{
+		&duckDB{},
+		&duckDBTableFunc{},
+		&gonum{},
+		&arrowCompute{},
+		&columnar{},
+		&gota{},
+		&csvStream{},
+		&sqliteEngine{},
+		&postgresEngine{},
+		&mysqlEngine{},
+		&clickhouseLocal{},
+	}
+	for _, newEngine := range registered {
+		all = append(all, newEngine())
+	}
+	return all
+}
+
+// Engines lists the names of the engines which can be compared.
+func Engines() []string {
+	var names []string
+	for _, e := range engines() {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// New returns a fresh instance of the named engine.
+func New(name string) (Engine, error) {
+	for _, e := range engines() {
+		if e.Name() == name {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown engine %q, expected one of %v", name, Engines())
+}
+
+// Result is how one engine fared with a dataset.
+type Result struct {
+	Engine string
+	Load   time.Duration
+	Query  time.Duration
+	Stats  duckbench.Stats
+	Err    error
+}
+
+// Run runs records through each named engine in turn, or every engine if names is empty. An engine failing is
+// reported in its Result rather than stopping the comparison.
+func Run(ctx context.Context, records []duckbench.Record, names ...string) ([]Result, error) {
+	selected := engines()
+	if len(names) > 0 {
+		selected = selected[:0]
+		for _, name := range names {
+			e, err := New(name)
+			if err != nil {
+				return nil, err
+			}
+			selected = append(selected, e)
+		}
+	}
+	return RunEngines(ctx, records, selected...), nil
+}
+
+// RunEngines runs records through each of engines in turn, which need not be registered.
+func RunEngines(ctx context.Context, records []duckbench.Record, engines ...Engine) []Result {
+	results := make([]Result, len(engines))
+	for i, e := range engines {
+		results[i] = run(ctx, e, records)
+	}
+	return results
+}
+
+func run(ctx context.Context, e Engine, records []duckbench.Record) (res Result) {
+	res.Engine = e.Name()
+	if err := e.Open(ctx); err != nil {
+		res.Err = fmt.Errorf("opening: %w", err)
+		return res
+	}
+	defer func() {
+		if err := e.Close(); err != nil && res.Err == nil {
+			res.Err = fmt.Errorf("closing: %w", err)
+		}
+	}()
+
+	start := time.Now()
+	if err := e.Load(ctx, records); err != nil {
+		res.Err = fmt.Errorf("loading: %w", err)
+		return res
+	}
+	res.Load = time.Since(start)
+
+	start = time.Now()
+	stats, err := e.RunQueryWorkload(ctx)
+	res.Query = time.Since(start)
+	if err != nil {
+		res.Err = fmt.Errorf("calculating statistics: %w", err)
+		return res
+	}
+	res.Stats = stats
+	return res
+}
@@ -0,0 +1,164 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+func records(values ...float64) []duckbench.Record {
+	records := make([]duckbench.Record, len(values))
+	for i, v := range values {
+		records[i] = duckbench.Record{ID: i, Value: v}
+	}
+	return records
+}
+
+func TestEnginesAgree(t *testing.T) {
+	nulls := records(3, math.NaN(), -1, math.Inf(1), 2.5, math.Inf(-1))
+	nulls = append(nulls, duckbench.GenerateRecords(1000)...)
+	if err := duckbench.InjectNulls(nulls, 0.1, 1); err != nil {
+		t.Fatal(err)
+	}
+	datasets := map[string][]duckbench.Record{
+		"generated":  duckbench.GenerateRecords(1001),
+		"even":       duckbench.GenerateRecords(1000),
+		"non-finite": records(3, math.NaN(), -1, math.Inf(1), 2.5, math.Inf(-1)),
+		"nulls":      nulls,
+	}
+	for name, records := range datasets {
+		t.Run(name, func(t *testing.T) {
+			want, err := duckbench.StatisticsFromRecords(records)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := Run(context.Background(), records)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range results {
+				if errors.Is(r.Err, ErrUnavailable) {
+					t.Logf("%s: %v", r.Engine, r.Err)
+					continue
+				}
+				if r.Err != nil {
+					t.Errorf("%s: %v", r.Engine, r.Err)
+					continue
+				}
+				for _, m := range duckbench.CompareStats(r.Stats, want, duckbench.StatTolerance) {
+					t.Errorf("%s: %s = %v, want %v", r.Engine, m.Stat, m.A, m.B)
+				}
+				if r.Stats.NonFinite != want.NonFinite || r.Stats.Nulls != want.Nulls {
+					t.Errorf("%s: NonFinite, Nulls = %d, %d, want %d, %d", r.Engine, r.Stats.NonFinite, r.Stats.Nulls, want.NonFinite, want.Nulls)
+				}
+			}
+		})
+	}
+}
+
+func TestRunUnknownEngine(t *testing.T) {
+	if _, err := Run(context.Background(), nil, "duckdb", "nosuchdb"); err == nil {
+		t.Error("running an unknown engine succeeded")
+	}
+}
+
+// fixedEngine is a third-party engine which always calculates the same statistics.
+type fixedEngine struct {
+	stats  duckbench.Stats
+	closed *bool
+}
+
+func (fixedEngine) Name() string                                   { return "fixed" }
+func (fixedEngine) Open(context.Context) error                     { return nil }
+func (fixedEngine) Load(context.Context, []duckbench.Record) error { return nil }
+func (e fixedEngine) RunQueryWorkload(context.Context) (duckbench.Stats, error) {
+	return e.stats, nil
+}
+func (e fixedEngine) Close() error {
+	*e.closed = true
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	want := duckbench.Stats{Mean: 1, Median: 2, StdDev: 3, Min: 4, Max: 5}
+	var closed bool
+	t.Cleanup(func() { registered = nil })
+	Register(func() Engine { return fixedEngine{stats: want, closed: &closed} })
+	if !slices.Contains(Engines(), "fixed") {
+		t.Fatalf("Engines() = %v, missing the registered engine", Engines())
+	}
+	results, err := Run(context.Background(), records(1, 2, 3), "fixed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Err != nil || !reflect.DeepEqual(results[0].Stats, want) {
+		t.Errorf("Run() = %+v, want the fixed statistics", results)
+	}
+	if !closed {
+		t.Error("engine was not closed")
+	}
+}
+
+// TestGonumPercentiles checks gonum's quantiles are rescaled to interpolate as quantile_cont does, for odd and even
+// counts and a single value.
+func TestGonumPercentiles(t *testing.T) {
+	percentiles := []float64{0, 0.01, 0.25, 0.5, 0.75, 0.99, 1}
+	for _, n := range []int{1, 2, 5, 1000, 1001} {
+		records, err := duckbench.GenerateDistribution(n, duckbench.DistUniform, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := duckbench.StatisticsWithSummation(records, duckbench.SumPairwise, percentiles...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := GonumStatistics(records, percentiles...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Percentiles) != len(percentiles) {
+			t.Fatalf("%d records: %d percentiles, want %d", n, len(got.Percentiles), len(percentiles))
+		}
+		for _, m := range duckbench.CompareStats(got, want, duckbench.StatTolerance) {
+			t.Errorf("%d records: %s = %v, want %v", n, m.Stat, m.A, m.B)
+		}
+	}
+}
+
+// TestPostgresDialect runs the Postgres spelling of the workload on DuckDB, which accepts it, so it is tested without
+// a Postgres server.
+func TestPostgresDialect(t *testing.T) {
+	ctx := context.Background()
+	records := records(3, math.NaN(), -1, math.Inf(1), 2.5, math.Inf(-1), 7)
+	records[6].Null = true
+	want, err := duckbench.StatisticsFromRecords(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := insertAll(ctx, db, Postgres, "records", records); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Postgres.Statistics(ctx, db, "records")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range duckbench.CompareStats(got, want, duckbench.StatTolerance) {
+		t.Errorf("%s = %v, want %v", m.Stat, m.A, m.B)
+	}
+	if got.NonFinite != 3 || got.Nulls != 1 {
+		t.Errorf("NonFinite, Nulls = %d, %d, want 3, 1", got.NonFinite, got.Nulls)
+	}
+}
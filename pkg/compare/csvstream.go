@@ -0,0 +1,97 @@
+package compare
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// csvStream involves no database at all: the records are saved as CSV and Stats streams the file through
+// encoding/csv, accumulating the statistics as it parses, which is what "just parse the file in Go" costs.
+type csvStream struct {
+	dir, path string
+}
+
+func (*csvStream) Name() string { return "csv" }
+
+func (e *csvStream) Open(context.Context) error {
+	var err error
+	e.dir, err = os.MkdirTemp("", "duckbench-csv-*")
+	return err
+}
+
+func (e *csvStream) Load(ctx context.Context, records []duckbench.Record) error {
+	e.path = filepath.Join(e.dir, "records.csv")
+	return duckbench.SaveDataset(ctx, records, e.path)
+}
+
+func (e *csvStream) RunQueryWorkload(context.Context) (s duckbench.Stats, err error) {
+	f, err := os.Open(e.path)
+	if err != nil {
+		return s, err
+	}
+	defer f.Close()
+	r := csv.NewReader(bufio.NewReaderSize(f, 1<<20))
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err != nil {
+		return s, err
+	}
+	column := slices.Index(header, "value")
+	if column < 0 {
+		return s, fmt.Errorf("%s has no value column", e.path)
+	}
+
+	// the mean and variance are accumulated with Welford's algorithm, but the exact median needs every value
+	var w duckbench.Welford
+	var values []float64
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return s, err
+		}
+		// writeCSV leaves NULL values empty
+		if row[column] == "" {
+			w.AddNull()
+			continue
+		}
+		v, err := strconv.ParseFloat(row[column], 64)
+		if err != nil {
+			return s, err
+		}
+		w.Add(v)
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			values = append(values, v)
+		}
+	}
+	if s, err = w.Stats(); err != nil {
+		return s, err
+	}
+
+	mid := len(values) / 2
+	s.Median = nthElement(values, mid)
+	if len(values)%2 == 0 {
+		s.Median = slices.Max(values[:mid])/2 + s.Median/2
+	}
+	return s, nil
+}
+
+func (e *csvStream) Close() error {
+	if e.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(e.dir)
+}
@@ -0,0 +1,119 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+// A Dialect is how a SQL engine spells the parts of the workload which differ between engines, so every SQL
+// engine runs the semantically identical workload from the one definition in StatisticsQuery. Each spelling is a
+// format string given the column.
+type Dialect struct {
+	Name string
+	// Placeholder is the bind parameter for the i-th (from 1) argument.
+	Placeholder func(i int) string
+	// IsFinite is a condition true for values which are neither NULL, NaN nor infinite.
+	IsFinite string
+	// Median and VarPop are the median and population variance aggregates. If empty, they are calculated by
+	// ranking and from the mean respectively.
+	Median, VarPop string
+	// NaNIsNull is set for engines which store NaN as NULL, and so cannot tell them apart. Their tables have an
+	// is_null column marking the values which really are NULL, and insertAll stores every non-finite value as NULL.
+	NaNIsNull bool
+}
+
+func questionMark(int) string { return "?" }
+
+var (
+	DuckDB = Dialect{
+		Name:        "duckdb",
+		Placeholder: questionMark,
+		IsFinite:    "isfinite(%s)",
+		Median:      "MEDIAN(%s)",
+		VarPop:      "VAR_POP(%s)",
+	}
+	Postgres = Dialect{
+		Name:        "postgres",
+		Placeholder: func(i int) string { return fmt.Sprintf("$%d", i) },
+		// NaN sorts above Infinity in Postgres
+		IsFinite: "abs(%s) < 'Infinity'::float8",
+		Median:   "percentile_cont(0.5) WITHIN GROUP (ORDER BY %s)",
+		VarPop:   "var_pop(%s)",
+	}
+	// SQLite stores NaN as NULL, and infinities as ±9e999, and has neither a median nor a variance aggregate.
+	SQLite = Dialect{
+		Name:        "sqlite",
+		Placeholder: questionMark,
+		IsFinite:    "%[1]s IS NOT NULL AND abs(%[1]s) < 9e999",
+		NaNIsNull:   true,
+	}
+	// MySQL has no median aggregate, and a MySQL DOUBLE cannot hold NaN or infinities, which are stored as NULL.
+	MySQL = Dialect{
+		Name:        "mysql",
+		Placeholder: questionMark,
+		IsFinite:    "%s IS NOT NULL",
+		VarPop:      "VAR_POP(%s)",
+		NaNIsNull:   true,
+	}
+)
+
+// InsertQuery inserts a single value into table, followed by whether it is NULL if NaNIsNull.
+func (d Dialect) InsertQuery(table string) string {
+	if d.NaNIsNull {
+		return fmt.Sprintf("INSERT INTO %s (value, is_null) VALUES (%s, %s)", table, d.Placeholder(1), d.Placeholder(2))
+	}
+	return fmt.Sprintf("INSERT INTO %s (value) VALUES (%s)", table, d.Placeholder(1))
+}
+
+// StatisticsQuery calculates the statistics of the value column of table, returning a statisticsRow.
+func (d Dialect) StatisticsQuery(table string) string {
+	agg := []string{"avg(value) AS mean", "min(value) AS min", "max(value) AS max", "count(*) AS n"}
+	median := "agg.median"
+	if d.Median != "" {
+		agg = append(agg, fmt.Sprintf(d.Median, "value")+" AS median")
+	} else {
+		// the middle one or two values in order, at ranks n / 2 and n / 2 + 1 when n is even, or (n + 1) / 2
+		median = "(SELECT avg(value) FROM ranked WHERE rn * 2 IN (agg.n, agg.n + 1, agg.n + 2))"
+	}
+	variance := "agg.variance"
+	if d.VarPop != "" {
+		agg = append(agg, fmt.Sprintf(d.VarPop, "value")+" AS variance")
+	} else {
+		variance = "(SELECT avg((value - agg.mean) * (value - agg.mean)) FROM finite)"
+	}
+
+	nulls := "count(*) - count(value)"
+	if d.NaNIsNull {
+		nulls = "coalesce(sum(is_null), 0)"
+	}
+
+	var q strings.Builder
+	fmt.Fprintf(&q, "WITH finite AS (SELECT value FROM %s WHERE %s),\n", table, fmt.Sprintf(d.IsFinite, "value"))
+	fmt.Fprintf(&q, "agg AS (SELECT %s FROM finite),\n", strings.Join(agg, ", "))
+	fmt.Fprintf(&q, "totals AS (SELECT count(*) AS n, %s AS nulls FROM %s)", nulls, table)
+	if d.Median == "" {
+		q.WriteString(",\nranked AS (SELECT value, row_number() OVER (ORDER BY value) AS rn FROM finite)")
+	}
+	fmt.Fprintf(&q, "\nSELECT agg.mean AS mean, %s AS median, %s AS variance, agg.min AS min, agg.max AS max,\n", median, variance)
+	q.WriteString("totals.n - agg.n - totals.nulls AS non_finite, totals.nulls AS nulls\nFROM agg, totals")
+	return q.String()
+}
+
+// Statistics runs StatisticsQuery over table.
+func (d Dialect) Statistics(ctx context.Context, db sqlscan.Queryer, table string) (duckbench.Stats, error) {
+	row, err := sqlscan.One[statisticsRow](ctx, db, d.StatisticsQuery(table))
+	if err != nil {
+		return duckbench.Stats{}, err
+	}
+	s := duckbench.Stats{NonFinite: row.NonFinite, Nulls: row.Nulls}
+	if !row.Mean.Valid {
+		return s, duckbench.ErrNoValues
+	}
+	s.Mean, s.Median, s.StdDev, s.Min, s.Max = row.Mean.V, row.Median.V, math.Sqrt(row.Variance.V), row.Min.V, row.Max.V
+	return s, nil
+}
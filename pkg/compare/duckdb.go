@@ -0,0 +1,73 @@
+package compare
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+type duckDB struct {
+	db *sql.DB
+}
+
+func (*duckDB) Name() string { return "duckdb" }
+
+func (e *duckDB) Open(ctx context.Context) error {
+	var err error
+	if e.db, err = duckbench.CreateDB(ctx, duckbench.DBOptions{}); err != nil {
+		return err
+	}
+	return duckbench.CreateRecordsTable(ctx, e.db)
+}
+
+func (e *duckDB) Load(ctx context.Context, records []duckbench.Record) error {
+	return insertAll(ctx, e.db, DuckDB, "records", records)
+}
+
+func (e *duckDB) RunQueryWorkload(ctx context.Context) (duckbench.Stats, error) {
+	return DuckDB.Statistics(ctx, e.db, "records")
+}
+
+func (e *duckDB) Close() error {
+	if e.db == nil {
+		return nil
+	}
+	return e.db.Close()
+}
+
+// duckDBTableFunc queries the records where they are in Go, through duckbench.RecordsFunc, so its load only
+// registers them.
+type duckDBTableFunc struct {
+	db *sql.DB
+}
+
+func (*duckDBTableFunc) Name() string { return "duckdb-tablefunc" }
+
+// Open does nothing, as the function is registered with the records when the database is created.
+func (*duckDBTableFunc) Open(context.Context) error { return nil }
+
+func (e *duckDBTableFunc) Load(ctx context.Context, records []duckbench.Record) error {
+	var err error
+	e.db, err = duckbench.CreateDB(ctx, duckbench.DBOptions{
+		TableFuncs: map[string]duckdb.ParallelRowTableFunction{"go_records": duckbench.RecordsFunc(records)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = e.db.ExecContext(ctx, duckbench.RecordsView("go_records"))
+	return err
+}
+
+func (e *duckDBTableFunc) RunQueryWorkload(ctx context.Context) (duckbench.Stats, error) {
+	return DuckDB.Statistics(ctx, e.db, "records")
+}
+
+func (e *duckDBTableFunc) Close() error {
+	if e.db == nil {
+		return nil
+	}
+	return e.db.Close()
+}
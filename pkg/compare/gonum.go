@@ -0,0 +1,72 @@
+package compare
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// gonum calculates the statistics with gonum/stat, a well-tested reference for both the hand-rolled Go engine and
+// DuckDB.
+type gonum struct {
+	records []duckbench.Record
+}
+
+func (*gonum) Name() string { return "gonum" }
+
+func (*gonum) Open(context.Context) error { return nil }
+
+func (e *gonum) Load(_ context.Context, records []duckbench.Record) error {
+	e.records = records
+	return nil
+}
+
+func (e *gonum) RunQueryWorkload(context.Context) (duckbench.Stats, error) {
+	return GonumStatistics(e.records)
+}
+
+func (*gonum) Close() error { return nil }
+
+// GonumStatistics calculates the statistics of records with gonum/stat, along with percentiles, skipping
+// non-finite values as the other engines do.
+func GonumStatistics(records []duckbench.Record, percentiles ...float64) (duckbench.Stats, error) {
+	var s duckbench.Stats
+	values := make([]float64, 0, len(records))
+	for _, r := range records {
+		if r.Null {
+			s.Nulls++
+			continue
+		}
+		if math.IsNaN(r.Value) || math.IsInf(r.Value, 0) {
+			s.NonFinite++
+			continue
+		}
+		values = append(values, r.Value)
+	}
+	if len(values) == 0 {
+		return s, duckbench.ErrNoValues
+	}
+	slices.Sort(values)
+
+	var variance float64
+	s.Mean, variance = stat.PopMeanVariance(values, nil)
+	s.StdDev = math.Sqrt(variance)
+	s.Min, s.Max = floats.Min(values), floats.Max(values)
+	s.Median = quantile(0.5, values)
+	for _, p := range percentiles {
+		s.Percentiles = append(s.Percentiles, duckbench.Percentile{P: p, Value: quantile(p, values)})
+	}
+	return s, nil
+}
+
+// quantile is DuckDB's quantile_cont of sorted values with stat.Quantile, interpolating between the values around
+// rank p(n-1). stat.LinInterp interpolates around rank pn-1 instead, so p is rescaled to match.
+func quantile(p float64, sorted []float64) float64 {
+	n := float64(len(sorted))
+	return stat.Quantile((p*(n-1)+1)/n, stat.LinInterp, sorted, nil)
+}
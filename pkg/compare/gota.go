@@ -0,0 +1,61 @@
+package compare
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// gota holds the records in a gota dataframe, which is what many Go users reach for first for this kind of
+// analysis.
+type gota struct {
+	df    dataframe.DataFrame
+	nulls int64
+}
+
+func (*gota) Name() string { return "gota" }
+
+func (*gota) Open(context.Context) error { return nil }
+
+func (e *gota) Load(_ context.Context, records []duckbench.Record) error {
+	ids := make([]int, 0, len(records))
+	values := make([]float64, 0, len(records))
+	for _, r := range records {
+		if r.Null {
+			e.nulls++
+			continue
+		}
+		ids, values = append(ids, r.ID), append(values, r.Value)
+	}
+	e.df = dataframe.New(series.New(ids, series.Int, "id"), series.New(values, series.Float, "value"))
+	return e.df.Err
+}
+
+func (e *gota) RunQueryWorkload(context.Context) (duckbench.Stats, error) {
+	s := duckbench.Stats{Nulls: e.nulls}
+	finite := e.df.Filter(dataframe.F{Colname: "value", Comparator: series.CompFunc, Comparando: func(el series.Element) bool {
+		v := el.Float()
+		return !math.IsNaN(v) && !math.IsInf(v, 0)
+	}})
+	if finite.Err != nil {
+		return s, finite.Err
+	}
+	s.NonFinite = int64(e.df.Nrow() - finite.Nrow())
+	if finite.Nrow() == 0 {
+		return s, duckbench.ErrNoValues
+	}
+
+	values := finite.Col("value")
+	s.Mean, s.Median, s.Min, s.Max = values.Mean(), values.Median(), values.Min(), values.Max()
+	// Series.StdDev is the sample standard deviation
+	if n := float64(values.Len()); n > 1 {
+		s.StdDev = values.StdDev() * math.Sqrt((n-1)/n)
+	}
+	return s, nil
+}
+
+func (*gota) Close() error { return nil }
@@ -0,0 +1,66 @@
+package compare
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// mysqlEngine is a MySQL (8.0 or later) server, given by a go-sql-driver DSN in $MYSQL_DSN, e.g.
+// "root:secret@tcp(localhost:3306)/bench", or else started in a container by startMySQL. It is unavailable if
+// neither is possible. The records are loaded into a duckbench_records table, which is dropped again on Close.
+type mysqlEngine struct {
+	db   *sql.DB
+	stop func(context.Context) error
+}
+
+// startMySQL starts a MySQL server for the engine when $MYSQL_DSN is not set. It is set when built with -tags
+// testcontainers, see mysql_testcontainers.go.
+var startMySQL startServer
+
+func (*mysqlEngine) Name() string { return "mysql" }
+
+func (e *mysqlEngine) Open(ctx context.Context) error {
+	dsn, stop, err := serverDSN(ctx, "MYSQL_DSN", startMySQL)
+	if err != nil {
+		return err
+	}
+	e.stop = stop
+	if e.db, err = sql.Open("mysql", dsn); err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		"DROP TABLE IF EXISTS duckbench_records",
+		"CREATE TABLE duckbench_records (id BIGINT AUTO_INCREMENT PRIMARY KEY, value DOUBLE, is_null BOOLEAN NOT NULL)",
+	} {
+		if _, err := e.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load stores non-finite values, which a MySQL DOUBLE cannot hold, as NULL.
+func (e *mysqlEngine) Load(ctx context.Context, records []duckbench.Record) error {
+	return insertAll(ctx, e.db, MySQL, "duckbench_records", records)
+}
+
+func (e *mysqlEngine) RunQueryWorkload(ctx context.Context) (duckbench.Stats, error) {
+	return MySQL.Statistics(ctx, e.db, "duckbench_records")
+}
+
+func (e *mysqlEngine) Close() error {
+	var err error
+	if e.db != nil {
+		_, err = e.db.Exec("DROP TABLE IF EXISTS duckbench_records")
+		err = errors.Join(err, e.db.Close())
+	}
+	if e.stop != nil {
+		err = errors.Join(err, e.stop(context.Background()))
+	}
+	return err
+}
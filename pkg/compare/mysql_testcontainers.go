@@ -0,0 +1,33 @@
+//go:build testcontainers
+
+package compare
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+)
+
+// mysqlImage is the server the mysql engine starts when $MYSQL_DSN is not set.
+const mysqlImage = "mysql:8.0"
+
+func init() { startMySQL = startMySQLContainer }
+
+// startMySQLContainer starts mysqlImage with Docker through testcontainers-go. The engine is unavailable, rather
+// than failing, if there is no Docker to start it with.
+func startMySQLContainer(ctx context.Context) (string, func(context.Context) error, error) {
+	container, err := mysql.Run(ctx, mysqlImage, mysql.WithDatabase("bench"))
+	if err != nil {
+		if container != nil {
+			container.Terminate(context.Background())
+		}
+		return "", nil, fmt.Errorf("%w: starting %s: %v", ErrUnavailable, mysqlImage, err)
+	}
+	dsn, err := container.ConnectionString(ctx)
+	if err != nil {
+		container.Terminate(context.Background())
+		return "", nil, err
+	}
+	return dsn, func(ctx context.Context) error { return container.Terminate(ctx) }, nil
+}
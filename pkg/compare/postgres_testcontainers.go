@@ -0,0 +1,38 @@
+//go:build testcontainers
+
+package compare
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// postgresImage is the server the postgres engine starts when $POSTGRES_DSN is not set.
+const postgresImage = "postgres:16-alpine"
+
+func init() { startPostgres = startPostgresContainer }
+
+// startPostgresContainer starts postgresImage with Docker through testcontainers-go, as startMySQLContainer does
+// MySQL. The server logs that it is ready once for its initialisation and again once it really is.
+func startPostgresContainer(ctx context.Context) (string, func(context.Context) error, error) {
+	container, err := postgres.Run(ctx, postgresImage, postgres.WithDatabase("bench"),
+		testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").
+			WithOccurrence(2).WithStartupTimeout(time.Minute)))
+	if err != nil {
+		if container != nil {
+			container.Terminate(context.Background())
+		}
+		return "", nil, fmt.Errorf("%w: starting %s: %v", ErrUnavailable, postgresImage, err)
+	}
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		container.Terminate(context.Background())
+		return "", nil, err
+	}
+	return dsn, func(ctx context.Context) error { return container.Terminate(ctx) }, nil
+}
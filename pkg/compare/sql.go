@@ -0,0 +1,68 @@
+package compare
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+)
+
+// startServer starts a database server for an engine, returning its DSN and a function stopping it again.
+type startServer func(ctx context.Context) (dsn string, stop func(context.Context) error, err error)
+
+// serverDSN returns the DSN in the environment variable env, or else starts a server with start, which is nil
+// unless built with -tags testcontainers. The engine is unavailable if neither is possible.
+func serverDSN(ctx context.Context, env string, start startServer) (string, func(context.Context) error, error) {
+	if dsn := os.Getenv(env); dsn != "" {
+		return dsn, nil, nil
+	}
+	if start == nil {
+		return "", nil, fmt.Errorf("%w: %s is not set, and testcontainers is not built in (-tags testcontainers)", ErrUnavailable, env)
+	}
+	return start(ctx)
+}
+
+// insertAll inserts records into table with a single prepared statement in one transaction, the fastest way to load
+// a table through database/sql which every SQL engine supports. NULL records are bound as nil.
+func insertAll(ctx context.Context, db *sql.DB, d Dialect, table string, records []duckbench.Record) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, d.InsertQuery(table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range records {
+		var value any = r.Value
+		if r.Null || d.NaNIsNull && (math.IsNaN(r.Value) || math.IsInf(r.Value, 0)) {
+			value = nil
+		}
+		args := []any{value}
+		if d.NaNIsNull {
+			args = append(args, r.Null)
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// statisticsRow is a row of Dialect.StatisticsQuery. Every aggregate but the count is NULL when there are no
+// finite values.
+type statisticsRow struct {
+	Mean      nullable.Nullable[float64] `db:"mean"`
+	Median    nullable.Nullable[float64] `db:"median"`
+	Variance  nullable.Nullable[float64] `db:"variance"`
+	Min       nullable.Nullable[float64] `db:"min"`
+	Max       nullable.Nullable[float64] `db:"max"`
+	NonFinite int64                      `db:"non_finite"`
+	Nulls     int64                      `db:"nulls"`
+}
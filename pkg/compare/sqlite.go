@@ -0,0 +1,43 @@
+package compare
+
+import (
+	"context"
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// sqliteEngine is an in-memory SQLite database, through the pure Go modernc.org/sqlite driver.
+type sqliteEngine struct {
+	db *sql.DB
+}
+
+func (*sqliteEngine) Name() string { return "sqlite" }
+
+func (e *sqliteEngine) Open(ctx context.Context) error {
+	var err error
+	if e.db, err = sql.Open("sqlite", ":memory:"); err != nil {
+		return err
+	}
+	// every connection to :memory: is a separate database
+	e.db.SetMaxOpenConns(1)
+	_, err = e.db.ExecContext(ctx, "CREATE TABLE records (id INTEGER PRIMARY KEY, value REAL, is_null INTEGER NOT NULL)")
+	return err
+}
+
+func (e *sqliteEngine) Load(ctx context.Context, records []duckbench.Record) error {
+	return insertAll(ctx, e.db, SQLite, "records", records)
+}
+
+func (e *sqliteEngine) RunQueryWorkload(ctx context.Context) (duckbench.Stats, error) {
+	return SQLite.Statistics(ctx, e.db, "records")
+}
+
+func (e *sqliteEngine) Close() error {
+	if e.db == nil {
+		return nil
+	}
+	return e.db.Close()
+}
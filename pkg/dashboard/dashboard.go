@@ -0,0 +1,121 @@
+// Package dashboard renders a live view of a running benchmark to a terminal, redrawn in place.
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/metrics"
+)
+
+// Dashboard periodically draws the state of a run. Memory usage is read from the gauges the run's resource
+// sampler keeps in Registry, so is only shown when sampling is enabled.
+type Dashboard struct {
+	W        io.Writer
+	Progress *duckbench.Progress
+	Registry *metrics.Registry
+	Interval time.Duration
+
+	lines int
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// Start begins redrawing the dashboard every Interval, until Stop is called.
+func (d *Dashboard) Start() {
+	if d.Interval <= 0 {
+		d.Interval = 250 * time.Millisecond
+	}
+	d.stop, d.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.Interval)
+		defer ticker.Stop()
+		for {
+			d.draw()
+			select {
+			case <-ticker.C:
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops redrawing and clears the dashboard, leaving the cursor where it was first drawn.
+func (d *Dashboard) Stop() {
+	close(d.stop)
+	<-d.done
+	d.clear()
+}
+
+func (d *Dashboard) clear() {
+	var b strings.Builder
+	if d.lines > 0 {
+		fmt.Fprintf(&b, "\033[%dA", d.lines)
+	}
+	b.WriteString("\r\033[J")
+	io.WriteString(d.W, b.String())
+	d.lines = 0
+}
+
+func (d *Dashboard) draw() {
+	rows := d.render()
+	var b strings.Builder
+	if d.lines > 0 {
+		fmt.Fprintf(&b, "\033[%dA", d.lines)
+	}
+	for _, row := range rows {
+		b.WriteString("\r\033[K")
+		b.WriteString(row)
+		b.WriteByte('\n')
+	}
+	io.WriteString(d.W, b.String())
+	d.lines = len(rows)
+}
+
+func (d *Dashboard) render() []string {
+	s := d.Progress.Snapshot()
+	if s.Phase == "" {
+		return []string{"phase     starting"}
+	}
+	rows := []string{fmt.Sprintf("phase     %s (%s)", s.Phase, s.Elapsed.Round(time.Second/10))}
+	if s.Total > 0 {
+		pct := float64(s.Done) / float64(s.Total)
+		rows = append(rows, fmt.Sprintf("rows      %d / %d %s %5.1f%%", s.Done, s.Total, bar(pct, 30), pct*100))
+		eta := "-"
+		if d, ok := s.ETA(); ok {
+			eta = d.Round(time.Second).String()
+		}
+		rows = append(rows, fmt.Sprintf("rate      %.0f rows/s, ETA %s", s.Rate(), eta))
+	}
+	if d.Registry != nil {
+		heap := d.Registry.Gauge("go_heap_alloc_bytes", "", nil).Value()
+		duck := d.Registry.Gauge("duckdb_memory_bytes", "", nil).Value()
+		if heap > 0 || duck > 0 {
+			rows = append(rows, fmt.Sprintf("memory    go heap %s, duckdb %s", formatBytes(heap), formatBytes(duck)))
+		}
+	}
+	return rows
+}
+
+func bar(frac float64, width int) string {
+	n := min(max(int(frac*float64(width)), 0), width)
+	return "[" + strings.Repeat("#", n) + strings.Repeat(".", width-n) + "]"
+}
+
+func formatBytes(n float64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	exp := 0
+	for n >= unit*unit && exp < 4 {
+		n /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", n/unit, "KMGTP"[exp])
+}
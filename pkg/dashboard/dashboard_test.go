@@ -0,0 +1,53 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/metrics"
+)
+
+// TestRunObserved runs a benchmark with everything which reads its state from other goroutines enabled: the
+// resource sampler, progress reporting and the dashboard drawing it. It is most useful run with -race.
+func TestRunObserved(t *testing.T) {
+	cfg := duckbench.Config{
+		N:              2000,
+		SampleInterval: time.Millisecond,
+		Metrics:        metrics.NewRegistry(),
+		Progress:       new(duckbench.Progress),
+	}
+	dash := &Dashboard{W: io.Discard, Progress: cfg.Progress, Registry: cfg.Metrics, Interval: time.Millisecond}
+	dash.Start()
+	res, err := duckbench.Run(context.Background(), cfg)
+	dash.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Samples) == 0 {
+		t.Error("no resource samples taken")
+	}
+	if s := cfg.Progress.Snapshot(); s.Phase != duckbench.PhaseQuery {
+		t.Errorf("progress ended in phase %q, want %q", s.Phase, duckbench.PhaseQuery)
+	}
+}
+
+func TestDrawInPlace(t *testing.T) {
+	var buf bytes.Buffer
+	d := &Dashboard{W: &buf, Progress: new(duckbench.Progress)}
+	d.draw()
+	d.draw()
+	d.clear()
+	out := buf.String()
+	if !strings.Contains(out, "phase     starting") {
+		t.Errorf("missing phase line in %q", out)
+	}
+	// the second draw and the clear each move back up over the one line drawn
+	if n := strings.Count(out, "\033[1A"); n != 2 {
+		t.Errorf("moved the cursor up %d times, want 2: %q", n, out)
+	}
+}
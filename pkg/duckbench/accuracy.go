@@ -0,0 +1,156 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand/v2"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// AccuracyDataset is a dataset of the accuracy report, whose i-th value Value makes from a uniform u in [0, 1).
+type AccuracyDataset struct {
+	Name  string
+	Value func(i int, u float64) float64
+}
+
+// AccuracyDatasets are the datasets of the accuracy report: uniform values, well conditioned, to compare the rest
+// against, then values huge next to their spread, and magnitudes cancelling each other out.
+var AccuracyDatasets = []AccuracyDataset{
+	{"uniform", func(_ int, u float64) float64 { return u }},
+	{"1e9 + uniform", func(_ int, u float64) float64 { return 1e9 + u }},
+	{"1e13 + uniform", func(_ int, u float64) float64 { return 1e13 + u }},
+	{"1 + 1e-9 uniform", func(_ int, u float64) float64 { return 1 + 1e-9*u }},
+	{"alternating magnitudes", func(i int, u float64) float64 {
+		return math.Pow(10, float64(i%16)) * float64(1-2*(i/16%2)) * (1 + u)
+	}},
+}
+
+// exactPrec returns enough bits for a big.Float to hold any sum of up to 2^64 of values exactly: one for each power
+// of two from the lowest bit of the smallest value to the highest of the largest, and 64 for the carries.
+func exactPrec(values []float64) uint {
+	lo, hi := math.MaxInt, math.MinInt
+	for _, v := range values {
+		if v != 0 {
+			_, exp := math.Frexp(v)
+			lo, hi = min(lo, exp), max(hi, exp)
+		}
+	}
+	if lo > hi {
+		return 64
+	}
+	return uint(hi-lo) + 53 + 64
+}
+
+// ExactMeanStdDev returns the mean and population standard deviation of finite values, or NaN if there are none.
+// Their sum is exact, and the rest is rounded to at least as many bits again, so both are the float64s nearest the
+// true statistics.
+func ExactMeanStdDev(values []float64) (mean, stddev float64) {
+	if len(values) == 0 {
+		return math.NaN(), math.NaN()
+	}
+	prec := exactPrec(values)
+	n := new(big.Float).SetPrec(prec).SetInt64(int64(len(values)))
+	m := new(big.Float).SetPrec(prec)
+	x := new(big.Float).SetPrec(prec)
+	for _, v := range values {
+		m.Add(m, x.SetFloat64(v))
+	}
+	m.Quo(m, n)
+	squares := new(big.Float).SetPrec(prec)
+	for _, v := range values {
+		x.SetFloat64(v)
+		x.Sub(x, m)
+		squares.Add(squares, x.Mul(x, x))
+	}
+	squares.Sqrt(squares.Quo(squares, n))
+	mean, _ = m.Float64()
+	stddev, _ = squares.Float64()
+	return mean, stddev
+}
+
+// textbookMeanStdDev is the one-pass formula of the textbooks, the variance being the mean square less the squared
+// mean, which cancels catastrophically when the values are large next to their spread.
+func textbookMeanStdDev(values []float64) (mean, stddev float64) {
+	var sum, squares float64
+	for _, v := range values {
+		sum += v
+		squares += v * v
+	}
+	n := float64(len(values))
+	mean = sum / n
+	return mean, math.Sqrt(math.Max(squares/n-mean*mean, 0))
+}
+
+// Accuracy is how far the mean and standard deviation a method calculated for a dataset are from the exact ones,
+// relative to them.
+type Accuracy struct {
+	Dataset, Method        string
+	MeanError, StdDevError float64
+}
+
+// AccuracyMethods are the methods of the accuracy report, in order: each Summation of the Go engine, the textbook
+// one-pass formula, Welford's algorithm and DuckDB.
+var AccuracyMethods = []string{string(SumNaive), string(SumPairwise), string(SumNeumaier), "textbook", "welford", "duckdb"}
+
+// AccuracyReport calculates the mean and standard deviation of n values of each of datasets with each of
+// AccuracyMethods, returning the relative error of each against ExactMeanStdDev, in that order.
+func AccuracyReport(ctx context.Context, datasets []AccuracyDataset, n int, seed uint64) ([]Accuracy, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("n %d is not positive", n)
+	}
+	var report []Accuracy
+	for _, dataset := range datasets {
+		r := rand.New(rand.NewPCG(seed, seed))
+		values := make([]float64, n)
+		records := make([]Record, n)
+		for i := range values {
+			values[i] = dataset.Value(i, r.Float64())
+			records[i] = Record{ID: i, Value: values[i]}
+		}
+		mean, stddev := ExactMeanStdDev(values)
+		add := func(method string, s Stats) {
+			report = append(report, Accuracy{Dataset: dataset.Name, Method: method,
+				MeanError: floatcmp.RelDiff(s.Mean, mean), StdDevError: floatcmp.RelDiff(s.StdDev, stddev)})
+		}
+
+		for _, sum := range Summations {
+			s, err := StatisticsWithSummation(records, sum)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", dataset.Name, sum, err)
+			}
+			add(string(sum), s)
+		}
+		var s Stats
+		s.Mean, s.StdDev = textbookMeanStdDev(values)
+		add("textbook", s)
+		s, err := StreamingStatistics(records)
+		if err != nil {
+			return nil, fmt.Errorf("%s: welford: %w", dataset.Name, err)
+		}
+		add("welford", s)
+		if s, err = datasetStatisticsFromDB(ctx, records); err != nil {
+			return nil, fmt.Errorf("%s: duckdb: %w", dataset.Name, err)
+		}
+		add("duckdb", s)
+	}
+	return report, nil
+}
+
+// datasetStatisticsFromDB calculates the statistics of records in a fresh in-memory database.
+func datasetStatisticsFromDB(ctx context.Context, records []Record) (Stats, error) {
+	db, err := CreateDB(ctx, DBOptions{})
+	if err != nil {
+		return Stats{}, err
+	}
+	defer db.Close()
+	if err := CreateRecordsTable(ctx, db); err != nil {
+		return Stats{}, err
+	}
+	if err := AppenderInsert(ctx, records, db); err != nil {
+		return Stats{}, err
+	}
+	return StatisticsFromDB(ctx, db)
+}
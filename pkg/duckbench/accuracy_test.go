@@ -0,0 +1,57 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestExactMeanStdDev(t *testing.T) {
+	tests := []struct {
+		name         string
+		values       []float64
+		mean, stddev float64
+	}{
+		{"small integers", []float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, 2},
+		// float64 sums lose the 1 entirely
+		{"cancelling", []float64{1e100, 1, -1e100}, 1.0 / 3, 8.164965809277261e99},
+		{"constant", []float64{0.1, 0.1, 0.1}, 0.1, 0},
+	}
+	for _, tt := range tests {
+		mean, stddev := ExactMeanStdDev(tt.values)
+		if mean != tt.mean || stddev != tt.stddev {
+			t.Errorf("%s: mean %v, stddev %v, want %v, %v", tt.name, mean, stddev, tt.mean, tt.stddev)
+		}
+	}
+	if mean, stddev := ExactMeanStdDev(nil); !math.IsNaN(mean) || !math.IsNaN(stddev) {
+		t.Errorf("no values: mean %v, stddev %v, want NaN", mean, stddev)
+	}
+}
+
+func TestAccuracyReport(t *testing.T) {
+	if _, err := AccuracyReport(context.Background(), AccuracyDatasets, 0, 1); err == nil {
+		t.Error("AccuracyReport of no values succeeded")
+	}
+	report, err := AccuracyReport(context.Background(), AccuracyDatasets, 10000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != len(AccuracyDatasets)*len(AccuracyMethods) {
+		t.Fatalf("%d results, want one for each of %d methods for %d datasets", len(report), len(AccuracyMethods), len(AccuracyDatasets))
+	}
+	errors := make(map[[2]string]Accuracy)
+	for _, a := range report {
+		errors[[2]string{a.Dataset, a.Method}] = a
+	}
+	// a compensated sum is within a few ulps of the exact mean everywhere; the stddev is only as accurate as the
+	// float64 nearest the mean, from which the deviations are taken
+	for _, dataset := range AccuracyDatasets {
+		if a := errors[[2]string{dataset.Name, string(SumNeumaier)}]; a.MeanError > 1e-15 {
+			t.Errorf("%s: neumaier mean error %g, want at most 1e-15", dataset.Name, a.MeanError)
+		}
+	}
+	// while the textbook formula loses the spread of values far from zero
+	if a := errors[[2]string{"1e9 + uniform", "textbook"}]; a.StdDevError < 1e-3 {
+		t.Errorf("textbook stddev error %g, want it to be large", a.StdDevError)
+	}
+}
@@ -0,0 +1,224 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+	"github.com/rpep/duckdb-go-experiments/pkg/sketch"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+// ApproxDistinct estimates the count of Distinct with a HyperLogLog in Go and approx_count_distinct in DuckDB.
+type ApproxDistinct struct {
+	// Precision is that of the HyperLogLog, sketch.DefaultPrecision if unset.
+	Precision uint8
+}
+
+func (ApproxDistinct) Name() string { return "approx_distinct" }
+
+func (a ApproxDistinct) Go(records []Record) (WorkloadResult, error) {
+	precision := a.Precision
+	if precision == 0 {
+		precision = sketch.DefaultPrecision
+	}
+	h := sketch.NewHyperLogLog(precision)
+	for _, r := range records {
+		if !finite(r.Value) {
+			continue
+		}
+		v := r.Value
+		if v == 0 {
+			// -0 is the same value as 0, with different bits
+			v = 0
+		}
+		h.Add(sketch.Hash64(math.Float64bits(v)))
+	}
+	return DistinctCount(h.Estimate()), nil
+}
+
+func (ApproxDistinct) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	row, err := sqlscan.One[distinctRow](ctx, db, "SELECT approx_count_distinct(value) AS n FROM records WHERE isfinite(value)")
+	if err != nil {
+		return nil, fmt.Errorf("querying approximate distinct count: %w", err)
+	}
+	return DistinctCount(row.N), nil
+}
+
+func (ApproxDistinct) Exact(records []Record) (WorkloadResult, error) { return Distinct{}.Go(records) }
+
+// Accuracy allows an error of 40%, three standard errors of approx_count_distinct, whose HyperLogLog has only 64
+// registers since DuckDB 1.1. The Go sketch is far closer at its default precision.
+func (ApproxDistinct) Accuracy() float64 { return 0.4 }
+
+// Error is the error of d relative to the exact count.
+func (d DistinctCount) Error(exact WorkloadResult) float64 {
+	e := exact.(DistinctCount)
+	if d == e {
+		return 0
+	}
+	return math.Abs(float64(d-e)) / float64(e)
+}
+
+// ApproxQuantiles estimates quantiles of the finite values with a t-digest in Go and approx_quantile in DuckDB.
+// Their errors are in rank: how far the fraction of the values below each estimate is from its quantile.
+type ApproxQuantiles struct {
+	// Quantiles are DefaultPercentiles if unset.
+	Quantiles []float64
+	// Compression is that of the t-digest, sketch.DefaultCompression if unset.
+	Compression float64
+}
+
+// QuantileEstimates is the result of ApproxQuantiles, the value of each quantile.
+type QuantileEstimates struct {
+	Quantiles, Values []float64
+	// sorted are the finite values, for the exact result to find the rank of estimates in.
+	sorted []float64
+}
+
+func (q QuantileEstimates) LogValue() slog.Value {
+	return slog.GroupValue(slog.Any("quantiles", q.Quantiles), slog.Any("values", q.Values))
+}
+
+func (ApproxQuantiles) Name() string { return "approx_quantile" }
+
+func (a ApproxQuantiles) quantiles() ([]float64, error) {
+	if a.Quantiles == nil {
+		return DefaultPercentiles, nil
+	}
+	return a.Quantiles, checkPercentiles(a.Quantiles)
+}
+
+func (a ApproxQuantiles) Go(records []Record) (WorkloadResult, error) {
+	quantiles, err := a.quantiles()
+	if err != nil {
+		return nil, err
+	}
+	compression := a.Compression
+	if compression == 0 {
+		compression = sketch.DefaultCompression
+	}
+	d := sketch.NewTDigest(compression)
+	empty := true
+	for _, r := range records {
+		if finite(r.Value) {
+			d.Add(r.Value)
+			empty = false
+		}
+	}
+	if empty {
+		return nil, ErrNoValues
+	}
+	res := QuantileEstimates{Quantiles: quantiles, Values: make([]float64, len(quantiles))}
+	for i, q := range quantiles {
+		res.Values[i] = d.Quantile(q)
+	}
+	return res, nil
+}
+
+type quantilesRow struct {
+	Quantiles nullable.Nullable[[]any] `db:"quantiles"`
+}
+
+func (a ApproxQuantiles) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	quantiles, err := a.quantiles()
+	if err != nil {
+		return nil, err
+	}
+	row, err := sqlscan.One[quantilesRow](ctx, db, fmt.Sprintf(
+		"SELECT approx_quantile(value, %s) AS quantiles FROM records WHERE isfinite(value)", listLiteral(quantiles)))
+	if err != nil {
+		return nil, fmt.Errorf("querying approximate quantiles: %w", err)
+	}
+	if !row.Quantiles.Valid {
+		return nil, ErrNoValues
+	}
+	res := QuantileEstimates{Quantiles: quantiles}
+	for i, v := range row.Quantiles.V {
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("quantile %g is %T, not a float64", quantiles[i], v)
+		}
+		res.Values = append(res.Values, f)
+	}
+	return res, nil
+}
+
+// Exact interpolates the quantiles as quantile_cont does.
+func (a ApproxQuantiles) Exact(records []Record) (WorkloadResult, error) {
+	quantiles, err := a.quantiles()
+	if err != nil {
+		return nil, err
+	}
+	var sorted []float64
+	for _, r := range records {
+		if finite(r.Value) {
+			sorted = append(sorted, r.Value)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil, ErrNoValues
+	}
+	slices.Sort(sorted)
+	res := QuantileEstimates{Quantiles: quantiles, Values: make([]float64, len(quantiles)), sorted: sorted}
+	for i, q := range quantiles {
+		res.Values[i] = percentile(sorted, q)
+	}
+	return res, nil
+}
+
+// Accuracy allows the estimates to be a percentile from the quantiles asked for.
+func (ApproxQuantiles) Accuracy() float64 { return 0.01 }
+
+// Error is the largest rank error of the estimates, where an estimate equal to many values has the range of their
+// ranks, and one between two adjacent values, as a t-digest interpolates an estimate between repeated values, has
+// the ranks of both.
+func (q QuantileEstimates) Error(exact WorkloadResult) float64 {
+	sorted := exact.(QuantileEstimates).sorted
+	n := float64(len(sorted))
+	var worst float64
+	for i, v := range q.Values {
+		lo, found := slices.BinarySearch(sorted, v)
+		hi := lo
+		if !found && lo > 0 && lo < len(sorted) {
+			// from the first of the value before to the last of the value after
+			lo, _ = slices.BinarySearch(sorted, sorted[lo-1])
+			v = sorted[hi]
+		}
+		for hi < len(sorted) && sorted[hi] == v {
+			hi++
+		}
+		target := q.Quantiles[i] * n
+		var err float64
+		switch {
+		case target < float64(lo):
+			err = (float64(lo) - target) / n
+		case target > float64(hi):
+			err = (target - float64(hi)) / n
+		}
+		worst = math.Max(worst, err)
+	}
+	return worst
+}
+
+// Compare compares the values of each quantile within tol.
+func (q QuantileEstimates) Compare(other WorkloadResult, tol floatcmp.Tolerance) []Mismatch {
+	o, ok := other.(QuantileEstimates)
+	if !ok {
+		return []Mismatch{{Stat: "type"}}
+	}
+	if len(q.Values) != len(o.Values) {
+		return []Mismatch{{Stat: "quantiles", A: float64(len(q.Values)), B: float64(len(o.Values))}}
+	}
+	var mismatches []Mismatch
+	for i := range q.Values {
+		if !tol.Equal(q.Values[i], o.Values[i]) {
+			mismatches = append(mismatches, Mismatch{Stat: Percentile{P: q.Quantiles[i]}.Name(), A: q.Values[i], B: o.Values[i]})
+		}
+	}
+	return mismatches
+}
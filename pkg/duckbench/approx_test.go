@@ -0,0 +1,53 @@
+package duckbench
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestApproximations(t *testing.T) {
+	for _, dist := range []Distribution{DistUniform, DistNormal, DistZipf} {
+		t.Run(string(dist), func(t *testing.T) {
+			res, err := Run(context.Background(), Config{N: 100000, Distribution: dist, Inserts: []InsertStrategy{InsertAppender},
+				Workloads: []Workload{ApproxDistinct{}, ApproxQuantiles{Quantiles: []float64{0.01, 0.5, 0.99}}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range res.Workloads {
+				if w.Exact == nil {
+					t.Fatalf("%s has no exact result", w.Name)
+				}
+				if m := w.Mismatches(floatcmp.Exact); len(m) > 0 {
+					t.Errorf("%s errors beyond %g: %+v", w.Name, w.Accuracy, m)
+				}
+				t.Logf("%s: go error %.3g, duckdb error %.3g", w.Name, w.GoError, w.DBError)
+			}
+		})
+	}
+}
+
+func TestQuantileEstimatesError(t *testing.T) {
+	exact, err := ApproxQuantiles{Quantiles: []float64{0.5}}.Exact(recordsOf(1, 2, 2, 2, 3, 4, 5, 6, 7, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		value, want float64
+	}{
+		{3.5, 0}, // the exact median, with 5 of the 10 values below it
+		{4, 0},   // 5 below
+		{2, 0.1}, // 1 to 4 below, depending on how many of the 2s
+		{4.5, 0}, // between 4 and 5, so as close as 4
+		{2.5, 0}, // between 2 and 3, 1 to 5 below
+		{8, 0.4}, // 9 below
+		{0, 0.5}, // before them all
+		{9, 0.5}, // after them all
+	} {
+		got := QuantileEstimates{Quantiles: []float64{0.5}, Values: []float64{tt.value}}.Error(exact)
+		if !floatcmp.Abs(1e-12).Equal(got, tt.want) {
+			t.Errorf("median estimated as %g: error %g, want %g", tt.value, got, tt.want)
+		}
+	}
+}
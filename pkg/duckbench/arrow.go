@@ -0,0 +1,99 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/marcboeker/go-duckdb"
+)
+
+// arrowBatchRows is how many records ArrowInsert converts into each Arrow record batch.
+const arrowBatchRows = 1 << 17
+
+// arrowViews numbers the views of ArrowInsert, which are visible to every connection, so that concurrent calls do
+// not replace each other's.
+var arrowViews atomic.Int64
+
+// arrowSchema is that of the records table, in the order of its columns.
+var arrowSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "id", Type: arrow.PrimitiveTypes.Int32},
+	{Name: "value", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	{Name: "value2", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "category", Type: arrow.BinaryTypes.String},
+	{Name: "ts", Type: arrow.FixedWidthTypes.Timestamp_us, Nullable: true},
+}, nil)
+
+// ArrowInsert converts records into Arrow record batches and registers them with DuckDB as a view over an Arrow
+// scan, on a connection of its own, then copies them into the records table with a single INSERT ... SELECT. DuckDB
+// reads the batches' columns in place, with no statement or driver call per row.
+func ArrowInsert(ctx context.Context, records []Record, db *sql.DB) error {
+	var batches []arrow.Record
+	defer func() {
+		for _, b := range batches {
+			b.Release()
+		}
+	}()
+	for start := 0; start < len(records); start += arrowBatchRows {
+		batches = append(batches, arrowBatch(records[start:min(start+arrowBatchRows, len(records))]))
+	}
+	reader, err := array.NewRecordReader(arrowSchema, batches)
+	if err != nil {
+		return fmt.Errorf("reading record batches: %w", err)
+	}
+	defer reader.Release()
+
+	return rawConn(ctx, db, func(conn driver.Conn) error {
+		a, err := duckdb.NewArrowFromConn(conn)
+		if err != nil {
+			return fmt.Errorf("opening Arrow interface: %w", err)
+		}
+		view := fmt.Sprintf("arrow_records_%d", arrowViews.Add(1))
+		release, err := a.RegisterView(reader, view)
+		if err != nil {
+			return fmt.Errorf("registering record batches: %w", err)
+		}
+		defer release()
+		execer := conn.(driver.ExecerContext)
+		// the view scans the batches once, so it is dropped with them
+		defer execer.ExecContext(context.Background(), "DROP VIEW IF EXISTS "+view, nil)
+		if _, err := execer.ExecContext(ctx, "INSERT INTO records SELECT * FROM "+view, nil); err != nil {
+			return fmt.Errorf("inserting from record batches: %w", err)
+		}
+		reportProgress(ctx, int64(len(records)))
+		return nil
+	})
+}
+
+// arrowBatch builds an Arrow record batch of records in arrowSchema, numbering their ids as AppenderInsert does.
+func arrowBatch(records []Record) arrow.Record {
+	b := array.NewRecordBuilder(memory.DefaultAllocator, arrowSchema)
+	defer b.Release()
+	id := b.Field(0).(*array.Int32Builder)
+	value := b.Field(1).(*array.Float64Builder)
+	value2 := b.Field(2).(*array.Float64Builder)
+	category := b.Field(3).(*array.StringBuilder)
+	ts := b.Field(4).(*array.TimestampBuilder)
+	b.Reserve(len(records))
+	for _, r := range records {
+		id.Append(int32(r.ID + 1))
+		if r.Null {
+			value.AppendNull()
+		} else {
+			value.Append(r.Value)
+		}
+		value2.Append(r.Value2)
+		category.Append(r.Category)
+		if r.Time.IsZero() {
+			ts.AppendNull()
+		} else {
+			ts.Append(arrow.Timestamp(r.Time.UnixMicro()))
+		}
+	}
+	return b.NewRecord()
+}
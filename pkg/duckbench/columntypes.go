@@ -0,0 +1,219 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// ColumnType is a type the values can be stored as in the typed_records table, to compare the cost of each with the
+// DOUBLE of the records table.
+type ColumnType string
+
+const (
+	ColumnDouble  ColumnType = "double"
+	ColumnFloat   ColumnType = "float"
+	ColumnInteger ColumnType = "integer"
+	ColumnBigint  ColumnType = "bigint"
+	// ColumnDecimal is DECIMAL(18,3), which DuckDB stores as a BIGINT scaled by 1000.
+	ColumnDecimal ColumnType = "decimal"
+)
+
+// ColumnTypes lists the supported column types.
+var ColumnTypes = []ColumnType{ColumnDouble, ColumnFloat, ColumnInteger, ColumnBigint, ColumnDecimal}
+
+func (t *ColumnType) UnmarshalText(text []byte) error {
+	for _, typ := range ColumnTypes {
+		if string(text) == string(typ) {
+			*t = typ
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown column type %q, expected one of %v", text, ColumnTypes)
+}
+
+// SQL is the type of the column in DuckDB.
+func (t ColumnType) SQL() string {
+	if t == ColumnDecimal {
+		return "DECIMAL(18,3)"
+	}
+	return strings.ToUpper(string(t))
+}
+
+// goValue is v as the Appender takes it for a column of type t, rounded to the nearest integer for the integer
+// types, which it must fit, and to the nearest thousandth for DECIMAL(18,3), which the Appender takes as a
+// duckdb.Decimal of the scaled integer.
+func (t ColumnType) goValue(v float64) driver.Value {
+	switch t {
+	case ColumnFloat:
+		return float32(v)
+	case ColumnInteger:
+		return int32(math.Round(v))
+	case ColumnBigint:
+		return int64(math.Round(v))
+	case ColumnDecimal:
+		return duckdb.Decimal{Width: 18, Scale: 3, Value: big.NewInt(int64(math.Round(v * 1000)))}
+	default:
+		return v
+	}
+}
+
+// CreateTypedTable replaces the typed_records table with an empty one whose value column is of type t.
+func CreateTypedTable(ctx context.Context, db Execer, t ColumnType) error {
+	_, err := db.ExecContext(ctx, "CREATE OR REPLACE TABLE typed_records (value "+t.SQL()+")")
+	return err
+}
+
+// TypedAppenderInsert appends the values of records to the typed_records table of type t, converted to the Go type
+// the Appender takes for it.
+func TypedAppenderInsert(ctx context.Context, db *sql.DB, t ColumnType, records []Record) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("opening connection: %w", err)
+	}
+	defer conn.Close()
+	return conn.Raw(func(dc any) error {
+		appender, err := duckdb.NewAppenderFromConn(sqlwrap.Unwrap(dc).(driver.Conn), "", "typed_records")
+		if err != nil {
+			return fmt.Errorf("creating appender: %w", err)
+		}
+		for i, r := range records {
+			if err := appender.AppendRow(t.goValue(r.Value)); err != nil {
+				appender.Close()
+				return fmt.Errorf("appending record %d: %w", i, err)
+			}
+			if (i+1)%appenderFlushRows == 0 {
+				if err := errors.Join(ctx.Err(), appender.Flush()); err != nil {
+					appender.Close()
+					return fmt.Errorf("flushing appender: %w", err)
+				}
+			}
+		}
+		if err := appender.Close(); err != nil {
+			return fmt.Errorf("closing appender: %w", err)
+		}
+		return nil
+	})
+}
+
+// TypedCastInsert fills the typed_records table of type t from the records table, casting each value in DuckDB.
+func TypedCastInsert(ctx context.Context, db Execer, t ColumnType) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO typed_records SELECT CAST(value AS "+t.SQL()+") FROM records")
+	return err
+}
+
+// TypedAggregate is the aggregates of the typed_records table, each calculated in the column's type (SUM of an
+// INTEGER is a HUGEINT, of a DECIMAL(18,3) a DECIMAL(38,3)) and then cast to a DOUBLE.
+type TypedAggregate struct {
+	Count               int64
+	Sum, Mean, Min, Max float64
+}
+
+type typedAggregateRow struct {
+	Count int64                      `db:"count"`
+	Sum   nullable.Nullable[float64] `db:"sum"`
+	Mean  nullable.Nullable[float64] `db:"mean"`
+	Min   nullable.Nullable[float64] `db:"min"`
+	Max   nullable.Nullable[float64] `db:"max"`
+}
+
+// TypedAggregateFromDB calculates the aggregates of the typed_records table, returning ErrNoValues if it is empty.
+func TypedAggregateFromDB(ctx context.Context, db Queryer) (TypedAggregate, error) {
+	row, err := sqlscan.One[typedAggregateRow](ctx, db, `
+		SELECT COUNT(value) AS count, SUM(value)::DOUBLE AS sum, AVG(value)::DOUBLE AS mean,
+			MIN(value)::DOUBLE AS min, MAX(value)::DOUBLE AS max
+		FROM typed_records`)
+	if err != nil {
+		return TypedAggregate{}, fmt.Errorf("querying aggregates: %w", err)
+	}
+	if !row.Sum.Valid {
+		return TypedAggregate{}, ErrNoValues
+	}
+	return TypedAggregate{Count: row.Count, Sum: row.Sum.V, Mean: row.Mean.V, Min: row.Min.V, Max: row.Max.V}, nil
+}
+
+// A ScanMapping reads the value column of the typed_records table into a Go type.
+type ScanMapping struct {
+	Name string
+	scan func(ctx context.Context, db Queryer) (float64, error)
+}
+
+// Scan reads every value, returning their sum as a float64 to check the mapping against the others.
+func (m ScanMapping) Scan(ctx context.Context, db Queryer) (float64, error) {
+	return m.scan(ctx, db)
+}
+
+// ScanMappings returns the ways of reading a column of type t into Go. database/sql only assigns a value the driver
+// returns directly to a destination of the same type; into any other it converts through a string.
+func ScanMappings(t ColumnType) []ScanMapping {
+	switch t {
+	case ColumnFloat:
+		return []ScanMapping{
+			scanAs("float32", "value", func(v float32) float64 { return float64(v) }),
+			scanAs("float64", "value", func(v float64) float64 { return v }),
+		}
+	case ColumnInteger:
+		return []ScanMapping{
+			scanAs("int32", "value", func(v int32) float64 { return float64(v) }),
+			scanAs("int64", "value", func(v int64) float64 { return float64(v) }),
+		}
+	case ColumnBigint:
+		return []ScanMapping{
+			scanAs("int64", "value", func(v int64) float64 { return float64(v) }),
+			scanAs("float64", "value", func(v float64) float64 { return v }),
+		}
+	case ColumnDecimal:
+		return []ScanMapping{
+			scanAs("duckdb.Decimal", "value", func(d duckdb.Decimal) float64 { return d.Float64() }),
+			scanAs("big.Rat", "value", func(d duckdb.Decimal) float64 {
+				f, _ := decimalRat(d).Float64()
+				return f
+			}),
+			scanAs("string", "value::VARCHAR", func(s string) float64 {
+				f, _ := strconv.ParseFloat(s, 64)
+				return f
+			}),
+			scanAs("float64 (cast to DOUBLE)", "value::DOUBLE", func(v float64) float64 { return v }),
+		}
+	default:
+		return []ScanMapping{scanAs("float64", "value", func(v float64) float64 { return v })}
+	}
+}
+
+// decimalRat is the exact value of d.
+func decimalRat(d duckdb.Decimal) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.Scale)), nil)
+	return new(big.Rat).SetFrac(d.Value, scale)
+}
+
+// scanAs is a ScanMapping selecting expr into a T, which value converts to a float64 to be summed.
+func scanAs[T any](name, expr string, value func(T) float64) ScanMapping {
+	return ScanMapping{Name: name, scan: func(ctx context.Context, db Queryer) (float64, error) {
+		rows, err := db.QueryContext(ctx, "SELECT "+expr+" FROM typed_records")
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		var sum float64
+		for rows.Next() {
+			var v T
+			if err := rows.Scan(&v); err != nil {
+				return 0, fmt.Errorf("scanning %s into %s: %w", expr, name, err)
+			}
+			sum += value(v)
+		}
+		return sum, rows.Err()
+	}}
+}
@@ -0,0 +1,57 @@
+package duckbench
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// TestColumnTypes checks that records inserted into a column of every type by both methods aggregate to the same
+// values, and that every scan mapping reads them back.
+func TestColumnTypes(t *testing.T) {
+	ctx := context.Background()
+	records := GenerateRecords(1000)
+	db := loadDB(t, nil)
+	if err := AppenderInsert(ctx, records, db); err != nil {
+		t.Fatal(err)
+	}
+	// 0 to 999 are exact in every type
+	want := TypedAggregate{Count: 1000, Sum: 499500, Mean: 499.5, Min: 0, Max: 999}
+	for _, typ := range ColumnTypes {
+		t.Run(string(typ), func(t *testing.T) {
+			// the cast goes last, so the table it fills is scanned below
+			inserts := []struct {
+				name   string
+				insert func() error
+			}{
+				{"appender", func() error { return TypedAppenderInsert(ctx, db, typ, records) }},
+				{"cast", func() error { return TypedCastInsert(ctx, db, typ) }},
+			}
+			for _, in := range inserts {
+				name := in.name
+				if err := CreateTypedTable(ctx, db, typ); err != nil {
+					t.Fatal(err)
+				}
+				if err := in.insert(); err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				got, err := TypedAggregateFromDB(ctx, db)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("%s: aggregates %+v, want %+v", name, got, want)
+				}
+			}
+			for _, m := range ScanMappings(typ) {
+				sum, err := m.Scan(ctx, db)
+				if err != nil {
+					t.Errorf("%s: %v", m.Name, err)
+				} else if !floatcmp.Rel(1e-12).Equal(sum, want.Sum) {
+					t.Errorf("%s: sum %v, want %v", m.Name, sum, want.Sum)
+				}
+			}
+		})
+	}
+}
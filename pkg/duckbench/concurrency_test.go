@@ -0,0 +1,201 @@
+package duckbench
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/metrics"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// These tests exercise the paths which run concurrently and are most useful run with -race (make race).
+
+// TestConcurrentIngestion inserts from several goroutines through the pool, with every hook observing, and
+// checks no row was lost.
+func TestConcurrentIngestion(t *testing.T) {
+	ctx := context.Background()
+	registry := metrics.NewRegistry()
+	shapes := sqlwrap.NewShapes(registry)
+	db, err := CreateDB(ctx, DBOptions{Hooks: []sqlwrap.Hook{
+		sqlwrap.NewAudit(io.Discard), sqlwrap.Metrics(registry), shapes,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := CreateRecordsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 8, 200
+	records := GenerateRecords(workers * perWorker)
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(batch []Record) {
+			defer wg.Done()
+			for _, r := range batch {
+				if _, err := db.ExecContext(ctx, "INSERT INTO records (value, value2) VALUES (?, ?)", r.Value, r.Value2); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(records[w*perWorker : (w+1)*perWorker])
+	}
+	// scrape the hooks' metrics while they are being written
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			registry.WritePrometheus(io.Discard)
+			shapes.ServeHTTP(httptest.NewRecorder(), nil)
+		}
+	}()
+	wg.Wait()
+	<-done
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if err := VerifyIngestion(ctx, db, records); err != nil {
+		t.Fatal(err)
+	}
+	got := registry.Counter("duckdb_statements_total", "", metrics.Labels{"op": string(sqlwrap.OpExec)}).Value()
+	if want := uint64(len(records) + 1); got != want {
+		t.Errorf("duckdb_statements_total{op=exec} = %d, want %d", got, want)
+	}
+}
+
+// TestConcurrentAppenderInsert appends from several goroutines at once, which is only safe because each
+// AppenderInsert has an appender and connection of its own.
+func TestConcurrentAppenderInsert(t *testing.T) {
+	ctx := context.Background()
+	db, err := CreateDB(ctx, DBOptions{Hooks: []sqlwrap.Hook{sqlwrap.NewAudit(io.Discard)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := CreateRecordsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 8, 5000
+	records := GenerateRecords(workers * perWorker)
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs[w] = AppenderInsert(ctx, records[w*perWorker:(w+1)*perWorker], db)
+		}(w)
+	}
+	wg.Wait()
+	for w, err := range errs {
+		if err != nil {
+			t.Fatalf("worker %d: %v", w, err)
+		}
+	}
+	if err := VerifyIngestion(ctx, db, records); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConcurrentQueries fans the statistics query out over the pool and checks every reader sees the same result.
+func TestConcurrentQueries(t *testing.T) {
+	ctx := context.Background()
+	db := loadDB(t, GenerateRecords(1000))
+	want, err := StatisticsFromDB(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const readers = 16
+	var wg sync.WaitGroup
+	results := make([]Stats, readers)
+	errs := make([]error, readers)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = StatisticsFromDB(ctx, db)
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatalf("reader %d: %v", i, errs[i])
+		}
+		if !reflect.DeepEqual(results[i], want) {
+			t.Errorf("reader %d: got %+v, want %+v", i, results[i], want)
+		}
+	}
+}
+
+// TestConcurrentInsert shards the records over several writers for each strategy which can be sharded.
+func TestConcurrentInsert(t *testing.T) {
+	ctx := context.Background()
+	records := GenerateRecords(10001)
+	for _, strategy := range []InsertStrategy{InsertStandard, InsertAppender, ValuesInsert{BatchSize: 100}, InsertCSV, InsertArrow, CommitInsert{CommitEvery: 1000}} {
+		c := ConcurrentInsert{Workers: 4, Strategy: strategy}
+		t.Run(c.Name(), func(t *testing.T) {
+			db := loadDB(t, nil)
+			if err := c.Insert(ctx, records, db); err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyIngestion(ctx, db, records); err != nil {
+				t.Fatal(err)
+			}
+			var ids int
+			if err := db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT id) FROM records").Scan(&ids); err != nil {
+				t.Fatal(err)
+			}
+			if ids != len(records) {
+				t.Errorf("%d distinct ids, want %d", ids, len(records))
+			}
+		})
+	}
+}
+
+// TestReadDuringInsert queries the table while it is written, and checks the readers only ever saw whole
+// transactions, or whole flushes of the appender.
+func TestReadDuringInsert(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		strategy InsertStrategy
+		n        int
+		counts   []int64
+	}{
+		{InsertAppender, appenderFlushRows + 1000, []int64{0, appenderFlushRows, appenderFlushRows + 1000}},
+		{ValuesInsert{BatchSize: 100}, 5000, []int64{0, 5000}},
+		{InsertStandard, 2000, []int64{0, 2000}},
+		{CommitInsert{CommitEvery: 1000}, 2500, []int64{0, 1000, 2000, 2500}},
+	} {
+		t.Run(tc.strategy.Name(), func(t *testing.T) {
+			records := GenerateRecords(tc.n)
+			db := loadDB(t, nil)
+			res, err := ReadDuringInsert(ctx, db, records, tc.strategy, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Queries < 4 {
+				t.Errorf("%d queries, want at least one per reader", res.Queries)
+			}
+			for _, n := range res.Snapshots {
+				if !slices.Contains(tc.counts, n) {
+					t.Errorf("a reader saw %d rows, want one of %v", n, tc.counts)
+				}
+			}
+			if err := VerifyIngestion(ctx, db, records); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
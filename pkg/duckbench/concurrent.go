@@ -0,0 +1,44 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ConcurrentInsert splits the records into Workers contiguous shards and inserts them all at once, each from a
+// goroutine of its own through Strategy, so that each shard goes over its own connection, or its own Appender. It
+// shows whether ingestion scales with concurrency on the Go side or DuckDB serializes the writers. The first shard
+// to fail cancels the others.
+type ConcurrentInsert struct {
+	Workers  int
+	Strategy InsertStrategy
+}
+
+func (c ConcurrentInsert) Name() string { return fmt.Sprintf("%s/x%d", c.Strategy.Name(), c.Workers) }
+
+func (c ConcurrentInsert) Insert(ctx context.Context, records []Record, db *sql.DB) error {
+	if c.Workers < 1 {
+		return fmt.Errorf("workers %d is not positive", c.Workers)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	size := (len(records) + c.Workers - 1) / c.Workers
+	errs := make([]error, c.Workers)
+	var wg sync.WaitGroup
+	for w := range errs {
+		shard := records[min(w*size, len(records)):min((w+1)*size, len(records))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Strategy.Insert(ctx, shard, db); err != nil {
+				errs[w] = fmt.Errorf("worker %d: %w", w, err)
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,94 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+// Correlation is the population covariance and the Pearson correlation of Value and Value2, over the records whose
+// values are both finite.
+type Correlation struct{}
+
+// CorrelationResult is the result of Correlation.
+type CorrelationResult struct {
+	Covariance, Correlation float64
+}
+
+func (c CorrelationResult) LogValue() slog.Value {
+	return slog.GroupValue(slog.Float64("covariance", c.Covariance), slog.Float64("correlation", c.Correlation))
+}
+
+func (Correlation) Name() string { return "correlation" }
+
+// Go makes two passes like StatisticsWithSummation, summing the products of the deviations from the means rather
+// than the raw values, which would cancel catastrophically for values far from zero.
+func (Correlation) Go(records []Record) (WorkloadResult, error) {
+	var n int
+	var sumX, sumY float64
+	for _, r := range records {
+		if finite(r.Value) && finite(r.Value2) {
+			n++
+			sumX += r.Value
+			sumY += r.Value2
+		}
+	}
+	if n == 0 {
+		return nil, ErrNoValues
+	}
+	meanX, meanY := sumX/float64(n), sumY/float64(n)
+	var xy, xx, yy float64
+	for _, r := range records {
+		if finite(r.Value) && finite(r.Value2) {
+			dx, dy := r.Value-meanX, r.Value2-meanY
+			xy += dx * dy
+			xx += dx * dx
+			yy += dy * dy
+		}
+	}
+	return CorrelationResult{Covariance: xy / float64(n), Correlation: xy / math.Sqrt(xx*yy)}, nil
+}
+
+type correlationRow struct {
+	Covariance  nullable.Nullable[float64] `db:"covariance"`
+	Correlation nullable.Nullable[float64] `db:"correlation"`
+}
+
+func (Correlation) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	row, err := sqlscan.One[correlationRow](ctx, db, `
+		SELECT COVAR_POP(value, value2) AS covariance, CORR(value, value2) AS correlation
+		FROM records
+		WHERE isfinite(value) AND isfinite(value2)`)
+	if err != nil {
+		return nil, fmt.Errorf("querying correlation: %w", err)
+	}
+	if !row.Covariance.Valid {
+		return nil, ErrNoValues
+	}
+	// CORR is NULL rather than NaN when either column is constant
+	res := CorrelationResult{Covariance: row.Covariance.V, Correlation: math.NaN()}
+	if row.Correlation.Valid {
+		res.Correlation = row.Correlation.V
+	}
+	return res, nil
+}
+
+func (c CorrelationResult) Compare(other WorkloadResult, tol floatcmp.Tolerance) []Mismatch {
+	o, ok := other.(CorrelationResult)
+	if !ok {
+		return []Mismatch{{Stat: "type"}}
+	}
+	var mismatches []Mismatch
+	if !tol.Equal(c.Covariance, o.Covariance) {
+		mismatches = append(mismatches, Mismatch{Stat: "covariance", A: c.Covariance, B: o.Covariance})
+	}
+	if !tol.Equal(c.Correlation, o.Correlation) {
+		mismatches = append(mismatches, Mismatch{Stat: "correlation", A: c.Correlation, B: o.Correlation})
+	}
+	return mismatches
+}
@@ -0,0 +1,50 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func pairsOf(values ...[2]float64) []Record {
+	records := make([]Record, len(values))
+	for i, v := range values {
+		records[i] = Record{ID: i, Value: v[0], Value2: v[1]}
+	}
+	return records
+}
+
+func TestCorrelation(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]struct {
+		records []Record
+		want    CorrelationResult
+	}{
+		"linear":     {pairsOf([2]float64{1, 2}, [2]float64{2, 4}, [2]float64{3, 6}, [2]float64{4, 8}), CorrelationResult{Covariance: 2.5, Correlation: 1}},
+		"opposite":   {pairsOf([2]float64{1, 1}, [2]float64{2, 0}), CorrelationResult{Covariance: -0.25, Correlation: -1}},
+		"non-finite": {pairsOf([2]float64{1, 2}, [2]float64{math.NaN(), 3}, [2]float64{3, math.Inf(1)}, [2]float64{2, 4}), CorrelationResult{Covariance: 0.5, Correlation: 1}},
+		"constant":   {pairsOf([2]float64{1, 5}, [2]float64{2, 5}), CorrelationResult{Covariance: 0, Correlation: math.NaN()}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := loadDB(t, tt.records)
+			for engine, run := range map[string]func() (WorkloadResult, error){
+				"go":     func() (WorkloadResult, error) { return Correlation{}.Go(tt.records) },
+				"duckdb": func() (WorkloadResult, error) { return Correlation{}.DuckDB(ctx, db) },
+			} {
+				got, err := run()
+				if err != nil {
+					t.Fatalf("%s: %v", engine, err)
+				}
+				if m := tt.want.Compare(got, floatcmp.Rel(1e-12)); len(m) > 0 {
+					t.Errorf("%s: %+v, mismatches %+v", engine, got, m)
+				}
+			}
+		})
+	}
+	if _, err := (Correlation{}).Go(pairsOf([2]float64{math.NaN(), 1})); err != ErrNoValues {
+		t.Errorf("no finite pairs: err = %v, want ErrNoValues", err)
+	}
+}
@@ -0,0 +1,223 @@
+package duckbench
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+)
+
+// datasetColumns are the columns of a saved dataset, as given to read_csv.
+const datasetColumns = `{'id': 'BIGINT', 'value': 'DOUBLE', 'value2': 'DOUBLE', 'category': 'VARCHAR', 'ts': 'TIMESTAMP'}`
+
+// datasetSelect reads the columns of the records table from a saved dataset, in which an empty category reads as
+// NULL.
+const datasetSelect = "SELECT value, value2, COALESCE(category, '') AS category, ts FROM "
+
+// datasetTime is how writeCSV writes timestamps, which DuckDB reads as a TIMESTAMP.
+const datasetTime = "2006-01-02 15:04:05.999999"
+
+func datasetFormat(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		return "csv", nil
+	case ".parquet":
+		return "parquet", nil
+	default:
+		return "", fmt.Errorf("unsupported dataset format %q, expected .csv or .parquet", ext)
+	}
+}
+
+// quote returns s as a SQL string literal, for table functions and COPY which do not take parameters.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// SaveDataset writes records to path as CSV or Parquet, chosen by its extension, so a run can later be replayed on
+// exactly the same data with LoadDataset.
+func SaveDataset(ctx context.Context, records []Record, path string) error {
+	format, err := datasetFormat(path)
+	if err != nil {
+		return err
+	}
+	csvPath := path
+	if format == "parquet" {
+		f, err := os.CreateTemp("", "duckbench-*.csv")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		csvPath = f.Name()
+		defer os.Remove(csvPath)
+	}
+	if err := writeCSV(records, csvPath); err != nil {
+		return err
+	}
+	if format == "csv" {
+		return nil
+	}
+
+	db, err := CreateDB(ctx, DBOptions{})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.ExecContext(ctx, fmt.Sprintf("COPY (SELECT * FROM read_csv(%s, header = true, columns = %s)) TO %s (FORMAT parquet)",
+		quote(csvPath), datasetColumns, quote(path)))
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeCSV writes records in a form DuckDB reads back exactly: the shortest representation which round trips, and
+// DuckDB's spellings of the non-finite values.
+func writeCSV(records []Record, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	w.WriteString("id,value,value2,category,ts\n")
+	for _, r := range records {
+		w.WriteString(strconv.Itoa(r.ID))
+		w.WriteByte(',')
+		if !r.Null {
+			writeFloat(w, r.Value)
+		}
+		w.WriteByte(',')
+		writeFloat(w, r.Value2)
+		w.WriteByte(',')
+		if strings.ContainsAny(r.Category, ",\"\r\n") {
+			w.WriteString(`"` + strings.ReplaceAll(r.Category, `"`, `""`) + `"`)
+		} else {
+			w.WriteString(r.Category)
+		}
+		w.WriteByte(',')
+		if !r.Time.IsZero() {
+			w.WriteString(r.Time.UTC().Format(datasetTime))
+		}
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeFloat(w *bufio.Writer, v float64) {
+	switch {
+	case math.IsNaN(v):
+		w.WriteString("nan")
+	case math.IsInf(v, 1):
+		w.WriteString("inf")
+	case math.IsInf(v, -1):
+		w.WriteString("-inf")
+	default:
+		w.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	}
+}
+
+// InsertDataset bulk loads the records saved by SaveDataset at path into the records table of db, which is far
+// faster than inserting them one by one for a large dataset.
+func InsertDataset(ctx context.Context, db Execer, path string) error {
+	source, err := datasetSource(path)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO records (value, value2, category, ts) "+datasetSelect+source+" ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", path, err)
+	}
+	return nil
+}
+
+// CSVInsert inserts records by writing them to a temporary CSV file and loading that with read_csv_auto, so its
+// timing covers the whole pipeline through the file.
+func CSVInsert(ctx context.Context, records []Record, db *sql.DB) error {
+	return csvInsert(ctx, records, db)
+}
+
+// csvInsert is CSVInsert into a database or a transaction.
+func csvInsert(ctx context.Context, records []Record, db Execer) error {
+	f, err := os.CreateTemp("", "duckbench-*.csv")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	if err := writeCSV(records, f.Name()); err != nil {
+		return err
+	}
+	// a column of integers would otherwise be sniffed as BIGINT
+	_, err = db.ExecContext(ctx, fmt.Sprintf("INSERT INTO records (value, value2, category, ts) "+datasetSelect+"read_csv_auto(%s, types = {'value': 'DOUBLE', 'value2': 'DOUBLE', 'category': 'VARCHAR', 'ts': 'TIMESTAMP'}) ORDER BY id",
+		quote(f.Name())))
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", f.Name(), err)
+	}
+	reportProgress(ctx, int64(len(records)))
+	return nil
+}
+
+// ExportParquet writes the records table of db to a Parquet file at path, compressed with compression (e.g.
+// snappy, zstd or uncompressed).
+func ExportParquet(ctx context.Context, db Execer, path, compression string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("COPY (SELECT * FROM records ORDER BY id) TO %s (FORMAT parquet, COMPRESSION %s)",
+		quote(path), quote(compression)))
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// datasetSource returns the table function reading the dataset at path.
+func datasetSource(path string) (string, error) {
+	format, err := datasetFormat(path)
+	if err != nil {
+		return "", err
+	}
+	if format == "csv" {
+		return fmt.Sprintf("read_csv(%s, header = true, columns = %s)", quote(path), datasetColumns), nil
+	}
+	return fmt.Sprintf("read_parquet(%s)", quote(path)), nil
+}
+
+// LoadDataset reads the records saved by SaveDataset from path.
+func LoadDataset(ctx context.Context, path string) ([]Record, error) {
+	source, err := datasetSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := CreateDB(ctx, DBOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.QueryContext(ctx, "SELECT id, value, value2, COALESCE(category, ''), ts FROM "+source+" ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var r Record
+		var value nullable.Nullable[float64]
+		var ts nullable.Nullable[time.Time]
+		if err := rows.Scan(&r.ID, &value, &r.Value2, &r.Category, &ts); err != nil {
+			return nil, err
+		}
+		r.Value, r.Null, r.Time = value.Or(math.NaN()), !value.Valid, ts.V
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
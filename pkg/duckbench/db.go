@@ -0,0 +1,98 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// DBOptions configures the database opened by CreateDB.
+type DBOptions struct {
+	// Path is the database file to open, or empty for an in-memory database.
+	Path string
+	// OnConnect statements run on every new connection in the pool. Settings applied with db.Exec only affect
+	// whichever pooled connection happened to run them, so LOAD, SET and PRAGMA statements belong here.
+	OnConnect []string
+	Hooks     []sqlwrap.Hook
+	// ScalarFuncs are Go functions registered with the database under their names, callable from SQL on every
+	// connection.
+	ScalarFuncs map[string]duckdb.ScalarFunc
+	// TableFuncs are table functions registered likewise, such as RecordsFunc.
+	TableFuncs map[string]duckdb.ParallelRowTableFunction
+	// Pool configures the connection pool; the zero value keeps the database/sql defaults.
+	Pool Pool
+}
+
+func CreateDB(ctx context.Context, opts DBOptions) (*sql.DB, error) {
+	connector, err := duckdb.NewConnector(opts.Path, onConnect(opts.OnConnect))
+	if err != nil {
+		return nil, fmt.Errorf("opening %q: %w", opts.Path, err)
+	}
+	db := sql.OpenDB(sqlwrap.Wrap(connector, opts.Hooks...))
+	opts.Pool.apply(db)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connecting: %w", err)
+	}
+	if err := registerFuncs(ctx, connector, opts); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func onConnect(stmts []string) func(driver.ExecerContext) error {
+	if len(stmts) == 0 {
+		return nil
+	}
+	return func(execer driver.ExecerContext) error {
+		for _, stmt := range stmts {
+			if _, err := execer.ExecContext(context.Background(), stmt, nil); err != nil {
+				return fmt.Errorf("running %q on connect: %w", stmt, err)
+			}
+		}
+		return nil
+	}
+}
+
+// InstallExtension returns the DBOptions.OnConnect statements loading the named extension, installing it first if
+// need be, which downloads it the first time. The DuckDB bundled with go-duckdb v1.8.3 includes only parquet and
+// json.
+func InstallExtension(name string) []string {
+	return []string{"INSTALL " + name, "LOAD " + name}
+}
+
+// BootStatements turns extension names and name=value settings into statements for DBOptions.OnConnect, after
+// any raw statements.
+func BootStatements(stmts, extensions, settings []string) ([]string, error) {
+	boot := slices.Clone(stmts)
+	for _, ext := range extensions {
+		boot = append(boot, "LOAD "+ext)
+	}
+	for _, setting := range settings {
+		name, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return nil, fmt.Errorf("setting %q is not of the form name=value", setting)
+		}
+		boot = append(boot, fmt.Sprintf("SET %s = %s", name, value))
+	}
+	return boot, nil
+}
+
+func CreateRecordsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE SEQUENCE seq_records_id START 1;
+		CREATE TABLE records (id INTEGER DEFAULT nextval('seq_records_id'), value DOUBLE, value2 DOUBLE, category VARCHAR NOT NULL DEFAULT '', ts TIMESTAMP)
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+// Distinct counts the distinct finite values: the keys of a map in Go, and COUNT(DISTINCT value) in DuckDB.
+type Distinct struct{}
+
+// DistinctCount is the result of Distinct.
+type DistinctCount int64
+
+func (d DistinctCount) LogValue() slog.Value { return slog.Int64Value(int64(d)) }
+
+func (Distinct) Name() string { return "distinct" }
+
+func (Distinct) Go(records []Record) (WorkloadResult, error) {
+	seen := make(map[float64]struct{})
+	for _, r := range records {
+		if finite(r.Value) {
+			seen[r.Value] = struct{}{}
+		}
+	}
+	return DistinctCount(len(seen)), nil
+}
+
+type distinctRow struct {
+	N int64 `db:"n"`
+}
+
+func (Distinct) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	row, err := sqlscan.One[distinctRow](ctx, db, "SELECT COUNT(DISTINCT value) AS n FROM records WHERE isfinite(value)")
+	if err != nil {
+		return nil, fmt.Errorf("querying distinct count: %w", err)
+	}
+	return DistinctCount(row.N), nil
+}
+
+// Compare compares the counts exactly.
+func (d DistinctCount) Compare(other WorkloadResult, _ floatcmp.Tolerance) []Mismatch {
+	o, ok := other.(DistinctCount)
+	if !ok {
+		return []Mismatch{{Stat: "type"}}
+	}
+	if d != o {
+		return []Mismatch{{Stat: "distinct", A: float64(d), B: float64(o)}}
+	}
+	return nil
+}
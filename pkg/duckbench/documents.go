@@ -0,0 +1,198 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// JSONExtension are the DBOptions.OnConnect statements loading the json extension, for the JSON type and
+// functions. It is bundled with go-duckdb v1.8.3, so needs no INSTALL.
+var JSONExtension = []string{"LOAD json"}
+
+// Document is the JSON document stored for a record in the documents table, with its values nested a level down
+// as the payload of an event might be.
+type Document struct {
+	ID       int             `json:"id"`
+	Category string          `json:"category"`
+	Reading  DocumentReading `json:"reading"`
+}
+
+type DocumentReading struct {
+	Value  float64 `json:"value"`
+	Value2 float64 `json:"value2"`
+}
+
+func NewDocument(r Record) Document {
+	return Document{ID: r.ID, Category: r.Category, Reading: DocumentReading{Value: r.Value, Value2: r.Value2}}
+}
+
+// DocumentFormat is the Go type documents are marshalled from and unmarshalled into.
+type DocumentFormat string
+
+const (
+	// DocumentStruct is a Document.
+	DocumentStruct DocumentFormat = "struct"
+	// DocumentMap is a map[string]any, as JSON of no fixed shape is handled.
+	DocumentMap DocumentFormat = "map"
+)
+
+// DocumentFormats lists the supported document formats.
+var DocumentFormats = []DocumentFormat{DocumentStruct, DocumentMap}
+
+func (f *DocumentFormat) UnmarshalText(text []byte) error {
+	for _, format := range DocumentFormats {
+		if string(text) == string(format) {
+			*f = format
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown document format %q, expected one of %v", text, DocumentFormats)
+}
+
+// marshal encodes the document of r.
+func (f DocumentFormat) marshal(r Record) ([]byte, error) {
+	if f == DocumentMap {
+		return json.Marshal(map[string]any{
+			"id":       r.ID,
+			"category": r.Category,
+			"reading":  map[string]any{"value": r.Value, "value2": r.Value2},
+		})
+	}
+	return json.Marshal(NewDocument(r))
+}
+
+var errDocumentShape = errors.New("document has no string category or numeric reading.value")
+
+// decode returns the category and value of a document.
+func (f DocumentFormat) decode(data []byte) (string, float64, error) {
+	if f == DocumentMap {
+		var doc map[string]any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return "", 0, err
+		}
+		category, ok := doc["category"].(string)
+		reading, _ := doc["reading"].(map[string]any)
+		value, vok := reading["value"].(float64)
+		if !ok || !vok {
+			return "", 0, errDocumentShape
+		}
+		return category, value, nil
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", 0, err
+	}
+	return doc.Category, doc.Reading.Value, nil
+}
+
+// CreateDocumentsTable replaces the documents table with an empty one of a single JSON column, which needs
+// JSONExtension.
+func CreateDocumentsTable(ctx context.Context, db Execer) error {
+	_, err := db.ExecContext(ctx, "CREATE OR REPLACE TABLE documents (doc JSON)")
+	return err
+}
+
+// InsertDocuments marshals the document of each of records in turn as format, as a stream of events would arrive,
+// and appends it to the documents table. JSON has no numbers for NaN or the infinities, so every value must be
+// finite.
+func InsertDocuments(ctx context.Context, db *sql.DB, records []Record, format DocumentFormat) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("opening connection: %w", err)
+	}
+	defer conn.Close()
+	return conn.Raw(func(dc any) error {
+		// a JSON column is a VARCHAR to the Appender
+		appender, err := duckdb.NewAppenderFromConn(sqlwrap.Unwrap(dc).(driver.Conn), "", "documents")
+		if err != nil {
+			return fmt.Errorf("creating appender: %w", err)
+		}
+		for i, r := range records {
+			doc, err := format.marshal(r)
+			if err == nil {
+				err = appender.AppendRow(string(doc))
+			}
+			if err != nil {
+				appender.Close()
+				return fmt.Errorf("appending document %d: %w", i, err)
+			}
+			if (i+1)%appenderFlushRows == 0 {
+				if err := errors.Join(ctx.Err(), appender.Flush()); err != nil {
+					appender.Close()
+					return fmt.Errorf("flushing appender: %w", err)
+				}
+			}
+		}
+		if err := appender.Close(); err != nil {
+			return fmt.Errorf("closing appender: %w", err)
+		}
+		return nil
+	})
+}
+
+// DocumentGroups reads every document back and unmarshals it as format, accumulating the statistics of each
+// category as GroupedStatistics does in Go.
+func DocumentGroups(ctx context.Context, db Queryer, format DocumentFormat) (Groups, error) {
+	rows, err := db.QueryContext(ctx, "SELECT doc::VARCHAR FROM documents")
+	if err != nil {
+		return nil, fmt.Errorf("querying documents: %w", err)
+	}
+	defer rows.Close()
+	acc := make(groupAccumulator)
+	var doc sql.RawBytes
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(&doc); err != nil {
+			return nil, fmt.Errorf("scanning document %d: %w", i, err)
+		}
+		category, value, err := format.decode(doc)
+		if err != nil {
+			return nil, fmt.Errorf("decoding document %d: %w", i, err)
+		}
+		acc.add(category, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading documents: %w", err)
+	}
+	return acc.groups()
+}
+
+// DocumentGroupsFromDB calculates the statistics of each category of the documents table with json_extract in
+// DuckDB, as GroupedStatistics does from the records table.
+func DocumentGroupsFromDB(ctx context.Context, db Queryer) (Groups, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT category, COUNT(*), AVG(value), STDDEV_POP(value)
+		FROM (
+			SELECT json_extract_string(doc, '$.category') AS category, json_extract(doc, '$.reading.value')::DOUBLE AS value
+			FROM documents
+		)
+		WHERE isfinite(value)
+		GROUP BY category`)
+	if err != nil {
+		return nil, fmt.Errorf("querying document statistics: %w", err)
+	}
+	defer rows.Close()
+	groups := make(Groups)
+	for rows.Next() {
+		var category string
+		var g GroupStats
+		if err := rows.Scan(&category, &g.Count, &g.Mean, &g.StdDev); err != nil {
+			return nil, fmt.Errorf("scanning document statistics: %w", err)
+		}
+		groups[category] = g
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading document statistics: %w", err)
+	}
+	if len(groups) == 0 {
+		return nil, ErrNoValues
+	}
+	return groups, nil
+}
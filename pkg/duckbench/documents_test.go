@@ -0,0 +1,70 @@
+package duckbench
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestDocumentFormats(t *testing.T) {
+	records, _ := GenerateDistribution(100, DistNormal, 1)
+	AssignCategories(records, 5, 1)
+	for _, from := range DocumentFormats {
+		for _, into := range DocumentFormats {
+			for _, r := range records {
+				doc, err := from.marshal(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				category, value, err := into.decode(doc)
+				if err != nil || category != r.Category || value != r.Value {
+					t.Fatalf("%s into %s: decoded %q, %v, %v from %s, want %q, %v", from, into, category, value, err, doc, r.Category, r.Value)
+				}
+			}
+		}
+	}
+	if _, _, err := DocumentMap.decode([]byte(`{"category": "g0", "reading": {"value": "1"}}`)); !errors.Is(err, errDocumentShape) {
+		t.Errorf("decoding a string value: %v, want %v", err, errDocumentShape)
+	}
+}
+
+// TestDocuments checks that both engines aggregate the documents as the records they were made from. It needs the
+// json extension, which is downloaded the first time.
+func TestDocuments(t *testing.T) {
+	ctx := context.Background()
+	db, err := CreateDB(ctx, DBOptions{OnConnect: JSONExtension})
+	if err != nil {
+		t.Skipf("json extension unavailable: %v", err)
+	}
+	defer db.Close()
+	records, _ := GenerateDistribution(5000, DistNormal, 1)
+	AssignCategories(records, 10, 1)
+	want, err := GroupedStatistics{}.Go(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tol := floatcmp.Tolerance{Rel: 1e-9, Abs: 1e-12}
+	for _, format := range DocumentFormats {
+		if err := CreateDocumentsTable(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+		if err := InsertDocuments(ctx, db, records, format); err != nil {
+			t.Fatal(err)
+		}
+		goGroups, err := DocumentGroups(ctx, db, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dbGroups, err := DocumentGroupsFromDB(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, got := range map[string]Groups{"go": goGroups, "duckdb": dbGroups} {
+			if m := want.Compare(got, tol); len(m) > 0 {
+				t.Errorf("%s from %s documents: %v", name, format, m)
+			}
+		}
+	}
+}
@@ -0,0 +1,472 @@
+// Package duckbench is the benchmark harness behind cmd/duckbench, exposed so the same DuckDB workloads can be
+// run programmatically, e.g. from another project's tests.
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/metrics"
+	"github.com/rpep/duckdb-go-experiments/pkg/schema"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// DefaultN is the number of records generated when Config.N is zero.
+const DefaultN = 1000000
+
+type Record struct {
+	ID    int
+	Value float64
+	// Value2 is a second value correlated with Value, for the workloads over more than one column.
+	Value2 float64
+	// Category is the group of the record for GroupedStatistics, see AssignCategories.
+	Category string
+	// Time is the timestamp of a DistTimeSeries record, stored to the microsecond, or zero, which is stored as
+	// NULL.
+	Time time.Time
+	// Null marks a record whose value is NULL, see InjectNulls. Its Value is NaN, so that everything in Go which
+	// skips non-finite values skips it too, as SQL's aggregates skip NULLs.
+	Null bool
+}
+
+// value is r.Value as inserted into the value column.
+func (r Record) value() any {
+	if r.Null {
+		return nil
+	}
+	return r.Value
+}
+
+// timestamp is r.Time as inserted into the ts column.
+func (r Record) timestamp() any {
+	if r.Time.IsZero() {
+		return nil
+	}
+	return r.Time
+}
+
+// Phase names a timed section of a benchmark run.
+type Phase string
+
+const (
+	PhaseGenerate Phase = "generate"
+	PhaseInsert   Phase = "insert"
+	PhaseStats    Phase = "stats"
+	PhaseQuery    Phase = "query"
+)
+
+// Phases lists the phases of a run in the order they are run.
+var Phases = []Phase{PhaseGenerate, PhaseInsert, PhaseStats, PhaseQuery}
+
+// A PhaseHook is called at the start of each phase, and may return a derived context for the phase. The returned
+// function is called when the phase ends.
+type PhaseHook func(ctx context.Context, phase Phase) (context.Context, func())
+
+// Config configures a benchmark run.
+type Config struct {
+	N int
+	// Distribution and Seed choose the values of the N generated records, DistSequential if unset.
+	Distribution Distribution
+	Seed         uint64
+	// Groups, if set, is how many categories the generated records are assigned to.
+	Groups int
+	// NullRatio is the fraction of the generated records made NULL, see InjectNulls.
+	NullRatio float64
+	// Records, if set, are replayed instead of generating N records, e.g. from LoadDataset.
+	Records []Record
+	// SaveDataset, if set, is a .csv or .parquet path to save the generated records to for later replay.
+	SaveDataset string
+	// ChunkSize, if set, streams the N records through a RecordStream this many at a time instead of generating
+	// them all up front, so N is not limited by memory. The Go engine then accumulates the statistics with
+	// Welford, single-threaded, which finds no median or percentiles, and DuckDB leaves them out likewise. Records,
+	// SaveDataset, Engines and Workloads need every record in memory, so cannot be combined with it.
+	ChunkSize int
+	// Inserts are the insert strategies to time, each into a fresh records table, the last of which the
+	// statistics are calculated from. Defaults to InsertStandard.
+	Inserts    []InsertStrategy
+	DB         DBOptions
+	PhaseHooks []PhaseHook
+	// SampleInterval enables sampling Go runtime and DuckDB resource usage throughout the run, into
+	// Results.Samples, the usage by phase derived from them and the gauges of Metrics if it is set.
+	SampleInterval time.Duration
+	// Metrics, if set, also receives the duration of each phase.
+	Metrics *metrics.Registry
+	// ProfileQueries captures DuckDB's operator-level profile of the statistics query into Results.QueryProfile.
+	ProfileQueries bool
+	// ExplainAnalyze runs the statistics query once more under EXPLAIN ANALYZE after it has been timed, into
+	// Results.Explain.
+	ExplainAnalyze bool
+	// LogPlans logs the EXPLAIN output of each benchmark query at debug level before it is run.
+	LogPlans bool
+	// SkipVerify skips reading the records table back after ingestion to check it holds exactly what was inserted.
+	SkipVerify bool
+	// Summation is the algorithm the Go engine sums with, SumPairwise if unset.
+	Summation Summation
+	// Percentiles are calculated by both engines as well, DefaultPercentiles if unset.
+	Percentiles []float64
+	// Threads, if set, is both the number of goroutines the Go engine calculates statistics with and DuckDB's
+	// threads setting, so both engines have the same parallelism. Otherwise the Go engine is single-threaded and
+	// DuckDB uses a thread per CPU.
+	Threads int
+	// Progress, if set, is kept up to date with the current phase and the rows it has processed.
+	Progress *Progress
+	// Engines are other implementations of the statistics, such as libraries to cross-check the Go engine with,
+	// timed after it into Results.Engines.
+	Engines []StatisticsEngine
+	// Workloads are other analyses to time in both Go and DuckDB after the statistics, into Results.Workloads.
+	Workloads []Workload
+	// Repeat is how many times each insert strategy and engine is timed, once if unset, after Warmup untimed runs.
+	// Their durations are then the median of the repeats.
+	Repeat, Warmup int
+}
+
+// Conns returns the most connections Run holds at once, which a Pool's MaxOpenConns must allow: one, or a
+// ConcurrentInsert's workers. The sampler and profiling borrow theirs from the pool between statements.
+func (cfg Config) Conns() int {
+	conns := 1
+	for _, s := range cfg.Inserts {
+		if c, ok := s.(ConcurrentInsert); ok {
+			conns = max(conns, c.Workers)
+		}
+	}
+	return conns
+}
+
+func (cfg *Config) startPhase(ctx context.Context, phase Phase) (context.Context, func()) {
+	ends := make([]func(), len(cfg.PhaseHooks))
+	for i, hook := range cfg.PhaseHooks {
+		ctx, ends[i] = hook(ctx, phase)
+	}
+	return ctx, func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i]()
+		}
+	}
+}
+
+// StatisticsEngine calculates the statistics of records in memory, like the Go engine, including the percentiles
+// requested.
+type StatisticsEngine struct {
+	Name       string
+	Statistics func(records []Record, percentiles ...float64) (Stats, error)
+}
+
+// BenchmarkResult is the timing of one way of doing one step of a run, such as an insert strategy.
+type BenchmarkResult struct {
+	Name string
+	Rows int
+	// Duration is the median of Samples if the step was repeated.
+	Duration time.Duration
+	Samples  []time.Duration
+	// Stats are the statistics the step calculated, if it calculated any.
+	Stats *Stats
+}
+
+func (r BenchmarkResult) RowsPerSecond() float64 {
+	return float64(r.Rows) / r.Duration.Seconds()
+}
+
+// Measure times a single call of fn processing rows records. It is the timing shared by Run and the commands;
+// pkg/bench runs the same steps under testing.B, for allocation counts and repeated samples.
+func Measure(name string, rows int, fn func() error) (BenchmarkResult, error) {
+	start := time.Now()
+	err := fn()
+	return BenchmarkResult{Name: name, Rows: rows, Duration: time.Since(start)}, err
+}
+
+// Results holds the timings and statistics of a benchmark run.
+type Results struct {
+	Started        time.Time
+	N              int
+	InsertDuration time.Duration
+	// Inserts are the timings of each of Config.Inserts, the last of which is InsertDuration.
+	Inserts []BenchmarkResult
+	// CheckpointDuration is how long writing the inserted records from the WAL into an on-disk database took.
+	CheckpointDuration time.Duration
+	GoStats            Stats
+	GoDuration         time.Duration
+	DBStats            Stats
+	DBDuration         time.Duration
+	// GoSamples and DBSamples are the durations of each repeat, of which GoDuration and DBDuration are the median.
+	GoSamples, DBSamples []time.Duration
+	// Engines are the timings and statistics of each of Config.Engines.
+	Engines []BenchmarkResult
+	// Workloads are the timings and results of each of Config.Workloads.
+	Workloads  []WorkloadRun
+	Samples    []ResourceSample
+	PeakMemory map[Phase]MemoryPeak
+	Process    map[Phase]ProcessUsage
+	GC         map[Phase]GCStats
+	// Memory is the memory used in each phase, notably PhaseStats for the Go engine and PhaseQuery for DuckDB, if
+	// Config.SampleInterval is set.
+	Memory       map[Phase]MemoryUsage
+	QueryProfile *QueryProfile
+	Explain      *ExplainAnalysis
+	// Tables are the tables of the database at the end of the run, as schema.Tables lists them.
+	Tables []schema.Table
+}
+
+// GenerateRecords returns n records with values 0..n-1, paired as GenerateDistribution pairs them.
+func GenerateRecords(n int) []Record {
+	records := make([]Record, n)
+	for i := 0; i < n; i++ {
+		records[i] = Record{
+			ID:    i,
+			Value: float64(i),
+		}
+	}
+	pair(records, rand.New(rand.NewPCG(0, 0)))
+	return records
+}
+
+// generate returns n records of cfg.Distribution, assigned to cfg.Groups categories, cfg.NullRatio of them NULL.
+func (cfg *Config) generate(n int) ([]Record, error) {
+	records, err := GenerateDistribution(n, cfg.Distribution, cfg.Seed)
+	if err != nil {
+		return nil, err
+	}
+	AssignCategories(records, cfg.Groups, cfg.Seed)
+	if cfg.NullRatio > 0 {
+		if err := InjectNulls(records, cfg.NullRatio, cfg.Seed); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Run inserts generated records into a fresh DuckDB database and times calculating their statistics both in Go and
+// in DuckDB.
+func Run(ctx context.Context, cfg Config) (res Results, err error) {
+	if cfg.Records != nil {
+		cfg.N = len(cfg.Records)
+	} else if cfg.N == 0 {
+		cfg.N = DefaultN
+	}
+	if len(cfg.Inserts) == 0 {
+		cfg.Inserts = []InsertStrategy{InsertStandard}
+	}
+	if cfg.Percentiles == nil {
+		cfg.Percentiles = DefaultPercentiles
+	}
+	cfg.Repeat = max(cfg.Repeat, 1)
+	if cfg.Threads > 0 {
+		cfg.DB.OnConnect = append(slices.Clip(cfg.DB.OnConnect), fmt.Sprintf("SET threads = %d", cfg.Threads))
+	}
+	res = Results{Started: time.Now(), N: cfg.N}
+
+	db, err := CreateDB(ctx, cfg.DB)
+	if err != nil {
+		return res, fmt.Errorf("creating DuckDB database: %w", err)
+	}
+	defer db.Close()
+
+	if r := cfg.Metrics; r != nil {
+		cfg.PhaseHooks = append(cfg.PhaseHooks, func(ctx context.Context, phase Phase) (context.Context, func()) {
+			start := time.Now()
+			return ctx, func() {
+				r.Gauge("duckbench_phase_duration_seconds", "Duration of each phase of the last run.",
+					metrics.Labels{"phase": string(phase)}).Set(time.Since(start).Seconds())
+			}
+		})
+	}
+
+	if cfg.Progress != nil {
+		cfg.PhaseHooks = append(cfg.PhaseHooks, cfg.Progress.hook(int64(cfg.N*len(cfg.Inserts)*cfg.iterations())))
+	}
+
+	gc := newGCTracker()
+	cfg.PhaseHooks = append([]PhaseHook{gc.hook}, cfg.PhaseHooks...)
+	res.GC = gc.stats
+
+	if cfg.SampleInterval > 0 {
+		s := startSampler(ctx, db, cfg.SampleInterval, cfg.Metrics)
+		defer func() {
+			res.Samples = s.stop()
+			res.PeakMemory = PeakMemory(res.Samples)
+			res.Process = ProcessUsageByPhase(res.Samples)
+			res.Memory = MemoryUsageByPhase(res.Samples)
+		}()
+		cfg.PhaseHooks = append([]PhaseHook{s.hook}, cfg.PhaseHooks...)
+	}
+
+	if err := CreateRecordsTable(sqlwrap.Setup(ctx), db); err != nil {
+		return res, fmt.Errorf("creating records table: %w", err)
+	}
+	if cfg.ChunkSize > 0 {
+		if cfg.Records != nil || cfg.SaveDataset != "" || len(cfg.Engines) > 0 || len(cfg.Workloads) > 0 {
+			return res, errStreamed
+		}
+		if err := cfg.runStream(ctx, db, &res); err != nil {
+			return res, err
+		}
+		return res, listTables(ctx, db, &res)
+	}
+
+	_, end := cfg.startPhase(ctx, PhaseGenerate)
+	records := cfg.Records
+	if records == nil {
+		records, err = cfg.generate(cfg.N)
+	}
+	end()
+	if err != nil {
+		return res, fmt.Errorf("generating records: %w", err)
+	}
+	if cfg.SaveDataset != "" {
+		if err := SaveDataset(ctx, records, cfg.SaveDataset); err != nil {
+			return res, fmt.Errorf("saving dataset: %w", err)
+		}
+	}
+
+	phaseCtx, end := cfg.startPhase(ctx, PhaseInsert)
+	err = cfg.insert(phaseCtx, db, records, &res)
+	end()
+	if err != nil {
+		return res, err
+	}
+
+	_, end = cfg.startPhase(ctx, PhaseStats)
+	goRun, err := cfg.measure(ctx, "go", len(records), nil, func() (err error) {
+		if cfg.Threads > 0 {
+			res.GoStats, err = ParallelStatistics(records, cfg.Summation, cfg.Threads, cfg.Percentiles...)
+		} else {
+			res.GoStats, err = StatisticsWithSummation(records, cfg.Summation, cfg.Percentiles...)
+		}
+		return err
+	})
+	res.GoDuration, res.GoSamples = goRun.Duration, goRun.Samples
+	end()
+	if err != nil {
+		return res, fmt.Errorf("calculating statistics in Go: %w", err)
+	}
+	// outside the stats phase, so its resource usage is only the Go engine's
+	for _, e := range cfg.Engines {
+		var stats Stats
+		run, err := cfg.measure(ctx, e.Name, len(records), nil, func() (err error) {
+			stats, err = e.Statistics(records, cfg.Percentiles...)
+			return err
+		})
+		if err != nil {
+			return res, fmt.Errorf("calculating statistics with %s: %w", e.Name, err)
+		}
+		run.Stats = &stats
+		res.Engines = append(res.Engines, run)
+	}
+
+	statistics := func(ctx context.Context, q Queryer) (Stats, error) {
+		return StatisticsFromDB(ctx, q, cfg.Percentiles...)
+	}
+	if err := cfg.query(ctx, db, len(records), statisticsQuery(cfg.Percentiles), statistics, &res); err != nil {
+		return res, err
+	}
+	for _, w := range cfg.Workloads {
+		run, err := cfg.workload(ctx, db, records, w)
+		if err != nil {
+			return res, fmt.Errorf("running %s: %w", w.Name(), err)
+		}
+		res.Workloads = append(res.Workloads, run)
+	}
+
+	return res, listTables(ctx, db, &res)
+}
+
+// listTables records the tables the run leaves behind in res.Tables.
+func listTables(ctx context.Context, db *sql.DB, res *Results) error {
+	tables, err := schema.Tables(sqlwrap.Setup(ctx), db)
+	if err != nil {
+		return fmt.Errorf("listing tables: %w", err)
+	}
+	res.Tables = tables
+	return nil
+}
+
+// query times calculating the statistics of the rows in the records table in DuckDB with statistics, which runs
+// query, in the query phase.
+func (cfg *Config) query(ctx context.Context, db *sql.DB, rows int, query string, statistics func(context.Context, Queryer) (Stats, error), res *Results) error {
+	if cfg.LogPlans {
+		LogPlans(ctx, db, query)
+	}
+	phaseCtx, end := cfg.startPhase(ctx, PhaseQuery)
+	run := func(ctx context.Context, q Queryer) error {
+		dbRun, err := cfg.measure(ctx, "duckdb", rows, nil, func() (err error) {
+			res.DBStats, err = statistics(ctx, q)
+			return err
+		})
+		res.DBDuration, res.DBSamples = dbRun.Duration, dbRun.Samples
+		return err
+	}
+	var err error
+	if cfg.ProfileQueries {
+		res.QueryProfile, err = ProfileQuery(phaseCtx, db, run)
+	} else {
+		err = run(phaseCtx, db)
+	}
+	end()
+	if err != nil {
+		return fmt.Errorf("calculating statistics in DuckDB: %w", err)
+	}
+	if cfg.ExplainAnalyze {
+		if res.Explain, err = ExplainAnalyze(ctx, db, query); err != nil {
+			return fmt.Errorf("explaining statistics query: %w", err)
+		}
+	}
+	return nil
+}
+
+// insert times inserting records with each of cfg.Inserts in turn, each into a fresh table, verifying each.
+func (cfg *Config) insert(ctx context.Context, db *sql.DB, records []Record, res *Results) error {
+	reset := freshTable(ctx, db)
+	for _, strategy := range cfg.Inserts {
+		run, err := cfg.measure(ctx, strategy.Name(), len(records), reset, func() error {
+			return strategy.Insert(ctx, records, db)
+		})
+		res.InsertDuration = run.Duration
+		res.Inserts = append(res.Inserts, run)
+		if err != nil {
+			return fmt.Errorf("inserting records with %s: %w", strategy.Name(), err)
+		}
+		if !cfg.SkipVerify {
+			if err := VerifyIngestion(sqlwrap.Setup(ctx), db, records); err != nil {
+				return fmt.Errorf("verifying records inserted with %s: %w", strategy.Name(), err)
+			}
+		}
+	}
+	return cfg.checkpoint(ctx, db, len(records), res)
+}
+
+// freshTable returns a setup function recreating the records table empty before each insert but the first, which
+// goes into the table Run created.
+func freshTable(ctx context.Context, db *sql.DB) func() error {
+	fresh := true
+	return func() error {
+		if fresh {
+			fresh = false
+			return nil
+		}
+		if _, err := db.ExecContext(sqlwrap.Setup(ctx), "DROP TABLE records; DROP SEQUENCE seq_records_id"); err != nil {
+			return fmt.Errorf("dropping records table: %w", err)
+		}
+		if err := CreateRecordsTable(sqlwrap.Setup(ctx), db); err != nil {
+			return fmt.Errorf("creating records table: %w", err)
+		}
+		return nil
+	}
+}
+
+// checkpoint times writing the inserted rows out of the WAL of an on-disk database.
+func (cfg *Config) checkpoint(ctx context.Context, db *sql.DB, rows int, res *Results) error {
+	if cfg.DB.Path != "" {
+		run, err := Measure("checkpoint", rows, func() error {
+			_, err := db.ExecContext(ctx, "CHECKPOINT")
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("checkpointing: %w", err)
+		}
+		res.CheckpointDuration = run.Duration
+	}
+	return nil
+}
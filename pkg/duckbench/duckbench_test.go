@@ -0,0 +1,434 @@
+package duckbench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+func TestRunEmpty(t *testing.T) {
+	_, err := Run(context.Background(), Config{Records: []Record{}})
+	if !errors.Is(err, ErrNoValues) {
+		t.Fatalf("err = %v, want ErrNoValues", err)
+	}
+}
+
+func TestRunSingleRow(t *testing.T) {
+	res, err := Run(context.Background(), Config{Records: recordsOf(42)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Stats{Mean: 42, Median: 42, StdDev: 0, Min: 42, Max: 42}
+	if res.N != 1 {
+		t.Errorf("N = %d, want 1", res.N)
+	}
+	assertStats(t, "go", res.GoStats, want)
+	assertStats(t, "duckdb", res.DBStats, want)
+	if len(res.Tables) != 1 || res.Tables[0].Name != "records" || len(res.Tables[0].Columns) == 0 {
+		t.Errorf("tables = %+v, want the records table and its columns", res.Tables)
+	}
+}
+
+func TestVerifyIngestion(t *testing.T) {
+	ctx := context.Background()
+	records := recordsOf(1, 2, 2, math.NaN(), math.Copysign(0, -1))
+	db := loadDB(t, records)
+	if err := VerifyIngestion(ctx, db, records); err != nil {
+		t.Fatalf("unchanged table: %v", err)
+	}
+
+	for _, stmt := range []string{
+		"DELETE FROM records WHERE id = 1",
+		"UPDATE records SET value = 3 WHERE id = 2",
+		"UPDATE records SET value = 0 WHERE value = 0",
+		"INSERT INTO records (value, value2) VALUES (2, 0), (2, 0)",
+	} {
+		t.Run(stmt, func(t *testing.T) {
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyIngestion(ctx, tx, records); !errors.Is(err, ErrIntegrity) {
+				t.Errorf("err = %v, want ErrIntegrity", err)
+			}
+		})
+	}
+}
+
+func TestRunInserts(t *testing.T) {
+	records := recordsOf(1, 2, 2, math.NaN(), math.Inf(-1), math.Copysign(0, -1))
+	// Run verifies the table each method inserts
+	strategies := []InsertStrategy{InsertStandard, InsertAppender, ValuesInsert{BatchSize: 4}, InsertValues, InsertCSV, InsertArrow, CommitInsert{CommitEvery: 4}}
+	res, err := Run(context.Background(), Config{Records: records, Inserts: strategies})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Inserts) != len(strategies) {
+		t.Fatalf("Inserts = %+v, want a timing for each strategy", res.Inserts)
+	}
+	for i, s := range strategies {
+		if res.Inserts[i].Name != s.Name() {
+			t.Errorf("Inserts[%d] is %s, want %s", i, res.Inserts[i].Name, s.Name())
+		}
+	}
+	assertStats(t, "duckdb", res.DBStats, res.GoStats)
+}
+
+// TestRunAudit checks that the audit log has every statement of a run, with those recreating and verifying the
+// table between inserts marked as setup.
+func TestRunAudit(t *testing.T) {
+	var buf bytes.Buffer
+	audit := sqlwrap.NewAudit(&buf)
+	cfg := Config{Records: recordsOf(1, 2, 3), Inserts: []InsertStrategy{InsertStandard, InsertValues}, DB: DBOptions{Hooks: []sqlwrap.Hook{audit}}}
+	if _, err := Run(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	var inserts, setups, drops, checksums int
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e sqlwrap.AuditEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case strings.HasPrefix(e.Query, "INSERT"):
+			inserts++
+			if e.Setup {
+				t.Errorf("insert %q marked as setup", e.Query)
+			}
+		case e.Setup:
+			setups++
+			if strings.HasPrefix(e.Query, "DROP TABLE records") {
+				drops++
+			}
+			if strings.HasPrefix(e.Query, "SELECT value, value2, category, ts FROM records") {
+				checksums++
+			}
+		}
+	}
+	if inserts == 0 || drops != 1 || checksums != 2 || setups < drops+checksums {
+		t.Errorf("%d inserts, %d setup statements of which %d drops and %d checksums, want the table dropped once and checked after each insert",
+			inserts, setups, drops, checksums)
+	}
+}
+
+// TestInsertTx checks that each method which can insert into a caller's transaction leaves nothing behind when it
+// is rolled back, and everything once it is committed.
+func TestInsertTx(t *testing.T) {
+	ctx := context.Background()
+	records := GenerateRecords(100)
+	for _, method := range InsertMethods {
+		db := loadDB(t, nil)
+		for _, commit := range []bool{false, true} {
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = method.InsertTx(ctx, records, tx)
+			if method == InsertAppender || method == InsertArrow {
+				tx.Rollback()
+				if !errors.Is(err, ErrNoTx) {
+					t.Errorf("%s: %v, want %v", method, err, ErrNoTx)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", method, err)
+			}
+			if commit {
+				err = tx.Commit()
+			} else {
+				err = tx.Rollback()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := records
+			if !commit {
+				want = nil
+			}
+			if err := VerifyIngestion(ctx, db, want); err != nil {
+				t.Errorf("%s, committed %t: %v", method, commit, err)
+			}
+		}
+	}
+}
+
+func TestAppenderInsert(t *testing.T) {
+	ctx := context.Background()
+	db, err := CreateDB(ctx, DBOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := CreateRecordsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	// more than one flush's worth
+	records := GenerateRecords(appenderFlushRows + 10)
+	if err := AppenderInsert(ctx, records, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyIngestion(ctx, db, records); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateDistribution(t *testing.T) {
+	for _, dist := range Distributions {
+		a, err := GenerateDistribution(100, dist, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := GenerateDistribution(100, dist, 1)
+		c, _ := GenerateDistribution(100, dist, 2)
+		if !slices.Equal(a, b) {
+			t.Errorf("%s: the same seed generated different records", dist)
+		}
+		if dist != DistSequential && slices.Equal(a, c) {
+			t.Errorf("%s: different seeds generated the same records", dist)
+		}
+	}
+	if _, err := GenerateDistribution(100, "pareto", 1); err == nil {
+		t.Error("generating an unknown distribution succeeded")
+	}
+}
+
+func TestInjectNulls(t *testing.T) {
+	records := GenerateRecords(10000)
+	if err := InjectNulls(records, 0.1, 1); err != nil {
+		t.Fatal(err)
+	}
+	var nulls int
+	for _, r := range records {
+		if r.Null {
+			nulls++
+			if !math.IsNaN(r.Value) {
+				t.Fatalf("NULL record %d has value %g", r.ID, r.Value)
+			}
+		}
+	}
+	if nulls < 900 || nulls > 1100 {
+		t.Errorf("%d of %d records are NULL, want about 10%%", nulls, len(records))
+	}
+	if err := InjectNulls(records, 1.5, 1); err == nil {
+		t.Error("injecting NULLs with a ratio above 1 succeeded")
+	}
+}
+
+func TestAssignCategories(t *testing.T) {
+	records := GenerateRecords(1000)
+	AssignCategories(records, 3, 1)
+	seen := map[string]bool{}
+	for _, r := range records {
+		seen[r.Category] = true
+	}
+	if len(seen) != 3 || !seen["g0"] || !seen["g1"] || !seen["g2"] {
+		t.Errorf("assigned categories %v, want g0, g1 and g2", seen)
+	}
+	for _, groups := range []int{0, -1} {
+		records := GenerateRecords(10)
+		AssignCategories(records, groups, 1)
+		for _, r := range records {
+			if r.Category != "" {
+				t.Fatalf("%d groups assigned record %d to %q", groups, r.ID, r.Category)
+			}
+		}
+	}
+}
+
+func TestRunOnDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench.db")
+	// the memory usage comes from the samples taken as each phase starts and ends
+	res, err := Run(context.Background(), Config{N: 1000, DB: DBOptions{Path: path}, SampleInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CheckpointDuration == 0 {
+		t.Error("no checkpoint timing for an on-disk database")
+	}
+	if m := res.Memory[PhaseQuery]; m.DatabaseSize == 0 || m.PeakDuckDBMemory == 0 {
+		t.Errorf("query memory usage = %+v, want the database's size and DuckDB's memory", m)
+	}
+	if m := res.Memory[PhaseStats]; m.TotalAlloc == 0 || m.PeakHeapAlloc < m.HeapAlloc {
+		t.Errorf("stats memory usage = %+v, want the Go engine's allocations", m)
+	}
+	assertStats(t, "duckdb", res.DBStats, res.GoStats)
+}
+
+func TestReportJSON(t *testing.T) {
+	res := Results{
+		N:       2,
+		Inserts: []BenchmarkResult{{Name: "standard", Rows: 2, Duration: time.Second}},
+		GoStats: Stats{Mean: math.Inf(1), Median: 1, Max: math.Inf(1), NonFinite: 1},
+	}
+	b, err := json.Marshal(res.Report())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Inserts []struct {
+			Strategy      string  `json:"strategy"`
+			RowsPerSecond float64 `json:"rows_per_sec"`
+		} `json:"inserts"`
+		Statistics []struct {
+			Strategy string         `json:"strategy"`
+			Stats    map[string]any `json:"stats"`
+		} `json:"statistics"`
+	}
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Inserts) != 1 || report.Inserts[0].Strategy != "standard" || report.Inserts[0].RowsPerSecond != 2 {
+		t.Errorf("inserts = %+v", report.Inserts)
+	}
+	if len(report.Statistics) != 2 || report.Statistics[0].Stats["mean"] != "Infinity" {
+		t.Errorf("statistics = %+v, want go's mean encoded as Infinity", report.Statistics)
+	}
+}
+
+func TestRunRepeat(t *testing.T) {
+	strategies := []InsertStrategy{InsertAppender, InsertValues}
+	res, err := Run(context.Background(), Config{N: 100, Inserts: strategies, Repeat: 3, Warmup: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range res.Inserts {
+		if len(r.Samples) != 3 {
+			t.Errorf("%s: %d samples, want 3", r.Name, len(r.Samples))
+		}
+		if timing := r.Timing(); r.Duration != timing.Median || timing.Min > timing.Median || timing.Median > timing.Max {
+			t.Errorf("%s: duration %v, timing %+v", r.Name, r.Duration, timing)
+		}
+	}
+	if len(res.GoSamples) != 3 || len(res.DBSamples) != 3 {
+		t.Errorf("%d go and %d duckdb samples, want 3", len(res.GoSamples), len(res.DBSamples))
+	}
+}
+
+func TestSummarizeTimings(t *testing.T) {
+	got := SummarizeTimings([]time.Duration{4 * time.Second, time.Second, 3 * time.Second, 2 * time.Second})
+	want := Timing{Median: 2500 * time.Millisecond, StdDev: 1118033988, Min: time.Second, Max: 4 * time.Second}
+	if got != want {
+		t.Errorf("SummarizeTimings() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelOnInsert := func(ctx context.Context, phase Phase) (context.Context, func()) {
+		if phase == PhaseInsert {
+			cancel()
+		}
+		return ctx, func() {}
+	}
+	for _, strategy := range []InsertStrategy{InsertStandard, InsertAppender, InsertValues, InsertCSV, InsertArrow} {
+		_, err := Run(ctx, Config{N: 10000, Inserts: []InsertStrategy{strategy}, PhaseHooks: []PhaseHook{cancelOnInsert}})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: err = %v, want context.Canceled", strategy.Name(), err)
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	settings := SweepSettings([]int{1, 2}, []string{"", "256MB"})
+	if len(settings) != 4 || settings[3] != (Settings{Threads: 2, MemoryLimit: "256MB"}) {
+		t.Fatalf("SweepSettings() = %v", settings)
+	}
+	sweep, err := Sweep(context.Background(), Config{N: 1000, Distribution: DistNormal, Inserts: []InsertStrategy{InsertAppender}}, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sweep) != len(settings) {
+		t.Fatalf("%d results, want %d", len(sweep), len(settings))
+	}
+	for _, s := range sweep {
+		if m := CompareStats(s.Results.DBStats, sweep[0].Results.GoStats, StatTolerance); len(m) > 0 {
+			t.Errorf("%s: duckdb differs from go on the same records: %+v", s.Settings, m)
+		}
+	}
+}
+
+func TestRunExplainAnalyze(t *testing.T) {
+	res, err := Run(context.Background(), Config{N: 1000, Inserts: []InsertStrategy{InsertAppender}, ExplainAnalyze: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := res.Explain
+	if e == nil || !strings.Contains(e.Plan, "UNGROUPED_AGGREGATE") {
+		t.Fatalf("explain = %+v, want the analyzed plan of the statistics query", e)
+	}
+	// DuckDB rounds its total to a tenth of a millisecond
+	if e.WallClock+100*time.Microsecond < e.Total {
+		t.Errorf("wall clock %v is less than DuckDB's total %v", e.WallClock, e.Total)
+	}
+}
+
+func TestRunProfileQueries(t *testing.T) {
+	// explained after profiling on what may be the same connection, which must not be left profiling as JSON
+	res, err := Run(context.Background(), Config{
+		N: 1000, Inserts: []InsertStrategy{InsertAppender}, ProfileQueries: true, ExplainAnalyze: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := res.QueryProfile
+	if p == nil || !strings.Contains(p.Query, "FROM records") || p.Timing <= 0 || len(p.Operators) == 0 {
+		t.Fatalf("profile = %+v, want the profile of the statistics query", p)
+	}
+	var scan *OperatorProfile
+	for i, op := range p.Operators {
+		if op.Name == "TABLE_SCAN" {
+			scan = &p.Operators[i]
+		}
+	}
+	if scan == nil || scan.Cardinality != 1000 || !strings.Contains(scan.ExtraInfo, "records") {
+		t.Errorf("table scan = %+v, want one of the 1000 records", scan)
+	}
+}
+
+// TestRunOneConn checks that a run with everything that queries the database besides the inserts completes on a pool
+// of a single connection, which its sampler and profiling must borrow rather than hold.
+func TestRunOneConn(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cfg := Config{
+		N: 1000, Distribution: DistNormal, Inserts: []InsertStrategy{InsertStandard, InsertAppender, InsertArrow},
+		Workloads: []Workload{Histogram{Bins: 10}}, SampleInterval: time.Millisecond, ProfileQueries: true,
+		ExplainAnalyze: true, DB: DBOptions{Pool: Pool{MaxOpenConns: 1}},
+	}
+	if n := cfg.Conns(); n != 1 {
+		t.Fatalf("Conns() = %d, want 1", n)
+	}
+	res, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.QueryProfile == nil || res.Explain == nil || len(res.Samples) == 0 {
+		t.Errorf("profile %v, explain %v and %d samples, want all three", res.QueryProfile, res.Explain, len(res.Samples))
+	}
+	assertStats(t, "duckdb", res.DBStats, res.GoStats)
+}
+
+func TestConfigConns(t *testing.T) {
+	cfg := Config{Inserts: []InsertStrategy{
+		InsertStandard, ConcurrentInsert{Workers: 4, Strategy: InsertAppender}, ConcurrentInsert{Workers: 2, Strategy: InsertStandard},
+	}}
+	if n := cfg.Conns(); n != 4 {
+		t.Errorf("Conns() = %d, want the 4 connections of the most workers", n)
+	}
+}
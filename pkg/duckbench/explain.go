@@ -0,0 +1,73 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Explain returns DuckDB's physical plan for query, as rendered by EXPLAIN.
+func Explain(ctx context.Context, db Queryer, query string) (string, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	var plan strings.Builder
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return "", err
+		}
+		plan.WriteString(value)
+	}
+	return plan.String(), rows.Err()
+}
+
+// LogPlans logs the plan of each query at debug level, so plan changes between DuckDB versions can be diffed
+// when timings shift. Failing to explain a query is logged rather than returned, since it is only a debugging aid.
+func LogPlans(ctx context.Context, db Queryer, queries ...string) {
+	for _, query := range queries {
+		plan, err := Explain(ctx, db, query)
+		if err != nil {
+			slog.WarnContext(ctx, "explaining query", "query", query, "err", err)
+			continue
+		}
+		slog.DebugContext(ctx, "query plan", "query", query, "plan", plan)
+	}
+}
+
+// ExplainAnalysis is DuckDB's EXPLAIN ANALYZE of a query, next to the wall-clock time the statement took in Go. The
+// difference between the two is the time spent outside the engine, in the driver and database/sql.
+type ExplainAnalysis struct {
+	Query string
+	// Plan is the operator tree DuckDB rendered, with each operator's cardinality and timing.
+	Plan      string
+	Total     time.Duration
+	WallClock time.Duration
+}
+
+var totalTime = regexp.MustCompile(`Total Time: ([0-9.]+)s`)
+
+// ExplainAnalyze runs query under EXPLAIN ANALYZE, timing the statement as a whole.
+func ExplainAnalyze(ctx context.Context, db Queryer, query string) (*ExplainAnalysis, error) {
+	start := time.Now()
+	plan, err := Explain(ctx, db, "ANALYZE "+query)
+	wall := time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	m := totalTime.FindStringSubmatch(plan)
+	if m == nil {
+		return nil, fmt.Errorf("no total time in the analyzed plan of %q", query)
+	}
+	total, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing total time %q: %w", m[1], err)
+	}
+	return &ExplainAnalysis{Query: query, Plan: plan, Total: seconds(total), WallClock: wall}, nil
+}
@@ -0,0 +1,126 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	rtmetrics "runtime/metrics"
+	"sync"
+	"time"
+)
+
+// GCStats is the garbage collector's impact during a phase, read from runtime/metrics.
+type GCStats struct {
+	Duration time.Duration
+	Cycles   uint64
+	Pauses   uint64
+	// PauseTotal is estimated from the runtime's pause histogram, so it is accurate to within a bucket per pause.
+	PauseTotal   time.Duration
+	CPU          time.Duration
+	AllocBytes   uint64
+	AllocObjects uint64
+}
+
+// AllocRate returns the bytes allocated per second during the phase.
+func (s GCStats) AllocRate() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.AllocBytes) / s.Duration.Seconds()
+}
+
+var gcSampleNames = []string{
+	"/gc/cycles/total:gc-cycles",
+	"/sched/pauses/total/gc:seconds",
+	"/cpu/classes/gc/total:cpu-seconds",
+	"/gc/heap/allocs:bytes",
+	"/gc/heap/allocs:objects",
+}
+
+type gcReading struct {
+	at         time.Time
+	cycles     uint64
+	pauses     uint64
+	pauseTotal float64
+	cpu        float64
+	allocBytes uint64
+	allocObjs  uint64
+}
+
+func readGC() gcReading {
+	samples := make([]rtmetrics.Sample, len(gcSampleNames))
+	for i, name := range gcSampleNames {
+		samples[i].Name = name
+	}
+	rtmetrics.Read(samples)
+	r := gcReading{at: time.Now()}
+	for _, s := range samples {
+		switch s.Value.Kind() {
+		case rtmetrics.KindUint64:
+			v := s.Value.Uint64()
+			switch s.Name {
+			case "/gc/cycles/total:gc-cycles":
+				r.cycles = v
+			case "/gc/heap/allocs:bytes":
+				r.allocBytes = v
+			case "/gc/heap/allocs:objects":
+				r.allocObjs = v
+			}
+		case rtmetrics.KindFloat64:
+			r.cpu = s.Value.Float64()
+		case rtmetrics.KindFloat64Histogram:
+			r.pauses, r.pauseTotal = histogramTotals(s.Value.Float64Histogram())
+		}
+	}
+	return r
+}
+
+// histogramTotals returns the count and approximate sum of a runtime histogram, taking each bucket's midpoint
+// (or its finite bound, for the open-ended buckets).
+func histogramTotals(h *rtmetrics.Float64Histogram) (uint64, float64) {
+	var count uint64
+	var sum float64
+	for i, n := range h.Counts {
+		if n == 0 {
+			continue
+		}
+		lo, hi := h.Buckets[i], h.Buckets[i+1]
+		mid := (lo + hi) / 2
+		switch {
+		case math.IsInf(lo, -1):
+			mid = hi
+		case math.IsInf(hi, 1):
+			mid = lo
+		}
+		count += n
+		sum += float64(n) * mid
+	}
+	return count, sum
+}
+
+// gcTracker records GCStats for every phase of a run.
+type gcTracker struct {
+	mu    sync.Mutex
+	stats map[Phase]GCStats
+}
+
+func newGCTracker() *gcTracker {
+	return &gcTracker{stats: make(map[Phase]GCStats)}
+}
+
+func (t *gcTracker) hook(ctx context.Context, phase Phase) (context.Context, func()) {
+	start := readGC()
+	return ctx, func() {
+		end := readGC()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.stats[phase] = GCStats{
+			Duration:     end.at.Sub(start.at),
+			Cycles:       end.cycles - start.cycles,
+			Pauses:       end.pauses - start.pauses,
+			PauseTotal:   time.Duration((end.pauseTotal - start.pauseTotal) * float64(time.Second)),
+			CPU:          time.Duration((end.cpu - start.cpu) * float64(time.Second)),
+			AllocBytes:   end.allocBytes - start.allocBytes,
+			AllocObjects: end.allocObjs - start.allocObjs,
+		}
+	}
+}
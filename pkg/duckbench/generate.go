@@ -0,0 +1,182 @@
+package duckbench
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// Distribution selects the values generated for the records.
+type Distribution string
+
+const (
+	// DistSequential is the values 0, 1, 2, ... in order. It is the default.
+	DistSequential Distribution = "sequential"
+	// DistUniform is uniform over [0, 1).
+	DistUniform Distribution = "uniform"
+	// DistNormal is the standard normal distribution.
+	DistNormal Distribution = "normal"
+	// DistExponential is the exponential distribution with rate 1.
+	DistExponential Distribution = "exponential"
+	// DistZipf is a Zipf distribution with exponent 1.1 over the integers [0, N), so a few values are very common
+	// and most are rare.
+	DistZipf Distribution = "zipf"
+	// DistTimeSeries is DefaultTimeSeries, timestamped readings with a trend, a daily cycle and noise.
+	DistTimeSeries Distribution = "timeseries"
+)
+
+// Distributions lists the supported distributions.
+var Distributions = []Distribution{DistSequential, DistUniform, DistNormal, DistExponential, DistZipf, DistTimeSeries}
+
+func (d *Distribution) UnmarshalText(text []byte) error {
+	for _, dist := range Distributions {
+		if string(text) == string(dist) {
+			*d = dist
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown distribution %q, expected one of %v", text, Distributions)
+}
+
+// GenerateDistribution returns n records with values drawn from dist, the same for the same seed. Each Value2 is
+// paired with its Value, see pair.
+func GenerateDistribution(n int, dist Distribution, seed uint64) ([]Record, error) {
+	if dist == "" || dist == DistSequential {
+		return GenerateRecords(n), nil
+	}
+	r := rand.New(rand.NewPCG(seed, seed))
+	next, err := recordSource(n, dist, r)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, n)
+	for i := range records {
+		records[i] = next(i)
+	}
+	pair(records, r)
+	return records, nil
+}
+
+// recordSource returns a function generating the records of one of n values of dist from r, which must be called
+// for each record in turn. Their Value2 is left to pair.
+func recordSource(n int, dist Distribution, r *rand.Rand) (func(i int) Record, error) {
+	var next func() float64
+	switch dist {
+	case "", DistSequential:
+		return func(i int) Record { return Record{ID: i, Value: float64(i)} }, nil
+	case DistUniform:
+		next = r.Float64
+	case DistNormal:
+		next = r.NormFloat64
+	case DistExponential:
+		next = r.ExpFloat64
+	case DistZipf:
+		zipf := rand.NewZipf(r, 1.1, 1, uint64(max(n, 1)-1))
+		next = func() float64 { return float64(zipf.Uint64()) }
+	case DistTimeSeries:
+		return func(i int) Record { return DefaultTimeSeries.record(i, r) }, nil
+	default:
+		return nil, fmt.Errorf("unknown distribution %q, expected one of %v", dist, Distributions)
+	}
+	return func(i int) Record { return Record{ID: i, Value: next()} }, nil
+}
+
+// TimeSeries describes the readings of GenerateTimeSeries, taken every Interval from Start. Each is a Trend per
+// hour, plus a sine wave of Amplitude repeating every Season, plus normal noise with a standard deviation of Noise.
+type TimeSeries struct {
+	Start     time.Time
+	Interval  time.Duration
+	Trend     float64
+	Season    time.Duration
+	Amplitude float64
+	Noise     float64
+}
+
+// DefaultTimeSeries is a reading every second from the start of 2024, rising by one an hour, with a daily cycle of
+// plus or minus 10 and standard normal noise.
+var DefaultTimeSeries = TimeSeries{
+	Start:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	Interval:  time.Second,
+	Trend:     1,
+	Season:    24 * time.Hour,
+	Amplitude: 10,
+	Noise:     1,
+}
+
+// GenerateTimeSeries returns n readings of ts, the same for the same seed, with their Value2 paired as by
+// GenerateDistribution. The timestamps are truncated to the microsecond, as the ts column stores them.
+func GenerateTimeSeries(n int, ts TimeSeries, seed uint64) []Record {
+	r := rand.New(rand.NewPCG(seed, seed))
+	records := make([]Record, n)
+	for i := range records {
+		records[i] = ts.record(i, r)
+	}
+	pair(records, r)
+	return records
+}
+
+// record returns the ith reading of ts, drawing its noise from r.
+func (ts TimeSeries) record(i int, r *rand.Rand) Record {
+	elapsed := time.Duration(i) * ts.Interval
+	v := ts.Trend * elapsed.Hours()
+	if ts.Season > 0 {
+		v += ts.Amplitude * math.Sin(2*math.Pi*float64(elapsed%ts.Season)/float64(ts.Season))
+	}
+	return Record{ID: i, Value: v + ts.Noise*r.NormFloat64(), Time: ts.Start.Add(elapsed).Truncate(time.Microsecond)}
+}
+
+// AssignCategories assigns each record to one of groups categories at random, named g0, g1, ..., the same for the
+// same seed. The records are left as they are if groups is less than 1.
+func AssignCategories(records []Record, groups int, seed uint64) {
+	if groups < 1 {
+		return
+	}
+	r := rand.New(rand.NewPCG(seed, ^seed))
+	for i := range records {
+		records[i].Category = category(r, groups)
+	}
+}
+
+func category(r *rand.Rand, groups int) string {
+	return "g" + strconv.Itoa(r.IntN(groups))
+}
+
+// InjectNulls makes each of records NULL with probability ratio, the same for the same seed, see Record.Null.
+// Their Value2 is left paired with the value they had.
+func InjectNulls(records []Record, ratio float64, seed uint64) error {
+	if err := checkNullRatio(ratio); err != nil {
+		return err
+	}
+	r := rand.New(rand.NewPCG(^seed, seed))
+	for i := range records {
+		nullify(&records[i], ratio, r)
+	}
+	return nil
+}
+
+func checkNullRatio(ratio float64) error {
+	if !(ratio >= 0 && ratio <= 1) {
+		return fmt.Errorf("null ratio %g is not between 0 and 1", ratio)
+	}
+	return nil
+}
+
+func nullify(record *Record, ratio float64, r *rand.Rand) {
+	if r.Float64() < ratio {
+		record.Value, record.Null = math.NaN(), true
+	}
+}
+
+// pair sets the Value2 of each record to half its Value plus standard normal noise, so the two are correlated but
+// not perfectly.
+func pair(records []Record, r *rand.Rand) {
+	for i := range records {
+		records[i].Value2 = paired(records[i].Value, r)
+	}
+}
+
+func paired(v float64, r *rand.Rand) float64 {
+	return v/2 + r.NormFloat64()
+}
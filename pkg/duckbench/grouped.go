@@ -0,0 +1,133 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// GroupedStatistics is the count, mean and standard deviation of the finite values of each category: a map of
+// Welford accumulators in Go, and a GROUP BY in DuckDB.
+type GroupedStatistics struct{}
+
+// GroupStats are the statistics of one category.
+type GroupStats struct {
+	Count        int64
+	Mean, StdDev float64
+}
+
+// Groups is the result of GroupedStatistics, by category.
+type Groups map[string]GroupStats
+
+func (g Groups) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("groups", len(g)))
+}
+
+func (GroupedStatistics) Name() string { return "grouped" }
+
+func (GroupedStatistics) Go(records []Record) (WorkloadResult, error) {
+	acc := make(groupAccumulator)
+	for _, r := range records {
+		acc.add(r.Category, r.Value)
+	}
+	groups, err := acc.groups()
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// groupAccumulator accumulates the finite values of each category with Welford.
+type groupAccumulator map[string]*Welford
+
+func (acc groupAccumulator) add(category string, v float64) {
+	if !finite(v) {
+		return
+	}
+	w := acc[category]
+	if w == nil {
+		w = new(Welford)
+		acc[category] = w
+	}
+	w.Add(v)
+}
+
+func (acc groupAccumulator) groups() (Groups, error) {
+	if len(acc) == 0 {
+		return nil, ErrNoValues
+	}
+	groups := make(Groups, len(acc))
+	for category, w := range acc {
+		s, _ := w.Stats()
+		groups[category] = GroupStats{Count: w.Count(), Mean: s.Mean, StdDev: s.StdDev}
+	}
+	return groups, nil
+}
+
+func (GroupedStatistics) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT category, COUNT(*), AVG(value), STDDEV_POP(value)
+		FROM records
+		WHERE isfinite(value)
+		GROUP BY category`)
+	if err != nil {
+		return nil, fmt.Errorf("querying grouped statistics: %w", err)
+	}
+	defer rows.Close()
+	groups := make(Groups)
+	for rows.Next() {
+		var category string
+		var g GroupStats
+		if err := rows.Scan(&category, &g.Count, &g.Mean, &g.StdDev); err != nil {
+			return nil, fmt.Errorf("scanning grouped statistics: %w", err)
+		}
+		groups[category] = g
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading grouped statistics: %w", err)
+	}
+	if len(groups) == 0 {
+		return nil, ErrNoValues
+	}
+	return groups, nil
+}
+
+// Compare compares the counts of each category exactly, and their means and standard deviations within tol.
+func (g Groups) Compare(other WorkloadResult, tol floatcmp.Tolerance) []Mismatch {
+	o, ok := other.(Groups)
+	if !ok {
+		return []Mismatch{{Stat: "type"}}
+	}
+	var mismatches []Mismatch
+	for _, category := range g.categories() {
+		a, b := g[category], o[category]
+		if a.Count != b.Count {
+			mismatches = append(mismatches, Mismatch{Stat: "count of " + category, A: float64(a.Count), B: float64(b.Count)})
+			continue
+		}
+		if !tol.Equal(a.Mean, b.Mean) {
+			mismatches = append(mismatches, Mismatch{Stat: "mean of " + category, A: a.Mean, B: b.Mean})
+		}
+		if !tol.Equal(a.StdDev, b.StdDev) {
+			mismatches = append(mismatches, Mismatch{Stat: "stddev of " + category, A: a.StdDev, B: b.StdDev})
+		}
+	}
+	for _, category := range o.categories() {
+		if _, ok := g[category]; !ok {
+			mismatches = append(mismatches, Mismatch{Stat: "count of " + category, B: float64(o[category].Count)})
+		}
+	}
+	return mismatches
+}
+
+func (g Groups) categories() []string {
+	categories := make([]string, 0, len(g))
+	for category := range g {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
@@ -0,0 +1,47 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestGroupedStatistics(t *testing.T) {
+	ctx := context.Background()
+	records := []Record{
+		{Value: 1, Category: "a"}, {Value: 3, Category: "a"}, {Value: math.NaN(), Category: "a"},
+		{Value: 10, Category: "b"},
+		{Value: math.Inf(1), Category: "c"},
+	}
+	want := Groups{"a": {Count: 2, Mean: 2, StdDev: 1}, "b": {Count: 1, Mean: 10, StdDev: 0}}
+	db := loadDB(t, records)
+	for engine, run := range map[string]func() (WorkloadResult, error){
+		"go":     func() (WorkloadResult, error) { return GroupedStatistics{}.Go(records) },
+		"duckdb": func() (WorkloadResult, error) { return GroupedStatistics{}.DuckDB(ctx, db) },
+	} {
+		got, err := run()
+		if err != nil {
+			t.Fatalf("%s: %v", engine, err)
+		}
+		if m := want.Compare(got, floatcmp.Rel(1e-12)); len(m) > 0 {
+			t.Errorf("%s: %+v, mismatches %+v", engine, got, m)
+		}
+	}
+}
+
+func TestRunGroupedStatistics(t *testing.T) {
+	res, err := Run(context.Background(), Config{N: 10000, Distribution: DistNormal, Groups: 100,
+		Inserts: []InsertStrategy{InsertAppender}, Workloads: []Workload{GroupedStatistics{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.Workloads[0].GoResult.(Groups)
+	if len(got) != 100 {
+		t.Errorf("%d groups, want 100", len(got))
+	}
+	if m := res.Workloads[0].Mismatches(floatcmp.Rel(1e-9)); len(m) > 0 {
+		t.Errorf("go and duckdb grouped statistics differ: %+v", m)
+	}
+}
@@ -0,0 +1,123 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// Histogram counts the finite values into Bins bins of equal width between their min and max, the last bin
+// including the max. Both engines bin with the same floating point operations, so their counts agree exactly.
+type Histogram struct {
+	Bins int
+}
+
+// HistogramCounts is the result of a Histogram.
+type HistogramCounts struct {
+	Min, Max float64
+	Counts   []int64
+}
+
+func (h HistogramCounts) LogValue() slog.Value {
+	return slog.GroupValue(slog.Float64("min", h.Min), slog.Float64("max", h.Max), slog.Any("counts", h.Counts))
+}
+
+func (h Histogram) Name() string { return fmt.Sprintf("histogram/%d", h.Bins) }
+
+func (h Histogram) check() error {
+	if h.Bins < 1 {
+		return fmt.Errorf("histogram needs at least one bin, not %d", h.Bins)
+	}
+	return nil
+}
+
+func (h Histogram) Go(records []Record) (WorkloadResult, error) {
+	if err := h.check(); err != nil {
+		return nil, err
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, r := range records {
+		if finite(r.Value) {
+			lo, hi = math.Min(lo, r.Value), math.Max(hi, r.Value)
+		}
+	}
+	if lo > hi {
+		return nil, ErrNoValues
+	}
+	res := HistogramCounts{Min: lo, Max: hi, Counts: make([]int64, h.Bins)}
+	width := (hi - lo) / float64(h.Bins)
+	for _, r := range records {
+		if !finite(r.Value) {
+			continue
+		}
+		bin := 0
+		if hi != lo {
+			bin = min(int(math.Floor((r.Value-lo)/width)), h.Bins-1)
+		}
+		res.Counts[bin]++
+	}
+	return res, nil
+}
+
+func (h Histogram) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	if err := h.check(); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		WITH bounds AS (SELECT MIN(value) AS lo, MAX(value) AS hi FROM records WHERE isfinite(value))
+		SELECT lo, hi,
+			CASE WHEN hi = lo THEN 0 ELSE LEAST(CAST(FLOOR((value - lo) / ((hi - lo) / %[1]d)) AS BIGINT), %[1]d - 1) END AS bin,
+			COUNT(*)
+		FROM records, bounds
+		WHERE isfinite(value)
+		GROUP BY lo, hi, bin
+	`, h.Bins))
+	if err != nil {
+		return nil, fmt.Errorf("querying histogram: %w", err)
+	}
+	defer rows.Close()
+	res := HistogramCounts{Counts: make([]int64, h.Bins)}
+	empty := true
+	for rows.Next() {
+		var bin, count int64
+		if err := rows.Scan(&res.Min, &res.Max, &bin, &count); err != nil {
+			return nil, fmt.Errorf("scanning histogram: %w", err)
+		}
+		res.Counts[bin] = count
+		empty = false
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading histogram: %w", err)
+	}
+	if empty {
+		return nil, ErrNoValues
+	}
+	return res, nil
+}
+
+// Compare compares the bounds within tol, and the counts exactly.
+func (h HistogramCounts) Compare(other WorkloadResult, tol floatcmp.Tolerance) []Mismatch {
+	o, ok := other.(HistogramCounts)
+	if !ok {
+		return []Mismatch{{Stat: "type"}}
+	}
+	var mismatches []Mismatch
+	if !tol.Equal(h.Min, o.Min) {
+		mismatches = append(mismatches, Mismatch{Stat: "min", A: h.Min, B: o.Min})
+	}
+	if !tol.Equal(h.Max, o.Max) {
+		mismatches = append(mismatches, Mismatch{Stat: "max", A: h.Max, B: o.Max})
+	}
+	if len(h.Counts) != len(o.Counts) {
+		return append(mismatches, Mismatch{Stat: "bins", A: float64(len(h.Counts)), B: float64(len(o.Counts))})
+	}
+	for i := range h.Counts {
+		if h.Counts[i] != o.Counts[i] {
+			mismatches = append(mismatches, Mismatch{Stat: fmt.Sprintf("bin %d", i), A: float64(h.Counts[i]), B: float64(o.Counts[i])})
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,55 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestHistogram(t *testing.T) {
+	ctx := context.Background()
+	sequential := GenerateRecords(100)
+	tests := map[string]struct {
+		records []Record
+		bins    int
+		want    HistogramCounts
+	}{
+		"sequential": {sequential, 10, HistogramCounts{Min: 0, Max: 99, Counts: []int64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}}},
+		"non-finite": {recordsOf(math.NaN(), 1, math.Inf(1), 2, 3, 4), 3, HistogramCounts{Min: 1, Max: 4, Counts: []int64{1, 1, 2}}},
+		"constant":   {recordsOf(5, 5, 5), 4, HistogramCounts{Min: 5, Max: 5, Counts: []int64{3, 0, 0, 0}}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := Histogram{Bins: tt.bins}
+			db := loadDB(t, tt.records)
+			for engine, run := range map[string]func() (WorkloadResult, error){
+				"go":     func() (WorkloadResult, error) { return h.Go(tt.records) },
+				"duckdb": func() (WorkloadResult, error) { return h.DuckDB(ctx, db) },
+			} {
+				got, err := run()
+				if err != nil {
+					t.Fatalf("%s: %v", engine, err)
+				}
+				if m := tt.want.Compare(got, floatcmp.Exact); len(m) > 0 {
+					t.Errorf("%s: %+v, mismatches %+v", engine, got, m)
+				}
+			}
+		})
+	}
+}
+
+func TestRunWorkloads(t *testing.T) {
+	res, err := Run(context.Background(), Config{N: 10000, Distribution: DistNormal, Inserts: []InsertStrategy{InsertAppender},
+		Workloads: []Workload{Histogram{Bins: 50}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Workloads) != 1 {
+		t.Fatalf("%d workloads, want 1", len(res.Workloads))
+	}
+	if m := res.Workloads[0].Mismatches(floatcmp.Exact); len(m) > 0 {
+		t.Errorf("go and duckdb histograms differ: %+v", m)
+	}
+}
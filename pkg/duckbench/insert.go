@@ -0,0 +1,237 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// An InsertStrategy inserts records into the records table created by CreateRecordsTable.
+type InsertStrategy interface {
+	Name() string
+	Insert(ctx context.Context, records []Record, db *sql.DB) error
+}
+
+// A TxInsertStrategy is an InsertStrategy which can also insert into a transaction its caller manages, to commit
+// the records along with other statements or roll them back. Its Insert runs InsertTx in a transaction of its own,
+// see InsertInTx.
+type TxInsertStrategy interface {
+	InsertStrategy
+	InsertTx(ctx context.Context, records []Record, tx *sql.Tx) error
+}
+
+// ErrNoTx is returned by InsertMethod.InsertTx for InsertAppender and InsertArrow, which write over a connection of
+// their own rather than through a transaction.
+var ErrNoTx = errors.New("insert method cannot run in a transaction")
+
+// InsertInTx inserts records with s in a transaction of their own on db, committing it once they are all inserted,
+// or rolling it back if any insert fails.
+func InsertInTx(ctx context.Context, db *sql.DB, records []Record, s TxInsertStrategy) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := s.InsertTx(ctx, records, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing: %w", err)
+	}
+	return nil
+}
+
+// InsertMethod is one of the built in insert strategies.
+type InsertMethod string
+
+const (
+	// InsertStandard runs an INSERT per record in a single transaction. It is the default.
+	InsertStandard InsertMethod = "standard"
+	// InsertAppender streams records through go-duckdb's native Appender.
+	InsertAppender InsertMethod = "appender"
+	// InsertValues runs multi-row INSERTs of DefaultValuesBatch rows each, see ValuesInsert.
+	InsertValues InsertMethod = "values"
+	// InsertCSV writes the records to a temporary CSV file and loads that with read_csv_auto.
+	InsertCSV InsertMethod = "csv"
+	// InsertArrow registers the records with DuckDB as Arrow record batches and inserts them from those, see
+	// ArrowInsert.
+	InsertArrow InsertMethod = "arrow"
+)
+
+// InsertMethods lists the supported insert methods.
+var InsertMethods = []InsertMethod{InsertStandard, InsertAppender, InsertValues, InsertCSV, InsertArrow}
+
+func (m *InsertMethod) UnmarshalText(text []byte) error {
+	for _, method := range InsertMethods {
+		if string(text) == string(method) {
+			*m = method
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown insert method %q, expected one of %v", text, InsertMethods)
+}
+
+func (m InsertMethod) Name() string { return string(m) }
+
+func (m InsertMethod) Insert(ctx context.Context, records []Record, db *sql.DB) error {
+	switch m {
+	case InsertAppender:
+		return AppenderInsert(ctx, records, db)
+	case InsertValues:
+		return ValuesInsert{BatchSize: DefaultValuesBatch}.Insert(ctx, records, db)
+	case InsertCSV:
+		return CSVInsert(ctx, records, db)
+	case InsertArrow:
+		return ArrowInsert(ctx, records, db)
+	default:
+		return StandardInsert(ctx, records, db)
+	}
+}
+
+// InsertTx inserts records into tx with any method but InsertAppender and InsertArrow, which return ErrNoTx.
+func (m InsertMethod) InsertTx(ctx context.Context, records []Record, tx *sql.Tx) error {
+	switch m {
+	case InsertAppender, InsertArrow:
+		return fmt.Errorf("%w: %s", ErrNoTx, m)
+	case InsertValues:
+		return ValuesInsert{BatchSize: DefaultValuesBatch}.InsertTx(ctx, records, tx)
+	case InsertCSV:
+		return csvInsert(ctx, records, tx)
+	default:
+		return StandardInsertTx(ctx, records, tx)
+	}
+}
+
+// StandardInsert runs an INSERT per record in a single transaction.
+func StandardInsert(ctx context.Context, records []Record, db *sql.DB) error {
+	return InsertInTx(ctx, db, records, InsertStandard)
+}
+
+// StandardInsertTx runs an INSERT per record in tx.
+func StandardInsertTx(ctx context.Context, records []Record, tx *sql.Tx) error {
+	for i, record := range records {
+		_, err := tx.ExecContext(ctx, insertStatement, record.value(), record.Value2, record.Category, record.timestamp())
+		if err != nil {
+			return fmt.Errorf("inserting record %d: %w", i, err)
+		}
+		reportProgress(ctx, 1)
+	}
+	return nil
+}
+
+// appenderFlushRows is how many rows AppenderInsert buffers before flushing them to the table, since the appender
+// otherwise holds every row in memory until it is closed.
+const appenderFlushRows = 1 << 17
+
+// AppenderInsert inserts records through the native DuckDB Appender on a connection of its own, bypassing SQL
+// entirely. An appender must not be shared between goroutines, but concurrent calls each get their own.
+func AppenderInsert(ctx context.Context, records []Record, db *sql.DB) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("opening connection: %w", err)
+	}
+	defer conn.Close()
+	return conn.Raw(func(dc any) error {
+		appender, err := duckdb.NewAppenderFromConn(sqlwrap.Unwrap(dc).(driver.Conn), "", "records")
+		if err != nil {
+			return fmt.Errorf("creating appender: %w", err)
+		}
+		for i, record := range records {
+			// the appender does not fill in defaults, so ids are numbered from the records' own, as the sequence
+			// would have numbered a whole generated dataset, and shards appended concurrently do not collide
+			if err := appender.AppendRow(int32(record.ID+1), record.value(), record.Value2, record.Category, record.timestamp()); err != nil {
+				appender.Close()
+				return fmt.Errorf("appending record %d: %w", i, err)
+			}
+			if (i+1)%appenderFlushRows == 0 {
+				if err := errors.Join(ctx.Err(), appender.Flush()); err != nil {
+					appender.Close()
+					return fmt.Errorf("flushing appender: %w", err)
+				}
+				reportProgress(ctx, appenderFlushRows)
+			}
+		}
+		if err := appender.Close(); err != nil {
+			return fmt.Errorf("closing appender: %w", err)
+		}
+		reportProgress(ctx, int64(len(records)%appenderFlushRows))
+		return nil
+	})
+}
+
+// DefaultValuesBatch is the number of rows per statement of InsertValues.
+const DefaultValuesBatch = 1000
+
+// ValuesInsert inserts BatchSize records per INSERT ... VALUES (?, ?, ?, ?), (?, ?, ?, ?), ... statement in a single
+// transaction, a middle ground between a statement per row and the Appender for drivers without one.
+type ValuesInsert struct {
+	BatchSize int
+}
+
+func (v ValuesInsert) Name() string { return fmt.Sprintf("values/%d", v.BatchSize) }
+
+func (v ValuesInsert) Insert(ctx context.Context, records []Record, db *sql.DB) error {
+	return InsertInTx(ctx, db, records, v)
+}
+
+func (v ValuesInsert) InsertTx(ctx context.Context, records []Record, tx *sql.Tx) error {
+	if v.BatchSize < 1 {
+		return fmt.Errorf("batch size %d is not positive", v.BatchSize)
+	}
+	full, err := tx.PrepareContext(ctx, valuesStatement(v.BatchSize))
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	defer full.Close()
+
+	args := make([]any, 0, 4*v.BatchSize)
+	for start := 0; start < len(records); start += v.BatchSize {
+		batch := records[start:min(start+v.BatchSize, len(records))]
+		args = args[:0]
+		for _, r := range batch {
+			args = append(args, r.value(), r.Value2, r.Category, r.timestamp())
+		}
+		if len(batch) == v.BatchSize {
+			_, err = full.ExecContext(ctx, args...)
+		} else {
+			_, err = tx.ExecContext(ctx, valuesStatement(len(batch)), args...)
+		}
+		if err != nil {
+			return fmt.Errorf("inserting records %d to %d: %w", start, start+len(batch)-1, err)
+		}
+		reportProgress(ctx, int64(len(batch)))
+	}
+	return nil
+}
+
+// CommitInsert runs an INSERT per record as StandardInsert does, but commits a transaction every CommitEvery
+// records instead of one over them all, to show what batching the commits is worth.
+type CommitInsert struct {
+	CommitEvery int
+}
+
+func (c CommitInsert) Name() string { return fmt.Sprintf("commit/%d", c.CommitEvery) }
+
+func (c CommitInsert) Insert(ctx context.Context, records []Record, db *sql.DB) error {
+	if c.CommitEvery < 1 {
+		return fmt.Errorf("commit size %d is not positive", c.CommitEvery)
+	}
+	for start := 0; start < len(records); start += c.CommitEvery {
+		batch := records[start:min(start+c.CommitEvery, len(records))]
+		if err := InsertInTx(ctx, db, batch, InsertStandard); err != nil {
+			return fmt.Errorf("transaction of records %d to %d: %w", start, start+len(batch)-1, err)
+		}
+	}
+	return nil
+}
+
+func valuesStatement(rows int) string {
+	return "INSERT INTO records (value, value2, category, ts) VALUES " + strings.Repeat("(?, ?, ?, ?), ", rows-1) + "(?, ?, ?, ?)"
+}
@@ -0,0 +1,113 @@
+package duckbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+)
+
+// ErrIntegrity is returned when the records table does not hold exactly the records which were inserted.
+var ErrIntegrity = errors.New("records table does not match the inserted records")
+
+// Checksum summarises a multiset of rows independently of their order. It hashes the exact bits of each value, the
+// bytes of each category and the microseconds of each timestamp, so any dropped, duplicated or altered row changes
+// it (short of deliberately constructed collisions); the sum of hashes catches the pairs of duplicates which
+// cancel out of the xor.
+type Checksum struct {
+	Count int64
+	Xor   uint64
+	Sum   uint64
+}
+
+// canonicalNaN stands in for every NaN, as ingestion paths going through text do not preserve NaN payloads.
+var canonicalNaN = math.Float64bits(math.NaN())
+
+const fnvOffset, fnvPrime = 14695981039346656037, 1099511628211
+
+func fnvUint64(h, v uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		h = (h ^ v&0xff) * fnvPrime
+		v >>= 8
+	}
+	return h
+}
+
+func floatBits(v float64) uint64 {
+	if math.IsNaN(v) {
+		return canonicalNaN
+	}
+	return math.Float64bits(v)
+}
+
+// nullBits stands in for the bits of a NULL value, a NaN payload which canonicalNaN never takes.
+const nullBits = 0x7ff4000000000000
+
+// Add adds a row of the records table, hashed with FNV-1a. Its ID is left out, not being part of the data.
+func (c *Checksum) Add(r Record) {
+	value := floatBits(r.Value)
+	if r.Null {
+		value = nullBits
+	}
+	h := fnvUint64(fnvUint64(fnvOffset, value), floatBits(r.Value2))
+	for i := 0; i < len(r.Category); i++ {
+		h = (h ^ uint64(r.Category[i])) * fnvPrime
+	}
+	h = fnvUint64(h, uint64(r.Time.UnixMicro()))
+	c.Count++
+	c.Xor ^= h
+	c.Sum += h
+}
+
+func ChecksumRecords(records []Record) Checksum {
+	var c Checksum
+	for _, r := range records {
+		c.Add(r)
+	}
+	return c
+}
+
+// ChecksumTable reads back every row of the records table. There is no way to reinterpret a DOUBLE's bits in
+// DuckDB's SQL, so the checksum is calculated in Go.
+func ChecksumTable(ctx context.Context, db Queryer) (Checksum, error) {
+	var c Checksum
+	rows, err := db.QueryContext(ctx, "SELECT value, value2, category, ts FROM records")
+	if err != nil {
+		return c, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r Record
+		var value nullable.Nullable[float64]
+		var ts nullable.Nullable[time.Time]
+		if err := rows.Scan(&value, &r.Value2, &r.Category, &ts); err != nil {
+			return c, err
+		}
+		r.Value, r.Null, r.Time = value.V, !value.Valid, ts.V
+		c.Add(r)
+	}
+	return c, rows.Err()
+}
+
+// VerifyIngestion checks that the records table holds exactly records, returning an error wrapping ErrIntegrity
+// if it does not.
+func VerifyIngestion(ctx context.Context, db Queryer, records []Record) error {
+	return verifyChecksum(ctx, db, ChecksumRecords(records))
+}
+
+func verifyChecksum(ctx context.Context, db Queryer, want Checksum) error {
+	got, err := ChecksumTable(ctx, db)
+	if err != nil {
+		return err
+	}
+	switch {
+	case got.Count != want.Count:
+		return fmt.Errorf("%w: %d rows, want %d", ErrIntegrity, got.Count, want.Count)
+	case got != want:
+		return fmt.Errorf("%w: checksum %x/%x, want %x/%x", ErrIntegrity, got.Xor, got.Sum, want.Xor, want.Sum)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package duckbench
+
+import "log/slog"
+
+// MemoryUsage is the memory used by both sides of a phase, which matters as much as speed once the records no
+// longer fit comfortably in memory.
+type MemoryUsage struct {
+	// HeapAlloc is the Go heap in use as the phase ended, PeakHeapAlloc the most sampled during it, and TotalAlloc
+	// and NumGC the bytes allocated and collections completed during it, from runtime.MemStats.
+	HeapAlloc     uint64 `json:"go_heap_bytes"`
+	PeakHeapAlloc uint64 `json:"go_peak_heap_bytes"`
+	TotalAlloc    uint64 `json:"go_total_alloc_bytes"`
+	NumGC         uint32 `json:"go_gc_cycles"`
+	// DuckDBMemory and PeakDuckDBMemory are the same for DuckDB's buffers, summed over duckdb_memory().
+	DuckDBMemory     int64 `json:"duckdb_memory_bytes"`
+	PeakDuckDBMemory int64 `json:"duckdb_peak_memory_bytes"`
+	// DatabaseSize is the bytes of blocks in use as the phase ended, from PRAGMA database_size, which is zero for
+	// an in-memory database.
+	DatabaseSize int64 `json:"database_size_bytes"`
+}
+
+func (m MemoryUsage) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Uint64("go_heap_bytes", m.HeapAlloc),
+		slog.Uint64("go_peak_heap_bytes", m.PeakHeapAlloc),
+		slog.Uint64("go_total_alloc_bytes", m.TotalAlloc),
+		slog.Uint64("go_gc_cycles", uint64(m.NumGC)),
+		slog.Int64("duckdb_memory_bytes", m.DuckDBMemory),
+		slog.Int64("duckdb_peak_memory_bytes", m.PeakDuckDBMemory),
+		slog.Int64("database_size_bytes", m.DatabaseSize),
+	)
+}
+
+// MemoryUsageByPhase returns the memory usage of samples by phase, from the samples taken as each started and ended
+// and those in between, so its peaks are only those sampled, as with PeakMemory.
+func MemoryUsageByPhase(samples []ResourceSample) map[Phase]MemoryUsage {
+	first := make(map[Phase]ResourceSample)
+	usage := make(map[Phase]MemoryUsage)
+	for _, sample := range samples {
+		if sample.Phase == "" {
+			continue
+		}
+		start, ok := first[sample.Phase]
+		if !ok {
+			first[sample.Phase] = sample
+			start = sample
+		}
+		u := usage[sample.Phase]
+		u.HeapAlloc = sample.GoHeapAlloc
+		u.PeakHeapAlloc = max(u.PeakHeapAlloc, sample.GoHeapAlloc)
+		u.TotalAlloc = sample.GoTotalAlloc - start.GoTotalAlloc
+		u.NumGC = sample.GoNumGC - start.GoNumGC
+		u.DuckDBMemory = sample.DuckDBMemory
+		u.PeakDuckDBMemory = max(u.PeakDuckDBMemory, sample.DuckDBMemory)
+		u.DatabaseSize = sample.DatabaseSize
+		usage[sample.Phase] = u
+	}
+	return usage
+}
@@ -0,0 +1,156 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// The inserts and scans here run the same statements through database/sql and straight through the go-duckdb
+// driver connection underneath it, so the difference between them is the cost of database/sql itself: converting
+// and checking arguments, locking the connection and watching the context on every call, and converting every
+// value scanned.
+
+const insertStatement = "INSERT INTO records (value, value2, category, ts) VALUES (?, ?, ?, ?)"
+
+// PreparedInsert is StandardInsert with the INSERT prepared once, through database/sql.
+func PreparedInsert(ctx context.Context, records []Record, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, insertStatement)
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	defer stmt.Close()
+	for i, r := range records {
+		if _, err := stmt.ExecContext(ctx, r.value(), r.Value2, r.Category, r.timestamp()); err != nil {
+			return fmt.Errorf("inserting record %d: %w", i, err)
+		}
+		reportProgress(ctx, 1)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing: %w", err)
+	}
+	return nil
+}
+
+// rawConn runs fn with the driver connection of a connection of its own from db.
+func rawConn(ctx context.Context, db *sql.DB, fn func(conn driver.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("opening connection: %w", err)
+	}
+	defer conn.Close()
+	return conn.Raw(func(dc any) error { return fn(sqlwrap.Unwrap(dc).(driver.Conn)) })
+}
+
+// NativeInsert is PreparedInsert straight through the driver connection.
+func NativeInsert(ctx context.Context, records []Record, db *sql.DB) error {
+	return rawConn(ctx, db, func(conn driver.Conn) error {
+		tx, err := conn.(driver.ConnBeginTx).BeginTx(ctx, driver.TxOptions{})
+		if err != nil {
+			return fmt.Errorf("beginning transaction: %w", err)
+		}
+		stmt, err := conn.Prepare(insertStatement)
+		if err != nil {
+			return errors.Join(fmt.Errorf("preparing insert: %w", err), tx.Rollback())
+		}
+		exec := stmt.(driver.StmtExecContext)
+		args := make([]driver.NamedValue, 4)
+		for i := range args {
+			args[i].Ordinal = i + 1
+		}
+		for i, r := range records {
+			// the driver takes the values database/sql would have converted them to
+			args[0].Value, args[1].Value, args[2].Value, args[3].Value = r.value(), r.Value2, r.Category, r.timestamp()
+			if _, err := exec.ExecContext(ctx, args); err != nil {
+				return errors.Join(fmt.Errorf("inserting record %d: %w", i, err), stmt.Close(), tx.Rollback())
+			}
+			reportProgress(ctx, 1)
+		}
+		if err := stmt.Close(); err != nil {
+			return errors.Join(fmt.Errorf("closing statement: %w", err), tx.Rollback())
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing: %w", err)
+		}
+		return nil
+	})
+}
+
+// scanQuery selects every column of the records table, in the order of their fields in Record.
+const scanQuery = "SELECT id, value, value2, category, ts FROM records"
+
+// ScanSummary is what a scan of the records table read, to check scans against each other.
+type ScanSummary struct {
+	Rows int
+	// Sum is the sum of the non-NULL values.
+	Sum float64
+}
+
+// ScanRecords reads every row of the records table through database/sql into typed variables.
+func ScanRecords(ctx context.Context, db Queryer) (ScanSummary, error) {
+	var s ScanSummary
+	rows, err := db.QueryContext(ctx, scanQuery)
+	if err != nil {
+		return s, fmt.Errorf("querying records: %w", err)
+	}
+	defer rows.Close()
+	var (
+		id       int32
+		value    nullable.Nullable[float64]
+		value2   float64
+		category string
+		ts       nullable.Nullable[time.Time]
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &value, &value2, &category, &ts); err != nil {
+			return s, fmt.Errorf("scanning record %d: %w", s.Rows, err)
+		}
+		s.Rows++
+		s.Sum += value.Or(0)
+	}
+	return s, rows.Err()
+}
+
+// NativeScanRecords is ScanRecords straight through the driver connection, taking the values as the driver returns
+// them.
+func NativeScanRecords(ctx context.Context, db *sql.DB) (ScanSummary, error) {
+	var s ScanSummary
+	err := rawConn(ctx, db, func(conn driver.Conn) error {
+		rows, err := conn.(driver.QueryerContext).QueryContext(ctx, scanQuery, nil)
+		if err != nil {
+			return fmt.Errorf("querying records: %w", err)
+		}
+		defer rows.Close()
+		dest := make([]driver.Value, len(rows.Columns()))
+		for {
+			if err := rows.Next(dest); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("reading record %d: %w", s.Rows, err)
+			}
+			_, idOK := dest[0].(int32)
+			_, v2OK := dest[2].(float64)
+			_, catOK := dest[3].(string)
+			if !idOK || !v2OK || !catOK {
+				return fmt.Errorf("record %d is %T, %T, %T, not an INTEGER, DOUBLE and VARCHAR", s.Rows, dest[0], dest[2], dest[3])
+			}
+			s.Rows++
+			if v, ok := dest[1].(float64); ok {
+				s.Sum += v
+			}
+		}
+	})
+	return s, err
+}
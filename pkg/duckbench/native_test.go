@@ -0,0 +1,39 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// TestNative checks that the inserts and scans through the driver connection do exactly what those through
+// database/sql do.
+func TestNative(t *testing.T) {
+	ctx := context.Background()
+	records, _ := GenerateDistribution(2000, DistTimeSeries, 1)
+	AssignCategories(records, 5, 1)
+	InjectNulls(records, 0.1, 1)
+	for name, insert := range map[string]func(context.Context, []Record, *sql.DB) error{
+		"prepared": PreparedInsert,
+		"native":   NativeInsert,
+	} {
+		db := loadDB(t, nil)
+		if err := insert(ctx, records, db); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := VerifyIngestion(ctx, db, records); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		want, err := ScanRecords(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := NativeScanRecords(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want.Rows != len(records) || got != want {
+			t.Errorf("%s: native scan read %+v, database/sql %+v of %d records", name, got, want, len(records))
+		}
+	}
+}
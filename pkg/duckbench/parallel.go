@@ -0,0 +1,127 @@
+package duckbench
+
+import (
+	"math"
+	"slices"
+	"sync"
+)
+
+// partial is the aggregate of one chunk of records, its finite values sorted.
+type partial struct {
+	values                 []float64
+	min, max, sum, squares float64
+	nonFinite, nulls       int64
+}
+
+// ParallelStatistics is StatisticsWithSummation split across threads goroutines, so it can be compared fairly with
+// DuckDB running as many threads. Each goroutine filters and sorts a chunk of the records, and sums it in the two
+// passes the serial version makes; the partial sums are then added up, and the sorted chunks merged in parallel
+// for the median and percentiles.
+func ParallelStatistics(records []Record, sum Summation, threads int, percentiles ...float64) (Stats, error) {
+	if err := checkPercentiles(percentiles); err != nil {
+		return Stats{}, err
+	}
+	threads = max(1, min(threads, len(records)))
+	size := (len(records) + threads - 1) / threads
+	parts := make([]partial, threads)
+	inParallel(threads, func(i int) {
+		p := partial{min: math.Inf(1), max: math.Inf(-1)}
+		chunk := records[min(i*size, len(records)):min((i+1)*size, len(records))]
+		p.values = make([]float64, 0, len(chunk))
+		for _, r := range chunk {
+			if r.Null {
+				p.nulls++
+				continue
+			}
+			if math.IsNaN(r.Value) || math.IsInf(r.Value, 0) {
+				p.nonFinite++
+				continue
+			}
+			p.min, p.max = math.Min(p.min, r.Value), math.Max(p.max, r.Value)
+			p.values = append(p.values, r.Value)
+		}
+		slices.Sort(p.values)
+		parts[i] = p
+	})
+
+	s := Stats{Min: math.Inf(1), Max: math.Inf(-1)}
+	n := 0
+	for _, p := range parts {
+		s.Min, s.Max = math.Min(s.Min, p.min), math.Max(s.Max, p.max)
+		s.NonFinite += p.nonFinite
+		s.Nulls += p.nulls
+		n += len(p.values)
+	}
+	if n == 0 {
+		return Stats{NonFinite: s.NonFinite, Nulls: s.Nulls}, ErrNoValues
+	}
+
+	// scaled as in StatisticsWithSummation, so the partial sums cannot overflow either
+	exp, scale := sumScale(math.Max(math.Abs(s.Min), math.Abs(s.Max)))
+	inParallel(threads, func(i int) { parts[i].sum = sum.sum(parts[i].values, scale) })
+	var total float64
+	for _, p := range parts {
+		total += p.sum
+	}
+	scaledMean := total / float64(n)
+	inParallel(threads, func(i int) { parts[i].squares = sum.squares(parts[i].values, scaledMean, scale) })
+	var squares float64
+	for _, p := range parts {
+		squares += p.squares
+	}
+	s.Mean = math.Ldexp(scaledMean, exp)
+	s.StdDev = math.Ldexp(math.Sqrt(squares/float64(n)), exp)
+
+	values := mergeSorted(parts)
+	s.Median = sortedMedian(values)
+	for _, p := range percentiles {
+		s.Percentiles = append(s.Percentiles, Percentile{P: p, Value: percentile(values, p)})
+	}
+	return s, nil
+}
+
+// inParallel calls fn with 0..n-1, each in its own goroutine, returning once they have all returned.
+func inParallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// mergeSorted merges the sorted values of parts into one sorted slice, merging pairs of runs in parallel until one
+// is left.
+func mergeSorted(parts []partial) []float64 {
+	runs := make([][]float64, len(parts))
+	for i, p := range parts {
+		runs[i] = p.values
+	}
+	for len(runs) > 1 {
+		merged := make([][]float64, (len(runs)+1)/2)
+		inParallel(len(merged), func(i int) {
+			if 2*i+1 == len(runs) {
+				merged[i] = runs[2*i]
+				return
+			}
+			merged[i] = mergeRuns(runs[2*i], runs[2*i+1])
+		})
+		runs = merged
+	}
+	return runs[0]
+}
+
+func mergeRuns(a, b []float64) []float64 {
+	merged := make([]float64, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if b[0] < a[0] {
+			merged, b = append(merged, b[0]), b[1:]
+		} else {
+			merged, a = append(merged, a[0]), a[1:]
+		}
+	}
+	return append(append(merged, a...), b...)
+}
@@ -0,0 +1,120 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Pool configures the database/sql connection pool of a DB. Every connection in it is another connection to the
+// same database in this process, so unlike with a database server opening one costs no round trip, but a query
+// can only run alongside another on a connection of its own.
+type Pool struct {
+	// MaxOpenConns limits the connections open at once, if positive; database/sql's default is no limit.
+	MaxOpenConns int
+	// MaxIdleConns is how many connections are kept open between queries, if positive, or none if negative;
+	// database/sql's default is 2.
+	MaxIdleConns int
+	// ConnMaxLifetime closes connections once they are this old, if positive.
+	ConnMaxLifetime time.Duration
+}
+
+func (p Pool) apply(db *sql.DB) {
+	if p.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(p.MaxOpenConns)
+	}
+	if p.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(max(p.MaxIdleConns, 0))
+	}
+	if p.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	}
+}
+
+// LoadResult is the outcome of QueryLoad.
+type LoadResult struct {
+	Clients  int
+	Queries  int
+	Duration time.Duration
+	Latency  Timing
+	P99      time.Duration
+	// Waits is how many queries waited for a connection, as the pool was at MaxOpenConns, and WaitDuration how
+	// long they waited in all.
+	Waits        int64
+	WaitDuration time.Duration
+	// Closed is how many connections the pool closed, to keep to MaxIdleConns or ConnMaxLifetime, which later
+	// queries had to open again.
+	Closed int64
+}
+
+func (r LoadResult) QueriesPerSecond() float64 { return float64(r.Queries) / r.Duration.Seconds() }
+
+// QueryLoad runs query in a loop from each of clients goroutines for duration, reading every row of each result,
+// and times each query along with what the pool of db did meanwhile. The first query to fail stops the others.
+func QueryLoad(ctx context.Context, db *sql.DB, query string, clients int, duration time.Duration) (LoadResult, error) {
+	if clients < 1 {
+		return LoadResult{}, fmt.Errorf("clients %d is not positive", clients)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	before := db.Stats()
+	start := time.Now()
+	deadline := start.Add(duration)
+	latencies := make([][]time.Duration, clients)
+	errs := make([]error, clients)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) && ctx.Err() == nil {
+				start := time.Now()
+				if err := drain(ctx, db, query); err != nil {
+					errs[i] = fmt.Errorf("client %d: %w", i, err)
+					cancel()
+					return
+				}
+				latencies[i] = append(latencies[i], time.Since(start))
+			}
+		}()
+	}
+	wg.Wait()
+	res := LoadResult{Clients: clients, Duration: time.Since(start)}
+	if err := errors.Join(errs...); err != nil {
+		return res, err
+	}
+
+	after := db.Stats()
+	res.Waits = after.WaitCount - before.WaitCount
+	res.WaitDuration = after.WaitDuration - before.WaitDuration
+	res.Closed = after.MaxIdleClosed + after.MaxIdleTimeClosed + after.MaxLifetimeClosed -
+		before.MaxIdleClosed - before.MaxIdleTimeClosed - before.MaxLifetimeClosed
+	var all []time.Duration
+	for _, l := range latencies {
+		all = append(all, l...)
+	}
+	if len(all) == 0 {
+		return res, fmt.Errorf("no query finished within %v", duration)
+	}
+	res.Queries = len(all)
+	res.Latency = SummarizeTimings(all)
+	slices.Sort(all)
+	res.P99 = all[min(len(all)-1, len(all)*99/100)]
+	return res, nil
+}
+
+// drain runs query and reads every row of its result.
+func drain(ctx context.Context, db Queryer, query string) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
@@ -0,0 +1,52 @@
+package duckbench
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestQueryLoad checks that the pool settings take effect: clients beyond MaxOpenConns wait for a connection, and
+// without idle connections every query opens and closes one.
+func TestQueryLoad(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name        string
+		pool        Pool
+		wait, close bool
+	}{
+		{"default", Pool{}, false, false},
+		{"two connections", Pool{MaxOpenConns: 2, MaxIdleConns: 2}, true, false},
+		{"no idle connections", Pool{MaxIdleConns: -1}, false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := CreateDB(ctx, DBOptions{Pool: tc.pool})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			if err := CreateRecordsTable(ctx, db); err != nil {
+				t.Fatal(err)
+			}
+			if err := AppenderInsert(ctx, GenerateRecords(10000), db); err != nil {
+				t.Fatal(err)
+			}
+			res, err := QueryLoad(ctx, db, "SELECT COUNT(*), AVG(value) FROM records", 8, 200*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Queries < 8 {
+				t.Errorf("%d queries, want at least one per client", res.Queries)
+			}
+			if open := db.Stats().MaxOpenConnections; tc.pool.MaxOpenConns > 0 && open != tc.pool.MaxOpenConns {
+				t.Errorf("MaxOpenConnections = %d, want %d", open, tc.pool.MaxOpenConns)
+			}
+			if (res.Waits > 0) != tc.wait {
+				t.Errorf("%d waits for a connection, want some: %t", res.Waits, tc.wait)
+			}
+			if tc.close && res.Closed < int64(res.Queries) {
+				t.Errorf("%d connections closed after %d queries, want one for each", res.Closed, res.Queries)
+			}
+		})
+	}
+}
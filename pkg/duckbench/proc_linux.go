@@ -0,0 +1,41 @@
+package duckbench
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// readProcess fills in the process-level readings of sample from getrusage and /proc/self. Readings which cannot
+// be taken are left at zero.
+func readProcess(sample *ResourceSample) {
+	var ru syscall.Rusage
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &ru) == nil {
+		sample.CPUTime = time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+	}
+	if b, err := os.ReadFile("/proc/self/statm"); err == nil {
+		var size, resident uint64
+		if _, err := fmt.Sscan(string(b), &size, &resident); err == nil {
+			sample.RSS = resident * uint64(os.Getpagesize())
+		}
+	}
+	if b, err := os.ReadFile("/proc/self/io"); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(b))
+		for scanner.Scan() {
+			var key string
+			var value uint64
+			if _, err := fmt.Sscanf(scanner.Text(), "%s %d", &key, &value); err != nil {
+				continue
+			}
+			switch key {
+			case "read_bytes:":
+				sample.ReadBytes = value
+			case "write_bytes:":
+				sample.WriteBytes = value
+			}
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package duckbench
+
+// readProcess is only implemented on Linux; elsewhere the process-level readings are left at zero.
+func readProcess(*ResourceSample) {}
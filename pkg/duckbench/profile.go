@@ -0,0 +1,123 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// QueryProfile is DuckDB's own profile of a query, as written with enable_profiling = 'json'.
+type QueryProfile struct {
+	Query  string
+	Timing time.Duration
+	// Operators is the operator tree flattened depth first, so Operators[0] is the root.
+	Operators []OperatorProfile
+}
+
+type OperatorProfile struct {
+	Name        string
+	Depth       int
+	Timing      time.Duration
+	Cardinality int64
+	ExtraInfo   string
+}
+
+// profileNode is a node of the profile of DuckDB 1.1, whose root is the query and the rest its operators.
+type profileNode struct {
+	Query       string         `json:"query_name"`
+	Latency     float64        `json:"latency"`
+	Name        string         `json:"operator_type"`
+	Timing      float64        `json:"operator_timing"`
+	Cardinality int64          `json:"operator_cardinality"`
+	ExtraInfo   map[string]any `json:"extra_info"`
+	Children    []profileNode  `json:"children"`
+}
+
+// extraInfo formats the extra information of an operator a line per entry, in order of name, with lists of values
+// such as the aggregates separated by commas.
+func (n profileNode) extraInfo() string {
+	lines := make([]string, 0, len(n.ExtraInfo))
+	for _, k := range slices.Sorted(maps.Keys(n.ExtraInfo)) {
+		v := n.ExtraInfo[k]
+		if list, ok := v.([]any); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			v = strings.Join(items, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("%s: %v", k, v))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ProfileQuery runs fn on a dedicated connection with DuckDB's JSON profiling enabled, returning the profile of the
+// last query fn ran. Profiling settings are per connection, which is why fn must use the Queryer it is given.
+func ProfileQuery(ctx context.Context, db *sql.DB, fn func(context.Context, Queryer) error) (*QueryProfile, error) {
+	dir, err := os.MkdirTemp("", "duckbench-profile")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "profile.json")
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA enable_profiling = 'json'"); err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA profiling_output = '%s'", strings.ReplaceAll(path, "'", "''"))); err != nil {
+		return nil, err
+	}
+	fnErr := fn(ctx, conn)
+	// resetting rather than disabling profiling, whose JSON format would otherwise leak into the EXPLAIN ANALYZE
+	// of the next query on the connection
+	if _, err := conn.ExecContext(ctx, "RESET enable_profiling; RESET profiling_output"); err != nil {
+		return nil, err
+	}
+	if fnErr != nil {
+		return nil, fnErr
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading DuckDB profile: %w", err)
+	}
+	var root profileNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parsing DuckDB profile: %w", err)
+	}
+	p := &QueryProfile{Query: root.Query, Timing: seconds(root.Latency)}
+	var walk func(n profileNode, depth int)
+	walk = func(n profileNode, depth int) {
+		p.Operators = append(p.Operators, OperatorProfile{
+			Name:        strings.TrimSpace(n.Name),
+			Depth:       depth,
+			Timing:      seconds(n.Timing),
+			Cardinality: n.Cardinality,
+			ExtraInfo:   n.extraInfo(),
+		})
+		for _, c := range n.Children {
+			walk(c, depth+1)
+		}
+	}
+	for _, c := range root.Children {
+		walk(c, 0)
+	}
+	return p, nil
+}
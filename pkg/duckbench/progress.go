@@ -0,0 +1,81 @@
+package duckbench
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Progress tracks how far through its current phase a run is, for display while it runs. It is safe for
+// concurrent use.
+type Progress struct {
+	mu    sync.Mutex
+	phase Phase
+	start time.Time
+	done  int64
+	total int64
+}
+
+// ProgressSnapshot is the state of a Progress at one instant. Total is zero for phases which do not report rows.
+type ProgressSnapshot struct {
+	Phase   Phase
+	Elapsed time.Duration
+	Done    int64
+	Total   int64
+}
+
+// Rate returns the rows processed per second so far in the phase.
+func (s ProgressSnapshot) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Done) / s.Elapsed.Seconds()
+}
+
+// ETA estimates the time left in the phase from the rate so far, or returns false if it cannot yet.
+func (s ProgressSnapshot) ETA() (time.Duration, bool) {
+	rate := s.Rate()
+	if s.Total == 0 || rate == 0 {
+		return 0, false
+	}
+	return time.Duration(float64(s.Total-s.Done) / rate * float64(time.Second)), true
+}
+
+func (p *Progress) Snapshot() ProgressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := ProgressSnapshot{Phase: p.phase, Done: p.done, Total: p.total}
+	if !p.start.IsZero() {
+		s.Elapsed = time.Since(p.start)
+	}
+	return s
+}
+
+func (p *Progress) add(n int64) {
+	p.mu.Lock()
+	p.done += n
+	p.mu.Unlock()
+}
+
+// hook returns a phase hook which resets the progress for each phase, expecting total rows in those phases which
+// report them.
+func (p *Progress) hook(total int64) PhaseHook {
+	return func(ctx context.Context, phase Phase) (context.Context, func()) {
+		p.mu.Lock()
+		p.phase, p.start, p.done, p.total = phase, time.Now(), 0, 0
+		if phase == PhaseInsert {
+			p.total = total
+		}
+		p.mu.Unlock()
+		return context.WithValue(ctx, progressKey{}, p), func() {}
+	}
+}
+
+type progressKey struct{}
+
+// reportProgress records n more rows processed against the Progress of the run ctx belongs to, if any.
+func reportProgress(ctx context.Context, n int64) {
+	if p, ok := ctx.Value(progressKey{}).(*Progress); ok {
+		p.add(n)
+	}
+}
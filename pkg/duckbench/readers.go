@@ -0,0 +1,92 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// readQuery is the aggregate each reader of ReadDuringInsert runs in a loop.
+const readQuery = "SELECT COUNT(*), AVG(value) FILTER (WHERE isfinite(value)) FROM records"
+
+// ReadResult is the outcome of ReadDuringInsert.
+type ReadResult struct {
+	Insert  BenchmarkResult
+	Queries int
+	Latency Timing
+	P99     time.Duration
+	// Snapshots are the distinct row counts the readers saw, in increasing order.
+	Snapshots []int64
+}
+
+// ReadDuringInsert inserts records with strategy while readers goroutines run an aggregate over the records table
+// in a loop, timing each query under the write load. DuckDB's MVCC gives each query a snapshot of the committed
+// rows, so a reader sees the rows of a transaction, or of an appender's flush, all at once as they commit, and never
+// fewer rows than its previous query did; ReadDuringInsert returns an error if one does.
+func ReadDuringInsert(ctx context.Context, db *sql.DB, records []Record, strategy InsertStrategy, readers int) (ReadResult, error) {
+	if readers < 1 {
+		return ReadResult{}, fmt.Errorf("readers %d is not positive", readers)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	latencies := make([][]time.Duration, readers)
+	counts := make([][]int64, readers)
+	errs := make([]error, readers+1)
+	var wg sync.WaitGroup
+	for i := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				start := time.Now()
+				var n int64
+				var mean sql.NullFloat64
+				if err := db.QueryRowContext(ctx, readQuery).Scan(&n, &mean); err != nil {
+					errs[i] = fmt.Errorf("reader %d: %w", i, err)
+					cancel()
+					return
+				}
+				latencies[i] = append(latencies[i], time.Since(start))
+				if last := len(counts[i]) - 1; last >= 0 && n < counts[i][last] {
+					errs[i] = fmt.Errorf("reader %d saw %d rows after %d", i, n, counts[i][last])
+					cancel()
+					return
+				}
+				counts[i] = append(counts[i], n)
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		}()
+	}
+	insert, err := Measure(strategy.Name(), len(records), func() error {
+		return strategy.Insert(ctx, records, db)
+	})
+	errs[readers] = err
+	close(done)
+	wg.Wait()
+	res := ReadResult{Insert: insert}
+	if err := errors.Join(errs...); err != nil {
+		return res, err
+	}
+
+	var all []time.Duration
+	for i := range readers {
+		all = append(all, latencies[i]...)
+		res.Snapshots = append(res.Snapshots, counts[i]...)
+	}
+	slices.Sort(res.Snapshots)
+	res.Snapshots = slices.Compact(res.Snapshots)
+	res.Queries = len(all)
+	res.Latency = SummarizeTimings(all)
+	slices.Sort(all)
+	res.P99 = all[min(len(all)-1, len(all)*99/100)]
+	return res, nil
+}
@@ -0,0 +1,117 @@
+//go:build unix
+
+package duckbench
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+const (
+	recoveryBatch   = 100
+	recoveryBatches = 1000
+	// recoveryPathEnv tells the test binary, re-run as a child, to ingest into this database file until killed.
+	recoveryPathEnv = "DUCKBENCH_RECOVERY_DB"
+)
+
+// recoveryOptions keeps committed data in the WAL rather than checkpointing it into the database file, so
+// reopening after the crash has to replay it.
+func recoveryOptions(path string) DBOptions {
+	return DBOptions{Path: path, OnConnect: []string{"SET checkpoint_threshold = '1GB'"}}
+}
+
+// TestRecoveryChild is the child process of TestCrashRecovery. It commits batches of records, reporting each on
+// stdout once its commit returns.
+func TestRecoveryChild(t *testing.T) {
+	path := os.Getenv(recoveryPathEnv)
+	if path == "" {
+		t.Skip("only run as the child of TestCrashRecovery")
+	}
+	ctx := context.Background()
+	db, err := CreateDB(ctx, recoveryOptions(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateRecordsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	records := GenerateRecords(recoveryBatch * recoveryBatches)
+	for b := 0; b < recoveryBatches; b++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range records[b*recoveryBatch : (b+1)*recoveryBatch] {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO records (value, value2) VALUES (?, ?)", r.Value, r.Value2); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Printf("committed %d\n", b+1)
+	}
+}
+
+// TestCrashRecovery kills a process part way through ingesting into a database file, then checks reopening it
+// recovers every batch which was committed, and no partial batch.
+func TestCrashRecovery(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns a child process")
+	}
+	path := filepath.Join(t.TempDir(), "recovery.duckdb")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRecoveryChild$", "-test.v")
+	cmd.Env = append(os.Environ(), recoveryPathEnv+"="+path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	const killAfter = 20
+	committed := 0
+	scanner := bufio.NewScanner(stdout)
+	for committed < killAfter && scanner.Scan() {
+		if n, ok := strings.CutPrefix(scanner.Text(), "committed "); ok {
+			committed, _ = strconv.Atoi(n)
+		}
+	}
+	if err := cmd.Process.Signal(syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+	if committed < killAfter {
+		t.Fatalf("child exited after committing %d batches", committed)
+	}
+	if _, err := os.Stat(path + ".wal"); err != nil {
+		t.Fatalf("no WAL left to recover from: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := CreateDB(ctx, DBOptions{Path: path})
+	if err != nil {
+		t.Fatalf("reopening after crash: %v", err)
+	}
+	defer db.Close()
+	var rows int
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM records").Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	// batches committed after the last one reported are fine, but partial ones are not
+	if rows < committed*recoveryBatch || rows%recoveryBatch != 0 {
+		t.Fatalf("recovered %d rows, want a whole number of batches of %d and at least %d", rows, recoveryBatch, committed*recoveryBatch)
+	}
+	if err := VerifyIngestion(ctx, db, GenerateRecords(rows)); err != nil {
+		t.Fatal(err)
+	}
+}
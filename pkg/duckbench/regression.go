@@ -0,0 +1,113 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+// Regression is the least squares fit of Value2 on Value, over the records whose values are both finite.
+type Regression struct{}
+
+// RegressionResult is the result of Regression: the line Value2 = Slope*Value + Intercept, and the fraction of the
+// variance of Value2 it explains, R2. The line passes through the means of the values, MeanX and MeanY.
+type RegressionResult struct {
+	Slope, Intercept, R2 float64
+	MeanX, MeanY         float64
+}
+
+func (r RegressionResult) LogValue() slog.Value {
+	return slog.GroupValue(slog.Float64("slope", r.Slope), slog.Float64("intercept", r.Intercept), slog.Float64("r2", r.R2))
+}
+
+func (Regression) Name() string { return "regression" }
+
+// Go makes two passes like Correlation. It follows DuckDB where the fit is undefined: with a constant Value there
+// is no slope, and with a constant Value2 the fit is perfect.
+func (Regression) Go(records []Record) (WorkloadResult, error) {
+	var n int
+	var sumX, sumY float64
+	for _, r := range records {
+		if finite(r.Value) && finite(r.Value2) {
+			n++
+			sumX += r.Value
+			sumY += r.Value2
+		}
+	}
+	if n == 0 {
+		return nil, ErrNoValues
+	}
+	meanX, meanY := sumX/float64(n), sumY/float64(n)
+	var xy, xx, yy float64
+	for _, r := range records {
+		if finite(r.Value) && finite(r.Value2) {
+			dx, dy := r.Value-meanX, r.Value2-meanY
+			xy += dx * dy
+			xx += dx * dx
+			yy += dy * dy
+		}
+	}
+	if xx == 0 {
+		return RegressionResult{Slope: math.NaN(), Intercept: math.NaN(), R2: math.NaN(), MeanX: meanX, MeanY: meanY}, nil
+	}
+	res := RegressionResult{Slope: xy / xx, R2: 1, MeanX: meanX, MeanY: meanY}
+	res.Intercept = meanY - res.Slope*meanX
+	if yy != 0 {
+		res.R2 = xy * xy / (xx * yy)
+	}
+	return res, nil
+}
+
+type regressionRow struct {
+	N         int64                      `db:"n"`
+	Slope     nullable.Nullable[float64] `db:"slope"`
+	Intercept nullable.Nullable[float64] `db:"intercept"`
+	R2        nullable.Nullable[float64] `db:"r2"`
+	MeanX     nullable.Nullable[float64] `db:"mean_x"`
+	MeanY     nullable.Nullable[float64] `db:"mean_y"`
+}
+
+func (Regression) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	row, err := sqlscan.One[regressionRow](ctx, db, `
+		SELECT regr_count(value2, value) AS n, regr_slope(value2, value) AS slope,
+			regr_intercept(value2, value) AS intercept, regr_r2(value2, value) AS r2,
+			regr_avgx(value2, value) AS mean_x, regr_avgy(value2, value) AS mean_y
+		FROM records
+		WHERE isfinite(value) AND isfinite(value2)`)
+	if err != nil {
+		return nil, fmt.Errorf("querying regression: %w", err)
+	}
+	if row.N == 0 {
+		return nil, ErrNoValues
+	}
+	// each is NULL rather than NaN when the fit is undefined
+	return RegressionResult{
+		Slope: row.Slope.Or(math.NaN()), Intercept: row.Intercept.Or(math.NaN()), R2: row.R2.Or(math.NaN()),
+		MeanX: row.MeanX.V, MeanY: row.MeanY.V,
+	}, nil
+}
+
+func (r RegressionResult) Compare(other WorkloadResult, tol floatcmp.Tolerance) []Mismatch {
+	o, ok := other.(RegressionResult)
+	if !ok {
+		return []Mismatch{{Stat: "type"}}
+	}
+	var mismatches []Mismatch
+	if !tol.Equal(r.Slope, o.Slope) {
+		mismatches = append(mismatches, Mismatch{Stat: "slope", A: r.Slope, B: o.Slope})
+	}
+	// the intercept is MeanY - Slope*MeanX, so it is only as exact as the larger of those, however close to zero it is
+	scale := max(math.Abs(r.MeanY), math.Abs(r.Slope*r.MeanX), math.Abs(o.MeanY), math.Abs(o.Slope*o.MeanX))
+	if !tol.Equal(r.Intercept, o.Intercept) && !(floatcmp.AbsDiff(r.Intercept, o.Intercept) <= tol.Rel*scale) {
+		mismatches = append(mismatches, Mismatch{Stat: "intercept", A: r.Intercept, B: o.Intercept})
+	}
+	if !tol.Equal(r.R2, o.R2) {
+		mismatches = append(mismatches, Mismatch{Stat: "r2", A: r.R2, B: o.R2})
+	}
+	return mismatches
+}
@@ -0,0 +1,44 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestRegression(t *testing.T) {
+	ctx := context.Background()
+	nan := math.NaN()
+	tests := map[string]struct {
+		records []Record
+		want    RegressionResult
+	}{
+		"line":            {pairsOf([2]float64{0, 1}, [2]float64{1, 3}, [2]float64{2, 5}), RegressionResult{Slope: 2, Intercept: 1, R2: 1}},
+		"scatter":         {pairsOf([2]float64{0, 0}, [2]float64{1, 2}, [2]float64{2, 1}), RegressionResult{Slope: 0.5, Intercept: 0.5, R2: 0.25}},
+		"non-finite":      {pairsOf([2]float64{0, 1}, [2]float64{nan, 3}, [2]float64{1, math.Inf(1)}, [2]float64{1, 3}), RegressionResult{Slope: 2, Intercept: 1, R2: 1}},
+		"constant value2": {pairsOf([2]float64{1, 4}, [2]float64{2, 4}), RegressionResult{Slope: 0, Intercept: 4, R2: 1}},
+		"constant value":  {pairsOf([2]float64{3, 1}, [2]float64{3, 2}), RegressionResult{Slope: nan, Intercept: nan, R2: nan}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := loadDB(t, tt.records)
+			for engine, run := range map[string]func() (WorkloadResult, error){
+				"go":     func() (WorkloadResult, error) { return Regression{}.Go(tt.records) },
+				"duckdb": func() (WorkloadResult, error) { return Regression{}.DuckDB(ctx, db) },
+			} {
+				got, err := run()
+				if err != nil {
+					t.Fatalf("%s: %v", engine, err)
+				}
+				if m := tt.want.Compare(got, floatcmp.Tolerance{Rel: 1e-12, Abs: 1e-15}); len(m) > 0 {
+					t.Errorf("%s: %+v, mismatches %+v", engine, got, m)
+				}
+			}
+		})
+	}
+	if _, err := (Regression{}).DuckDB(ctx, loadDB(t, nil)); err != ErrNoValues {
+		t.Errorf("empty table: err = %v, want ErrNoValues", err)
+	}
+}
@@ -0,0 +1,118 @@
+package duckbench
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/schema"
+)
+
+// Report is the machine-readable form of Results, for CI jobs and plotting scripts to consume as JSON.
+type Report struct {
+	Started time.Time         `json:"started"`
+	Rows    int               `json:"rows"`
+	Inserts []BenchmarkResult `json:"inserts"`
+	// CheckpointSeconds is only set for an on-disk database.
+	CheckpointSeconds float64 `json:"checkpoint_seconds,omitempty"`
+	// Statistics are the timings of the Go and DuckDB engines and then Results.Engines, with the statistics each
+	// calculated.
+	Statistics []BenchmarkResult `json:"statistics"`
+	Workloads  []WorkloadReport  `json:"workloads,omitempty"`
+	// Memory is the memory used in each phase.
+	Memory map[Phase]MemoryUsage `json:"memory,omitempty"`
+	// Tables are the tables of the database the run left behind, with their columns and estimated row counts.
+	Tables []schema.Table `json:"tables,omitempty"`
+}
+
+// WorkloadReport is the timings of both engines running one of Results.Workloads.
+type WorkloadReport struct {
+	Name   string          `json:"name"`
+	Go     BenchmarkResult `json:"go"`
+	DuckDB BenchmarkResult `json:"duckdb"`
+	// GoError and DuckDBError are the Error of each engine's estimate, for an Approximation.
+	GoError     float64 `json:"go_error,omitempty"`
+	DuckDBError float64 `json:"duckdb_error,omitempty"`
+}
+
+func (r Results) Report() Report {
+	goStats, dbStats := r.GoStats, r.DBStats
+	var workloads []WorkloadReport
+	for _, w := range r.Workloads {
+		workloads = append(workloads, WorkloadReport{Name: w.Name, Go: w.Go, DuckDB: w.DuckDB, GoError: w.GoError, DuckDBError: w.DBError})
+	}
+	return Report{
+		Started:           r.Started,
+		Rows:              r.N,
+		Inserts:           r.Inserts,
+		CheckpointSeconds: r.CheckpointDuration.Seconds(),
+		Statistics: append([]BenchmarkResult{
+			{Name: "go", Rows: r.N, Duration: r.GoDuration, Samples: r.GoSamples, Stats: &goStats},
+			{Name: "duckdb", Rows: r.N, Duration: r.DBDuration, Samples: r.DBSamples, Stats: &dbStats},
+		}, r.Engines...),
+		Workloads: workloads,
+		Memory:    r.Memory,
+		Tables:    r.Tables,
+	}
+}
+
+func (r BenchmarkResult) MarshalJSON() ([]byte, error) {
+	type timing struct {
+		Samples       int     `json:"samples"`
+		MedianSeconds float64 `json:"median_seconds"`
+		StdDevSeconds float64 `json:"stddev_seconds"`
+		MinSeconds    float64 `json:"min_seconds"`
+		MaxSeconds    float64 `json:"max_seconds"`
+	}
+	var repeats *timing
+	if len(r.Samples) > 0 {
+		t := r.Timing()
+		repeats = &timing{len(r.Samples), t.Median.Seconds(), t.StdDev.Seconds(), t.Min.Seconds(), t.Max.Seconds()}
+	}
+	return json.Marshal(struct {
+		Strategy        string    `json:"strategy"`
+		Rows            int       `json:"rows"`
+		DurationSeconds float64   `json:"duration_seconds"`
+		RowsPerSecond   jsonFloat `json:"rows_per_sec"`
+		Repeats         *timing   `json:"repeats,omitempty"`
+		Stats           *Stats    `json:"stats,omitempty"`
+	}{r.Name, r.Rows, r.Duration.Seconds(), jsonFloat(r.RowsPerSecond()), repeats, r.Stats})
+}
+
+func (s Stats) MarshalJSON() ([]byte, error) {
+	var percentiles map[string]jsonFloat
+	if len(s.Percentiles) > 0 {
+		percentiles = make(map[string]jsonFloat, len(s.Percentiles))
+		for _, p := range s.Percentiles {
+			percentiles[p.Name()] = jsonFloat(p.Value)
+		}
+	}
+	return json.Marshal(struct {
+		Mean        jsonFloat            `json:"mean"`
+		Median      jsonFloat            `json:"median"`
+		StdDev      jsonFloat            `json:"stddev"`
+		Min         jsonFloat            `json:"min"`
+		Max         jsonFloat            `json:"max"`
+		NonFinite   int64                `json:"non_finite"`
+		Nulls       int64                `json:"nulls,omitempty"`
+		Percentiles map[string]jsonFloat `json:"percentiles,omitempty"`
+	}{jsonFloat(s.Mean), jsonFloat(s.Median), jsonFloat(s.StdDev), jsonFloat(s.Min), jsonFloat(s.Max), s.NonFinite,
+		s.Nulls, percentiles})
+}
+
+// jsonFloat encodes the non-finite values JSON has no numbers for as strings, as the audit log does, e.g. a mean
+// which overflowed to infinity.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	switch v := float64(f); {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Infinity"`), nil
+	default:
+		return json.Marshal(v)
+	}
+}
@@ -0,0 +1,127 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// RollingStat is the mean and population standard deviation of the window of records ending at one record.
+type RollingStat struct {
+	Mean, StdDev float64
+}
+
+func checkWindow(window int) error {
+	if window < 1 {
+		return fmt.Errorf("rolling window must be at least one row, not %d", window)
+	}
+	return nil
+}
+
+// RollingStatistics returns the rolling statistics of each record's Value over the window of up to window records
+// ending at it. The window is a ring buffer, which a Welford accumulator slides along by adding each value as it
+// enters and removing it again as it leaves, so a record costs the same whatever the window. Removing a value
+// cannot undo a NaN or an infinity, so the values must be finite.
+//
+// Removing a value from the sum of squares leaves behind the rounding of its share, which is most of what remains
+// once a large outlier leaves or the window's remaining values are nearly equal, and which would build up over a
+// long run. So the accumulator is recalculated from the ring once per window of records, and after removing a share
+// much larger than what remains.
+func RollingStatistics(records []Record, window int) ([]RollingStat, error) {
+	if err := checkWindow(window); err != nil {
+		return nil, err
+	}
+	ring := make([]float64, window)
+	var n int
+	var mean, m2 float64
+	stats := make([]RollingStat, len(records))
+	for i, r := range records {
+		if !finite(r.Value) {
+			return nil, fmt.Errorf("record %d is %g: rolling statistics need finite values", i, r.Value)
+		}
+		recalculate := i%window == window-1
+		if i >= window {
+			old := ring[i%window]
+			n--
+			delta := old - mean
+			mean -= delta / float64(n)
+			share := delta * (old - mean)
+			m2 -= share
+			recalculate = recalculate || share > rollingCancellation*m2
+		}
+		ring[i%window] = r.Value
+		n++
+		delta := r.Value - mean
+		mean += delta / float64(n)
+		m2 += delta * (r.Value - mean)
+		if recalculate {
+			mean, m2 = twoPass(ring[:n])
+		}
+		stats[i] = RollingStat{Mean: mean, StdDev: math.Sqrt(m2 / float64(n))}
+	}
+	return stats, nil
+}
+
+// rollingCancellation is how many times larger than the remaining sum of squares a removed share may be before
+// its rounding, relative to the remainder, could exceed about 1e-13.
+const rollingCancellation = 1e3
+
+// twoPass returns the mean of values and the sum of their squared deviations from it.
+func twoPass(values []float64) (mean, m2 float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		m2 += (v - mean) * (v - mean)
+	}
+	return mean, m2
+}
+
+// RollingStatisticsFromDB calculates the same with window functions over the records table in id order.
+func RollingStatisticsFromDB(ctx context.Context, db Queryer, window int) ([]RollingStat, error) {
+	if err := checkWindow(window); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT AVG(value) OVER w, STDDEV_POP(value) OVER w
+		FROM records
+		WINDOW w AS (ORDER BY id ROWS BETWEEN %d PRECEDING AND CURRENT ROW)
+		ORDER BY id`, window-1))
+	if err != nil {
+		return nil, fmt.Errorf("querying rolling statistics: %w", err)
+	}
+	defer rows.Close()
+	var stats []RollingStat
+	for rows.Next() {
+		var s RollingStat
+		if err := rows.Scan(&s.Mean, &s.StdDev); err != nil {
+			return nil, fmt.Errorf("scanning rolling statistics: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading rolling statistics: %w", err)
+	}
+	return stats, nil
+}
+
+// CompareRolling returns the rolling statistics on which a and b are not equal within tol, the first max of them
+// if there are more.
+func CompareRolling(a, b []RollingStat, tol floatcmp.Tolerance, max int) []Mismatch {
+	if len(a) != len(b) {
+		return []Mismatch{{Stat: "rows", A: float64(len(a)), B: float64(len(b))}}
+	}
+	var mismatches []Mismatch
+	for i := 0; i < len(a) && len(mismatches) < max; i++ {
+		if !tol.Equal(a[i].Mean, b[i].Mean) {
+			mismatches = append(mismatches, Mismatch{Stat: fmt.Sprintf("mean of row %d", i), A: a[i].Mean, B: b[i].Mean})
+		}
+		if !tol.Equal(a[i].StdDev, b[i].StdDev) {
+			mismatches = append(mismatches, Mismatch{Stat: fmt.Sprintf("stddev of row %d", i), A: a[i].StdDev, B: b[i].StdDev})
+		}
+	}
+	return mismatches[:min(len(mismatches), max)]
+}
@@ -0,0 +1,66 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestRollingStatistics(t *testing.T) {
+	records := recordsOf(1, 3, 5, 5, 5, 2)
+	want := []RollingStat{{1, 0}, {2, 1}, {3, math.Sqrt(8.0 / 3)}, {13.0 / 3, math.Sqrt(8.0 / 9)}, {5, 0}, {4, math.Sqrt(2)}}
+	got, err := RollingStatistics(records, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := CompareRolling(want, got, floatcmp.Rel(1e-12), 10); len(m) > 0 {
+		t.Errorf("go: %+v", m)
+	}
+	db := loadDB(t, records)
+	got, err = RollingStatisticsFromDB(context.Background(), db, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a window of equal values may be a hair from a stddev of 0
+	if m := CompareRolling(want, got, floatcmp.Tolerance{Rel: 1e-12, Abs: 1e-12}, 10); len(m) > 0 {
+		t.Errorf("duckdb: %+v", m)
+	}
+	if _, err := RollingStatistics(recordsOf(1, math.NaN()), 3); err == nil {
+		t.Error("NaN accepted")
+	}
+}
+
+func TestRollingStatisticsAgree(t *testing.T) {
+	for _, dist := range []Distribution{DistSequential, DistNormal, DistExponential} {
+		records, err := GenerateDistribution(100000, dist, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		goStats, err := RollingStatistics(records, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		db, err := CreateDB(ctx, DBOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if err := CreateRecordsTable(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+		if err := AppenderInsert(ctx, records, db); err != nil {
+			t.Fatal(err)
+		}
+		dbStats, err := RollingStatisticsFromDB(ctx, db, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the mean of a window can be near zero when its values are not
+		if m := CompareRolling(goStats, dbStats, floatcmp.Tolerance{Rel: 1e-9, Abs: 1e-12}, 5); len(m) > 0 {
+			t.Errorf("%s: %+v", dist, m)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/metrics"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// ResourceSample is a point-in-time reading of Go runtime and DuckDB resource usage, taken during a phase.
+type ResourceSample struct {
+	Time        time.Time
+	Phase       Phase
+	GoHeapAlloc uint64
+	GoHeapSys   uint64
+	// GoTotalAlloc is cumulative, as is GoNumGC.
+	GoTotalAlloc uint64
+	GoNumGC      uint32
+	Goroutines   int
+	// DuckDBMemory and DuckDBTempStorage are summed over the tags reported by duckdb_memory().
+	DuckDBMemory      int64
+	DuckDBTempStorage int64
+	// DatabaseSize is the bytes of blocks in use, from PRAGMA database_size.
+	DatabaseSize int64
+	// CPUTime, ReadBytes and WriteBytes are cumulative for the process; they and RSS are only read on Linux.
+	CPUTime    time.Duration
+	RSS        uint64
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// sampler reads resource usage on an interval for the duration of a run, mirroring each reading into the gauges
+// of a registry when one is configured. It queries through the pool rather than holding a connection of its own,
+// which a run with as few as one open connection needs for its workload, so a reading may wait for one.
+type sampler struct {
+	db       *sql.DB
+	registry *metrics.Registry
+
+	mu      sync.Mutex
+	phase   Phase
+	samples []ResourceSample
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+func startSampler(ctx context.Context, db *sql.DB, interval time.Duration, registry *metrics.Registry) *sampler {
+	s := &sampler{db: db, registry: registry, stopCh: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.sample(ctx)
+			select {
+			case <-ticker.C:
+			case <-s.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// hook records the current phase against subsequent samples, taking a sample as each phase starts and ends so
+// short phases are not missed.
+func (s *sampler) hook(ctx context.Context, phase Phase) (context.Context, func()) {
+	s.mu.Lock()
+	s.phase = phase
+	s.mu.Unlock()
+	s.sample(ctx)
+	return ctx, func() { s.sample(ctx) }
+}
+
+func (s *sampler) sample(ctx context.Context) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	sample := ResourceSample{
+		Time:         time.Now(),
+		GoHeapAlloc:  ms.HeapAlloc,
+		GoHeapSys:    ms.HeapSys,
+		GoTotalAlloc: ms.TotalAlloc,
+		GoNumGC:      ms.NumGC,
+		Goroutines:   runtime.NumGoroutine(),
+	}
+	readProcess(&sample)
+	// the sampler must not fail the run, so a failed query just leaves the DuckDB readings at zero
+	s.db.QueryRowContext(sqlwrap.Quiet(ctx), `
+		SELECT sum(memory_usage_bytes)::BIGINT, sum(temporary_storage_bytes)::BIGINT,
+			(SELECT used_blocks * block_size FROM pragma_database_size() LIMIT 1)
+		FROM duckdb_memory()
+	`).Scan(&sample.DuckDBMemory, &sample.DuckDBTempStorage, &sample.DatabaseSize)
+
+	s.mu.Lock()
+	sample.Phase = s.phase
+	s.samples = append(s.samples, sample)
+	s.mu.Unlock()
+
+	if r := s.registry; r != nil {
+		r.Gauge("go_heap_alloc_bytes", "Bytes of allocated heap objects.", nil).Set(float64(sample.GoHeapAlloc))
+		r.Gauge("go_heap_sys_bytes", "Bytes of heap memory obtained from the OS.", nil).Set(float64(sample.GoHeapSys))
+		r.Gauge("go_gc_cycles", "Completed GC cycles.", nil).Set(float64(sample.GoNumGC))
+		r.Gauge("go_goroutines", "Number of goroutines.", nil).Set(float64(sample.Goroutines))
+		r.Gauge("duckdb_memory_bytes", "Memory used by DuckDB, from duckdb_memory().", nil).Set(float64(sample.DuckDBMemory))
+		r.Gauge("duckdb_temporary_storage_bytes", "Temporary storage used by DuckDB, from duckdb_memory().", nil).Set(float64(sample.DuckDBTempStorage))
+		r.Gauge("process_cpu_seconds", "User and system CPU time used by the process.", nil).Set(sample.CPUTime.Seconds())
+		r.Gauge("process_resident_memory_bytes", "Resident set size of the process.", nil).Set(float64(sample.RSS))
+		r.Gauge("process_read_bytes", "Bytes the process has caused to be read from storage.", nil).Set(float64(sample.ReadBytes))
+		r.Gauge("process_write_bytes", "Bytes the process has caused to be written to storage.", nil).Set(float64(sample.WriteBytes))
+	}
+}
+
+// stop ends sampling and returns every sample taken.
+func (s *sampler) stop() []ResourceSample {
+	close(s.stopCh)
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.samples
+}
+
+// MemoryPeak is the highest memory usage sampled during a phase. Peaks between samples are missed, so the sample
+// interval bounds how sharp a spike can be and still be seen.
+type MemoryPeak struct {
+	GoHeapAlloc       uint64
+	DuckDBMemory      int64
+	DuckDBTempStorage int64
+}
+
+// PeakMemory returns the high-water marks of samples by phase.
+func PeakMemory(samples []ResourceSample) map[Phase]MemoryPeak {
+	peaks := make(map[Phase]MemoryPeak)
+	for _, sample := range samples {
+		if sample.Phase == "" {
+			continue
+		}
+		p := peaks[sample.Phase]
+		p.GoHeapAlloc = max(p.GoHeapAlloc, sample.GoHeapAlloc)
+		p.DuckDBMemory = max(p.DuckDBMemory, sample.DuckDBMemory)
+		p.DuckDBTempStorage = max(p.DuckDBTempStorage, sample.DuckDBTempStorage)
+		peaks[sample.Phase] = p
+	}
+	return peaks
+}
+
+// ProcessUsage is the CPU and IO used by the process over a phase, from the samples taken as it started and ended.
+type ProcessUsage struct {
+	Wall       time.Duration
+	CPUTime    time.Duration
+	PeakRSS    uint64
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// CPUPercent returns CPU time as a percentage of wall time; it exceeds 100 when more than one core was busy. A
+// phase well below 100 spent much of its time waiting, e.g. on IO.
+func (u ProcessUsage) CPUPercent() float64 {
+	if u.Wall <= 0 {
+		return 0
+	}
+	return 100 * u.CPUTime.Seconds() / u.Wall.Seconds()
+}
+
+// ProcessUsageByPhase returns the process usage of samples by phase.
+func ProcessUsageByPhase(samples []ResourceSample) map[Phase]ProcessUsage {
+	first := make(map[Phase]ResourceSample)
+	usage := make(map[Phase]ProcessUsage)
+	for _, sample := range samples {
+		if sample.Phase == "" {
+			continue
+		}
+		start, ok := first[sample.Phase]
+		if !ok {
+			first[sample.Phase] = sample
+			start = sample
+		}
+		u := usage[sample.Phase]
+		u.Wall = sample.Time.Sub(start.Time)
+		u.CPUTime = sample.CPUTime - start.CPUTime
+		u.PeakRSS = max(u.PeakRSS, sample.RSS)
+		u.ReadBytes = sample.ReadBytes - start.ReadBytes
+		u.WriteBytes = sample.WriteBytes - start.WriteBytes
+		usage[sample.Phase] = u
+	}
+	return usage
+}
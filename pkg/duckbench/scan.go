@@ -0,0 +1,194 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+// ScanMethod is a way of reading the whole records table back out of DuckDB into Records, the opposite direction
+// to the aggregates, which return a row or a few.
+type ScanMethod string
+
+const (
+	// ScanVariables scans each row into a variable per column and appends the Record made of them.
+	ScanVariables ScanMethod = "variables"
+	// ScanPreallocated counts the rows first and scans each straight into the fields of a slice allocated once.
+	ScanPreallocated ScanMethod = "preallocated"
+	// ScanStruct scans each row into a struct by column name with pkg/sqlscan.
+	ScanStruct ScanMethod = "struct"
+	// ScanArrow fetches Arrow record batches through go-duckdb's Arrow interface, copying out a batch of columns at
+	// a time without database/sql converting every value.
+	ScanArrow ScanMethod = "arrow"
+)
+
+// ScanMethods lists the supported scan methods.
+var ScanMethods = []ScanMethod{ScanVariables, ScanPreallocated, ScanStruct, ScanArrow}
+
+func (m *ScanMethod) UnmarshalText(text []byte) error {
+	for _, method := range ScanMethods {
+		if string(text) == string(method) {
+			*m = method
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown scan method %q, expected one of %v", text, ScanMethods)
+}
+
+// ReadRecords reads every row of the records table into a Record with method. The rows come back in no
+// particular order.
+func ReadRecords(ctx context.Context, db *sql.DB, method ScanMethod) ([]Record, error) {
+	switch method {
+	case ScanPreallocated:
+		return readPreallocated(ctx, db)
+	case ScanStruct:
+		return readStructs(ctx, db)
+	case ScanArrow:
+		return readArrow(ctx, db)
+	default:
+		return readVariables(ctx, db)
+	}
+}
+
+// newRecord makes a Record of the nullable columns of a row.
+func newRecord(id int, value nullable.Nullable[float64], value2 float64, category string, ts nullable.Nullable[time.Time]) Record {
+	return Record{ID: id, Value: value.Or(math.NaN()), Value2: value2, Category: category, Time: ts.V, Null: !value.Valid}
+}
+
+func readVariables(ctx context.Context, db Queryer) ([]Record, error) {
+	rows, err := db.QueryContext(ctx, scanQuery)
+	if err != nil {
+		return nil, fmt.Errorf("querying records: %w", err)
+	}
+	defer rows.Close()
+	var (
+		records  []Record
+		id       int
+		value    nullable.Nullable[float64]
+		value2   float64
+		category string
+		ts       nullable.Nullable[time.Time]
+	)
+	for rows.Next() {
+		if err := rows.Scan(&id, &value, &value2, &category, &ts); err != nil {
+			return nil, fmt.Errorf("scanning record %d: %w", len(records), err)
+		}
+		records = append(records, newRecord(id, value, value2, category, ts))
+	}
+	return records, rows.Err()
+}
+
+func readPreallocated(ctx context.Context, db *sql.DB) ([]Record, error) {
+	// the count and the rows must come from the same snapshot of the table; DuckDB has no read-only transactions
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+	var n int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM records").Scan(&n); err != nil {
+		return nil, fmt.Errorf("counting records: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, scanQuery)
+	if err != nil {
+		return nil, fmt.Errorf("querying records: %w", err)
+	}
+	defer rows.Close()
+	records := make([]Record, n)
+	var value nullable.Nullable[float64]
+	var ts nullable.Nullable[time.Time]
+	i := 0
+	for ; rows.Next(); i++ {
+		if i == n {
+			return nil, fmt.Errorf("more than the %d records counted", n)
+		}
+		r := &records[i]
+		if err := rows.Scan(&r.ID, &value, &r.Value2, &r.Category, &ts); err != nil {
+			return nil, fmt.Errorf("scanning record %d: %w", i, err)
+		}
+		r.Value, r.Null, r.Time = value.Or(math.NaN()), !value.Valid, ts.V
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return records[:i], nil
+}
+
+// recordRow is a row of the records table for sqlscan.
+type recordRow struct {
+	ID       int                          `db:"id"`
+	Value    nullable.Nullable[float64]   `db:"value"`
+	Value2   float64                      `db:"value2"`
+	Category string                       `db:"category"`
+	Time     nullable.Nullable[time.Time] `db:"ts"`
+}
+
+func readStructs(ctx context.Context, db Queryer) ([]Record, error) {
+	rows, err := sqlscan.All[recordRow](ctx, db, scanQuery)
+	if err != nil {
+		return nil, fmt.Errorf("scanning records: %w", err)
+	}
+	records := make([]Record, len(rows))
+	for i, r := range rows {
+		records[i] = newRecord(r.ID, r.Value, r.Value2, r.Category, r.Time)
+	}
+	return records, nil
+}
+
+func readArrow(ctx context.Context, db *sql.DB) ([]Record, error) {
+	var records []Record
+	err := rawConn(ctx, db, func(conn driver.Conn) error {
+		a, err := duckdb.NewArrowFromConn(conn)
+		if err != nil {
+			return fmt.Errorf("opening Arrow interface: %w", err)
+		}
+		reader, err := a.QueryContext(ctx, scanQuery)
+		if err != nil {
+			return fmt.Errorf("querying records: %w", err)
+		}
+		defer reader.Release()
+		for reader.Next() {
+			if records, err = appendBatch(records, reader.Record()); err != nil {
+				return err
+			}
+		}
+		return reader.Err()
+	})
+	return records, err
+}
+
+// appendBatch appends the rows of an Arrow record batch of scanQuery to records.
+func appendBatch(records []Record, batch arrow.Record) ([]Record, error) {
+	id, idOK := batch.Column(0).(*array.Int32)
+	value, valueOK := batch.Column(1).(*array.Float64)
+	value2, value2OK := batch.Column(2).(*array.Float64)
+	category, categoryOK := batch.Column(3).(*array.String)
+	ts, tsOK := batch.Column(4).(*array.Timestamp)
+	if !idOK || !valueOK || !value2OK || !categoryOK || !tsOK {
+		return nil, fmt.Errorf("record batch has columns %v, not those of the records table", batch.Schema())
+	}
+	unit := ts.DataType().(*arrow.TimestampType).Unit
+	for i := range int(batch.NumRows()) {
+		// the strings of an Arrow array point into its buffers, which are reused once the batch is released
+		r := Record{ID: int(id.Value(i)), Value: value.Value(i), Value2: value2.Value(i), Category: strings.Clone(category.Value(i))}
+		if value.IsNull(i) {
+			r.Value, r.Null = math.NaN(), true
+		}
+		if ts.IsValid(i) {
+			r.Time = ts.Value(i).ToTime(unit)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
@@ -0,0 +1,28 @@
+package duckbench
+
+import (
+	"context"
+	"testing"
+)
+
+// TestReadRecords checks that every scan method reads back exactly the records inserted, NULLs and timestamps
+// included, across more than one Arrow record batch.
+func TestReadRecords(t *testing.T) {
+	ctx := context.Background()
+	records, _ := GenerateDistribution(5000, DistTimeSeries, 1)
+	AssignCategories(records, 5, 1)
+	InjectNulls(records, 0.1, 1)
+	db := loadDB(t, nil)
+	if err := AppenderInsert(ctx, records, db); err != nil {
+		t.Fatal(err)
+	}
+	want := ChecksumRecords(records)
+	for _, method := range ScanMethods {
+		got, err := ReadRecords(ctx, db, method)
+		if err != nil {
+			t.Errorf("%s: %v", method, err)
+		} else if sum := ChecksumRecords(got); sum != want {
+			t.Errorf("%s: read %d records with checksum %+v, want %d with %+v", method, len(got), sum, len(records), want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+// Stats are the summary statistics calculated by each engine. Both engines skip NaN and infinite values, which
+// would otherwise poison every statistic (and which DuckDB's STDDEV_POP rejects outright), counting them in
+// NonFinite instead. NULL values are skipped by the aggregates and counted in Nulls.
+type Stats struct {
+	Mean, Median, StdDev, Min, Max float64
+	NonFinite, Nulls               int64
+	// Percentiles are those requested of the engine, in the order requested.
+	Percentiles []Percentile
+}
+
+// DefaultPercentiles are the percentiles calculated when Config.Percentiles is unset.
+var DefaultPercentiles = []float64{0.25, 0.75, 0.95, 0.99}
+
+// Percentile is the value below which a fraction P of the finite values lie, interpolated linearly between the
+// closest ranks as DuckDB's quantile_cont does.
+type Percentile struct {
+	P, Value float64
+}
+
+// Name is the percentile as it is reported, e.g. p25 or p99.9.
+func (p Percentile) Name() string {
+	return fmt.Sprintf("p%g", p.P*100)
+}
+
+func (s Stats) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Float64("mean", s.Mean),
+		slog.Float64("median", s.Median),
+		slog.Float64("stddev", s.StdDev),
+		slog.Float64("min", s.Min),
+		slog.Float64("max", s.Max),
+	}
+	for _, p := range s.Percentiles {
+		attrs = append(attrs, slog.Float64(p.Name(), p.Value))
+	}
+	if s.NonFinite > 0 {
+		attrs = append(attrs, slog.Int64("non_finite", s.NonFinite))
+	}
+	if s.Nulls > 0 {
+		attrs = append(attrs, slog.Int64("nulls", s.Nulls))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// StatNames names the statistics in Stats, in the order Values returns them.
+var StatNames = []string{"mean", "median", "stddev", "min", "max"}
+
+func (s Stats) Values() []float64 {
+	return []float64{s.Mean, s.Median, s.StdDev, s.Min, s.Max}
+}
+
+// StatTolerance is how closely the engines are expected to agree on every statistic.
+var StatTolerance = floatcmp.Tolerance{Rel: 1e-9}
+
+// Mismatch is a statistic on which two sets of Stats disagree.
+type Mismatch struct {
+	Stat string
+	A, B float64
+}
+
+// CompareStats returns the statistics on which a and b are not equal within tol. Only the percentiles both
+// calculated are compared.
+func CompareStats(a, b Stats, tol floatcmp.Tolerance) []Mismatch {
+	var mismatches []Mismatch
+	av, bv := a.Values(), b.Values()
+	for i, name := range StatNames {
+		if !tol.Equal(av[i], bv[i]) {
+			mismatches = append(mismatches, Mismatch{Stat: name, A: av[i], B: bv[i]})
+		}
+	}
+	for _, p := range a.Percentiles {
+		if q, ok := b.Percentile(p.P); ok && !tol.Equal(p.Value, q) {
+			mismatches = append(mismatches, Mismatch{Stat: p.Name(), A: p.Value, B: q})
+		}
+	}
+	return mismatches
+}
+
+// Percentile returns the pth percentile, if it was calculated.
+func (s Stats) Percentile(p float64) (float64, bool) {
+	for _, q := range s.Percentiles {
+		if q.P == p {
+			return q.Value, true
+		}
+	}
+	return 0, false
+}
+
+func checkPercentiles(percentiles []float64) error {
+	for _, p := range percentiles {
+		if !(p >= 0 && p <= 1) {
+			return fmt.Errorf("percentile %g is outside [0, 1]", p)
+		}
+	}
+	return nil
+}
+
+// percentile interpolates the pth percentile of sorted values as quantile_cont does, falling back to weighting
+// the closest ranks for the values whose difference overflows.
+func percentile(values []float64, p float64) float64 {
+	rank := p * float64(len(values)-1)
+	lo, hi := values[int(math.Floor(rank))], values[int(math.Ceil(rank))]
+	d := rank - math.Floor(rank)
+	if d == 0 {
+		return lo
+	}
+	if delta := hi - lo; !math.IsInf(delta, 0) {
+		return lo + delta*d
+	}
+	return lo*(1-d) + hi*d
+}
+
+// ErrNoValues is returned by both engines for a dataset with no finite values, which has no statistics.
+var ErrNoValues = errors.New("no finite values to calculate statistics of")
+
+func StatisticsFromRecords(records []Record) (Stats, error) {
+	return StatisticsWithSummation(records, SumPairwise)
+}
+
+// StatisticsWithSummation is StatisticsFromRecords accumulating the mean and stddev with sum, and also calculating
+// percentiles.
+func StatisticsWithSummation(records []Record, sum Summation, percentiles ...float64) (Stats, error) {
+	if err := checkPercentiles(percentiles); err != nil {
+		return Stats{}, err
+	}
+	var mean, median, stddev, min, max float64
+	var nonFinite, nulls int64
+
+	values := make([]float64, 0, len(records))
+	max = math.Inf(-1)
+	min = math.Inf(1)
+
+	for _, r := range records {
+		if r.Null {
+			nulls++
+			continue
+		}
+		if math.IsNaN(r.Value) || math.IsInf(r.Value, 0) {
+			nonFinite++
+			continue
+		}
+		if r.Value > max {
+			max = r.Value
+		}
+		if r.Value < min {
+			min = r.Value
+		}
+		values = append(values, r.Value)
+	}
+	if len(values) == 0 {
+		return Stats{NonFinite: nonFinite, Nulls: nulls}, ErrNoValues
+	}
+	slices.Sort(values)
+
+	// mean and stddev are calculated on values scaled below 1 so neither can overflow, however large the values
+	exp, scale := sumScale(math.Max(math.Abs(min), math.Abs(max)))
+	n := float64(len(values))
+	scaledMean := sum.sum(values, scale) / n
+	mean = math.Ldexp(scaledMean, exp)
+	stddev = math.Ldexp(math.Sqrt(sum.squares(values, scaledMean, scale)/n), exp)
+
+	median = sortedMedian(values)
+
+	s := Stats{Mean: mean, Median: median, StdDev: stddev, Min: min, Max: max, NonFinite: nonFinite, Nulls: nulls}
+	for _, p := range percentiles {
+		s.Percentiles = append(s.Percentiles, Percentile{P: p, Value: percentile(values, p)})
+	}
+	return s, nil
+}
+
+func finite(v float64) bool { return !math.IsNaN(v) && !math.IsInf(v, 0) }
+
+// sortedMedian returns the median of sorted values, halving the middle two separately if their sum overflows.
+func sortedMedian(values []float64) float64 {
+	if len(values)%2 == 1 {
+		return values[len(values)/2]
+	}
+	a, b := values[len(values)/2-1], values[len(values)/2]
+	if median := (a + b) / 2; !math.IsInf(median, 0) {
+		return median
+	}
+	return a/2 + b/2
+}
+
+// Queryer is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type Queryer = sqlscan.Queryer
+
+// Execer is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// statisticsQuery calculates every statistic in one pass over the records table, with the percentiles as a list
+// which is NULL if none are requested.
+func statisticsQuery(percentiles []float64) string {
+	var q strings.Builder
+	q.WriteString(`
+	SELECT
+		AVG(value) FILTER (WHERE isfinite(value)) AS mean,
+		MEDIAN(value) FILTER (WHERE isfinite(value)) AS median,
+		STDDEV_POP(value) FILTER (WHERE isfinite(value)) AS stddev,
+		MIN(value) FILTER (WHERE isfinite(value)) AS min,
+		MAX(value) FILTER (WHERE isfinite(value)) AS max,
+		COUNT(*) FILTER (WHERE NOT isfinite(value)) AS non_finite,
+		COUNT(*) FILTER (WHERE value IS NULL) AS nulls`)
+	if len(percentiles) > 0 {
+		fmt.Fprintf(&q, `,
+		QUANTILE_CONT(value, %s) FILTER (WHERE isfinite(value)) AS percentiles`, listLiteral(percentiles))
+	} else {
+		q.WriteString(`,
+		NULL::DOUBLE[] AS percentiles`)
+	}
+	q.WriteString(`
+	FROM records`)
+	return q.String()
+}
+
+// listLiteral formats values as a DuckDB list.
+func listLiteral(values []float64) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+// streamingStatisticsQuery is statisticsQuery without the median or percentiles, which a single pass in Go cannot
+// find, for comparing with Welford.
+var streamingStatisticsQuery = strings.Replace(statisticsQuery(nil),
+	"MEDIAN(value) FILTER (WHERE isfinite(value))", "NULL::DOUBLE", 1)
+
+// StatisticsQueries lists the queries StatisticsFromDB runs for DefaultPercentiles, e.g. for LogPlans.
+var StatisticsQueries = []string{statisticsQuery(DefaultPercentiles)}
+
+// statisticsRow is a row of statisticsQuery, scanned by column name. Every aggregate but the count is NULL when
+// there are no finite values.
+type statisticsRow struct {
+	Mean        nullable.Nullable[float64] `db:"mean"`
+	Median      nullable.Nullable[float64] `db:"median"`
+	StdDev      nullable.Nullable[float64] `db:"stddev"`
+	Min         nullable.Nullable[float64] `db:"min"`
+	Max         nullable.Nullable[float64] `db:"max"`
+	NonFinite   int64                      `db:"non_finite"`
+	Nulls       int64                      `db:"nulls"`
+	Percentiles nullable.Nullable[[]any]   `db:"percentiles"`
+}
+
+func (row statisticsRow) stats() (Stats, error) {
+	s := Stats{NonFinite: row.NonFinite, Nulls: row.Nulls}
+	if !row.Mean.Valid {
+		return s, ErrNoValues
+	}
+	s.Mean, s.Median, s.StdDev, s.Min, s.Max = row.Mean.V, row.Median.Or(math.NaN()), row.StdDev.V, row.Min.V, row.Max.V
+	return s, nil
+}
+
+// StreamingStatisticsFromDB calculates the statistics of the records table which Welford does, leaving the
+// median NaN.
+func StreamingStatisticsFromDB(ctx context.Context, db Queryer) (Stats, error) {
+	row, err := sqlscan.One[statisticsRow](ctx, db, streamingStatisticsQuery)
+	if err != nil {
+		return Stats{}, fmt.Errorf("querying statistics: %w", err)
+	}
+	return row.stats()
+}
+
+// StatisticsFromDB calculates the statistics of the records table, and also percentiles.
+func StatisticsFromDB(ctx context.Context, db Queryer, percentiles ...float64) (Stats, error) {
+	if err := checkPercentiles(percentiles); err != nil {
+		return Stats{}, err
+	}
+	row, err := sqlscan.One[statisticsRow](ctx, db, statisticsQuery(percentiles))
+	if err != nil {
+		return Stats{}, fmt.Errorf("querying statistics: %w", err)
+	}
+	s, err := row.stats()
+	if err != nil {
+		return s, err
+	}
+	for i, v := range row.Percentiles.V {
+		f, ok := v.(float64)
+		if !ok {
+			return s, fmt.Errorf("percentile %g is %T, not a float64", percentiles[i], v)
+		}
+		s.Percentiles = append(s.Percentiles, Percentile{P: percentiles[i], Value: f})
+	}
+	return s, nil
+}
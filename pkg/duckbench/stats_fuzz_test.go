@@ -0,0 +1,65 @@
+package duckbench
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+func encodeFloats(values ...float64) []byte {
+	b := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(b[8*i:], math.Float64bits(v))
+	}
+	return b
+}
+
+// decodeFloats reads up to 256 little-endian float64s from b, ignoring any trailing partial value.
+func decodeFloats(b []byte) []float64 {
+	values := make([]float64, 0, min(len(b)/8, 256))
+	for len(b) >= 8 && len(values) < cap(values) {
+		values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		b = b[8:]
+	}
+	return values
+}
+
+func FuzzStatistics(f *testing.F) {
+	f.Add(encodeFloats())
+	f.Add(encodeFloats(1))
+	f.Add(encodeFloats(1, 2, 3, 4))
+	f.Add(encodeFloats(math.NaN(), 1, 2))
+	f.Add(encodeFloats(math.Inf(1), math.Inf(-1)))
+	f.Add(encodeFloats(math.Inf(1), 1))
+	f.Add(encodeFloats(5e-324, -5e-324, math.SmallestNonzeroFloat64*3))
+	f.Add(encodeFloats(math.MaxFloat64, math.MaxFloat64))
+	f.Add(encodeFloats(math.Copysign(0, -1), 0))
+
+	ctx := context.Background()
+	db := loadDB(f, nil)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		values := decodeFloats(data)
+		records := recordsOf(values...)
+
+		if _, err := db.ExecContext(ctx, "DELETE FROM records"); err != nil {
+			t.Fatal(err)
+		}
+		if err := StandardInsert(ctx, records, db); err != nil {
+			t.Fatalf("inserting %v: %v", values, err)
+		}
+		goStats, goErr := StatisticsFromRecords(records)
+		dbStats, dbErr := StatisticsFromDB(ctx, db)
+		if goErr != nil || dbErr != nil {
+			if !errors.Is(goErr, ErrNoValues) || !errors.Is(dbErr, ErrNoValues) {
+				t.Logf("%v: go: %v, duckdb: %v", values, goErr, dbErr)
+			}
+			return
+		}
+		for _, m := range CompareStats(goStats, dbStats, StatTolerance) {
+			t.Logf("%v: %s diverges: go %v, duckdb %v", values, m.Stat, m.A, m.B)
+		}
+	})
+}
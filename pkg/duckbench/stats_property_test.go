@@ -0,0 +1,63 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestStatisticsProperties(t *testing.T) {
+	ctx := context.Background()
+	db := loadDB(t, nil)
+
+	rapid.Check(t, func(rt *rapid.T) {
+		values := rapid.SliceOfN(rapid.Float64Range(-1e6, 1e6), 1, 200).Draw(rt, "values")
+		records := recordsOf(values...)
+
+		if _, err := db.ExecContext(ctx, "DELETE FROM records"); err != nil {
+			rt.Fatal(err)
+		}
+		if err := StandardInsert(ctx, records, db); err != nil {
+			rt.Fatal(err)
+		}
+		goStats, err := StatisticsFromRecords(records)
+		if err != nil {
+			rt.Fatal(err)
+		}
+		dbStats, err := StatisticsFromDB(ctx, db)
+		if err != nil {
+			rt.Fatal(err)
+		}
+
+		for name, s := range map[string]Stats{"go": goStats, "duckdb": dbStats} {
+			if !(s.Min <= s.Median && s.Median <= s.Max) {
+				rt.Errorf("%s: median %v outside [%v, %v]", name, s.Median, s.Min, s.Max)
+			}
+			if !(s.Min <= s.Mean && s.Mean <= s.Max) {
+				rt.Errorf("%s: mean %v outside [%v, %v]", name, s.Mean, s.Min, s.Max)
+			}
+			if !(s.StdDev >= 0) {
+				rt.Errorf("%s: stddev %v is negative", name, s.StdDev)
+			}
+		}
+
+		// Rounding error in sums is relative to the magnitude of the data rather than the result, which may be
+		// close to zero, so differences are also allowed in proportion to the largest value.
+		scale := 0.0
+		for _, v := range values {
+			scale = max(scale, math.Abs(v))
+		}
+		g, d := goStats.Values(), dbStats.Values()
+		for i, stat := range StatNames {
+			tol := statTolerance[stat]
+			tol.Abs = max(tol.Rel, 1e-12) * scale
+			if !tol.Equal(g[i], d[i]) {
+				rt.Errorf("%s: go %v, duckdb %v differ by %g", stat, g[i], d[i], floatcmp.AbsDiff(g[i], d[i]))
+			}
+		}
+	})
+}
@@ -0,0 +1,487 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// statTolerance is the difference allowed between the engines, and between an engine and a golden value, for each
+// statistic. Min and max select a value rather than computing one, so must match exactly.
+var statTolerance = map[string]floatcmp.Tolerance{
+	"mean":   floatcmp.Rel(1e-12),
+	"median": floatcmp.Rel(1e-12),
+	"stddev": floatcmp.Rel(1e-9),
+	"min":    floatcmp.Exact,
+	"max":    floatcmp.Exact,
+}
+
+// assertStats fails t if any statistic of got differs from want by more than its tolerance.
+func assertStats(t *testing.T, name string, got, want Stats) {
+	t.Helper()
+	g, w := got.Values(), want.Values()
+	for i, stat := range StatNames {
+		if tol := statTolerance[stat]; !tol.Equal(g[i], w[i]) {
+			t.Errorf("%s: %s = %v, want %v (tolerance %+v)", name, stat, g[i], w[i], tol)
+		}
+	}
+}
+
+func recordsOf(values ...float64) []Record {
+	records := make([]Record, len(values))
+	for i, v := range values {
+		records[i] = Record{ID: i, Value: v}
+	}
+	return records
+}
+
+// loadDB returns a fresh database whose records table holds records.
+func loadDB(t testing.TB, records []Record) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+	db, err := CreateDB(ctx, DBOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := CreateRecordsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := StandardInsert(ctx, records, db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// bothEngines calculates the statistics of records in Go and in DuckDB, failing t if either fails.
+func bothEngines(t *testing.T, records []Record) (goStats, dbStats Stats) {
+	t.Helper()
+	goStats, err := StatisticsFromRecords(records)
+	if err != nil {
+		t.Fatalf("go: %v", err)
+	}
+	dbStats, err = StatisticsFromDB(context.Background(), loadDB(t, records))
+	if err != nil {
+		t.Fatalf("duckdb: %v", err)
+	}
+	return goStats, dbStats
+}
+
+// bulkLoadDB is loadDB for datasets too large to insert row by row, going through a saved CSV dataset instead.
+func bulkLoadDB(t *testing.T, records []Record) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+	path := t.TempDir() + "/records.csv"
+	if err := SaveDataset(ctx, records, path); err != nil {
+		t.Fatal(err)
+	}
+	db := loadDB(t, nil)
+	if err := InsertDataset(ctx, db, path); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestStatisticsGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []Record
+		want    Stats
+	}{
+		{
+			name:    "single row",
+			records: recordsOf(-2.5),
+			want:    Stats{Mean: -2.5, Median: -2.5, StdDev: 0, Min: -2.5, Max: -2.5},
+		},
+		{
+			name:    "even count",
+			records: recordsOf(4, 1, 3, 2),
+			want:    Stats{Mean: 2.5, Median: 2.5, StdDev: math.Sqrt(1.25), Min: 1, Max: 4},
+		},
+		{
+			name:    "odd count",
+			records: recordsOf(10, -5, 0, 25, 5),
+			want:    Stats{Mean: 7, Median: 5, StdDev: math.Sqrt(106), Min: -5, Max: 25},
+		},
+		{
+			name:    "constant",
+			records: recordsOf(3.5, 3.5, 3.5),
+			want:    Stats{Mean: 3.5, Median: 3.5, StdDev: 0, Min: 3.5, Max: 3.5},
+		},
+		{
+			name:    "negative",
+			records: recordsOf(-1, -2, -3, -4, -5, -6),
+			want:    Stats{Mean: -3.5, Median: -3.5, StdDev: math.Sqrt(17.5 / 6), Min: -6, Max: -1},
+		},
+		{
+			name:    "mixed magnitudes",
+			records: recordsOf(1e-9, 1e9, 0.5, -1e3),
+			want:    Stats{Mean: (1e-9 + 1e9 + 0.5 - 1e3) / 4, Median: (1e-9 + 0.5) / 2, StdDev: 433012846.1578104, Min: -1e3, Max: 1e9},
+		},
+		{
+			name:    "generated",
+			records: GenerateRecords(1001),
+			want:    Stats{Mean: 500, Median: 500, StdDev: math.Sqrt((1001*1001 - 1) / 12.0), Min: 0, Max: 1000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goStats, dbStats := bothEngines(t, tt.records)
+			assertStats(t, "duckdb vs go", dbStats, goStats)
+			assertStats(t, "go vs golden", goStats, tt.want)
+			assertStats(t, "duckdb vs golden", dbStats, tt.want)
+		})
+	}
+}
+
+// TestStatisticsDistributions checks the engines agree on the records of every distribution, under more than one
+// seed, loading them with the Appender as there are too many to insert row by row.
+func TestStatisticsDistributions(t *testing.T) {
+	ctx := context.Background()
+	for _, dist := range Distributions {
+		for _, seed := range []uint64{1, 2} {
+			t.Run(fmt.Sprintf("%s/%d", dist, seed), func(t *testing.T) {
+				records, err := GenerateDistribution(20000, dist, seed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				db := loadDB(t, nil)
+				if err := AppenderInsert(ctx, records, db); err != nil {
+					t.Fatal(err)
+				}
+				goStats, err := StatisticsFromRecords(records)
+				if err != nil {
+					t.Fatal(err)
+				}
+				dbStats, err := StatisticsFromDB(ctx, db)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// the mean of a normal distribution is close to zero, so rounding is allowed in proportion to the
+				// largest value as well
+				scale := math.Max(math.Abs(goStats.Min), math.Abs(goStats.Max))
+				g, d := goStats.Values(), dbStats.Values()
+				for i, stat := range StatNames {
+					tol := statTolerance[stat]
+					tol.Abs = tol.Rel * scale
+					if !tol.Equal(d[i], g[i]) {
+						t.Errorf("%s: duckdb %v, go %v differ by %g", stat, d[i], g[i], floatcmp.AbsDiff(d[i], g[i]))
+					}
+				}
+				if goStats.NonFinite != 0 || dbStats.NonFinite != 0 {
+					t.Errorf("NonFinite = %d (go), %d (duckdb), want 0", goStats.NonFinite, dbStats.NonFinite)
+				}
+			})
+		}
+	}
+}
+
+func TestStatisticsNonFinite(t *testing.T) {
+	finite := []float64{4, 1, 3, 2}
+	want, _ := bothEngines(t, recordsOf(finite...))
+	want.NonFinite = 4
+
+	goStats, dbStats := bothEngines(t, recordsOf(math.NaN(), 4, math.Inf(1), 1, 3, math.Inf(-1), 2, math.NaN()))
+	for name, got := range map[string]Stats{"go": goStats, "duckdb": dbStats} {
+		assertStats(t, name, got, want)
+		if got.NonFinite != want.NonFinite {
+			t.Errorf("%s: NonFinite = %d, want %d", name, got.NonFinite, want.NonFinite)
+		}
+	}
+}
+
+func TestStatisticsNulls(t *testing.T) {
+	want, _ := bothEngines(t, recordsOf(4, 1, 3, 2))
+	want.NonFinite, want.Nulls = 1, 2
+
+	records := recordsOf(math.NaN(), 4, 0, 1, 3, 0, 2)
+	for _, i := range []int{2, 5} {
+		records[i].Value, records[i].Null = math.NaN(), true
+	}
+	goStats, dbStats := bothEngines(t, records)
+	streaming, err := StreamingStatistics(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := ParallelStatistics(records, SumNaive, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	streaming.Median = want.Median
+	for name, got := range map[string]Stats{"go": goStats, "duckdb": dbStats, "streaming": streaming, "parallel": parallel} {
+		assertStats(t, name, got, want)
+		if got.NonFinite != want.NonFinite || got.Nulls != want.Nulls {
+			t.Errorf("%s: NonFinite, Nulls = %d, %d, want %d, %d", name, got.NonFinite, got.Nulls, want.NonFinite, want.Nulls)
+		}
+	}
+}
+
+// TestRunNulls checks that every insert method, a saved dataset and a streamed run keep the NULLs of
+// Config.NullRatio, and that both engines skip the same ones.
+func TestRunNulls(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/nulls.parquet"
+	cfg := Config{N: 2000, Distribution: DistNormal, NullRatio: 0.25, SaveDataset: path,
+		Inserts: []InsertStrategy{InsertStandard, InsertAppender, InsertValues, InsertCSV}}
+	res, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := res.GoStats.Nulls; n < 400 || n > 600 || res.DBStats.Nulls != n {
+		t.Errorf("go skipped %d NULLs and duckdb %d, want about %d", n, res.DBStats.Nulls, cfg.N/4)
+	}
+	assertStats(t, "duckdb", res.DBStats, res.GoStats)
+
+	records, err := LoadDataset(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := GenerateDistribution(cfg.N, cfg.Distribution, cfg.Seed)
+	InjectNulls(want, cfg.NullRatio, cfg.Seed)
+	if ChecksumRecords(records) != ChecksumRecords(want) {
+		t.Error("the saved dataset lost its NULLs")
+	}
+
+	cfg.SaveDataset, cfg.ChunkSize = "", 300
+	streamed, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed.GoStats.Nulls != res.GoStats.Nulls || streamed.DBStats.Nulls != res.GoStats.Nulls {
+		t.Errorf("streamed run skipped %d and %d NULLs, want %d", streamed.GoStats.Nulls, streamed.DBStats.Nulls, res.GoStats.Nulls)
+	}
+}
+
+func TestStatisticsPercentiles(t *testing.T) {
+	ctx := context.Background()
+	records := recordsOf(math.NaN(), 10, 20, 30, 40, math.Inf(1), -50, 1e150, -1e150)
+	percentiles := []float64{0, 0.25, 0.5, 0.99, 1}
+	// quantile_cont interpolates linearly between the closest ranks of the 7 finite values
+	want := []float64{-1e150, -20, 20, 1e150 - 0.06*(1e150-40), 1e150}
+
+	goStats, err := StatisticsWithSummation(records, SumPairwise, percentiles...)
+	if err != nil {
+		t.Fatalf("go: %v", err)
+	}
+	dbStats, err := StatisticsFromDB(ctx, loadDB(t, records), percentiles...)
+	if err != nil {
+		t.Fatalf("duckdb: %v", err)
+	}
+	for name, got := range map[string]Stats{"go": goStats, "duckdb": dbStats} {
+		if len(got.Percentiles) != len(percentiles) {
+			t.Fatalf("%s: Percentiles = %v, want %v", name, got.Percentiles, percentiles)
+		}
+		for i, p := range got.Percentiles {
+			if p.P != percentiles[i] || !floatcmp.Rel(1e-12).Equal(p.Value, want[i]) {
+				t.Errorf("%s: %s = %v, want %v", name, p.Name(), p.Value, want[i])
+			}
+		}
+	}
+	if m := CompareStats(goStats, dbStats, StatTolerance); len(m) > 0 {
+		t.Errorf("engines disagree: %+v", m)
+	}
+
+	if _, err := StatisticsWithSummation(records, SumPairwise, 1.5); err == nil {
+		t.Error("go: calculating the 150th percentile succeeded")
+	}
+	if _, err := StatisticsFromDB(ctx, loadDB(t, records), -0.1); err == nil {
+		t.Error("duckdb: calculating the -10th percentile succeeded")
+	}
+}
+
+func TestStreamingStatistics(t *testing.T) {
+	for _, dist := range Distributions {
+		records, err := GenerateDistribution(10000, dist, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, Record{Value: math.NaN()}, Record{Value: math.Inf(-1)})
+		want, err := StatisticsFromRecords(records)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := StreamingStatistics(records)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !math.IsNaN(got.Median) {
+			t.Errorf("%s: median = %v, want NaN", dist, got.Median)
+		}
+		got.Median = want.Median
+		assertStats(t, string(dist), got, want)
+		if got.NonFinite != 2 {
+			t.Errorf("%s: NonFinite = %d, want 2", dist, got.NonFinite)
+		}
+	}
+	if _, err := StreamingStatistics(recordsOf(math.NaN())); !errors.Is(err, ErrNoValues) {
+		t.Errorf("err = %v, want ErrNoValues", err)
+	}
+}
+
+func TestParallelStatistics(t *testing.T) {
+	percentiles := []float64{0.1, 0.5, 0.99}
+	datasets := map[string][]Record{
+		"fewer records than threads": recordsOf(3, math.NaN(), 1),
+		"non-finite":                 append(GenerateRecords(1001), Record{Value: math.Inf(1)}, Record{Value: math.NaN()}),
+		"large magnitudes":           recordsOf(math.MaxFloat64, -math.MaxFloat64, math.MaxFloat64, 1),
+	}
+	for _, dist := range Distributions {
+		datasets[string(dist)], _ = GenerateDistribution(10000, dist, 1)
+	}
+	for name, records := range datasets {
+		want, err := StatisticsWithSummation(records, SumPairwise, percentiles...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, threads := range []int{1, 3, 8} {
+			got, err := ParallelStatistics(records, SumPairwise, threads, percentiles...)
+			if err != nil {
+				t.Fatalf("%s, %d threads: %v", name, threads, err)
+			}
+			assertStats(t, fmt.Sprintf("%s, %d threads", name, threads), got, want)
+			// the merged chunks are the same sorted values, so the percentiles are exact
+			if got.NonFinite != want.NonFinite || !slices.Equal(got.Percentiles, want.Percentiles) {
+				t.Errorf("%s, %d threads: NonFinite %d, percentiles %v, want %d, %v", name, threads,
+					got.NonFinite, got.Percentiles, want.NonFinite, want.Percentiles)
+			}
+		}
+	}
+	if _, err := ParallelStatistics(recordsOf(math.NaN()), SumPairwise, 4); !errors.Is(err, ErrNoValues) {
+		t.Errorf("err = %v, want ErrNoValues", err)
+	}
+}
+
+func TestStatisticsNoValues(t *testing.T) {
+	tests := map[string][]Record{
+		"empty":   {},
+		"all nan": recordsOf(math.NaN(), math.NaN()),
+		"all inf": recordsOf(math.Inf(1), math.Inf(-1)),
+	}
+	for name, records := range tests {
+		t.Run(name, func(t *testing.T) {
+			goStats, err := StatisticsFromRecords(records)
+			if !errors.Is(err, ErrNoValues) {
+				t.Errorf("go: err = %v, want ErrNoValues", err)
+			}
+			dbStats, err := StatisticsFromDB(context.Background(), loadDB(t, records))
+			if !errors.Is(err, ErrNoValues) {
+				t.Errorf("duckdb: err = %v, want ErrNoValues", err)
+			}
+			if n := int64(len(records)); goStats.NonFinite != n || dbStats.NonFinite != n {
+				t.Errorf("NonFinite = %d (go), %d (duckdb), want %d", goStats.NonFinite, dbStats.NonFinite, n)
+			}
+		})
+	}
+}
+
+func TestStatisticsLargeMagnitudes(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []Record
+		want    Stats
+	}{
+		{
+			name:    "max float",
+			records: recordsOf(math.MaxFloat64, math.MaxFloat64),
+			want:    Stats{Mean: math.MaxFloat64, Median: math.MaxFloat64, StdDev: 0, Min: math.MaxFloat64, Max: math.MaxFloat64},
+		},
+		{
+			name:    "opposite signs",
+			records: recordsOf(-1e308, 1e308, 1e308, -1e308),
+			want:    Stats{Mean: 0, Median: 0, StdDev: 1e308, Min: -1e308, Max: 1e308},
+		},
+		{
+			name:    "squares overflow",
+			records: recordsOf(1e200, -1e200, 3e200),
+			want:    Stats{Mean: 1e200, Median: 1e200, StdDev: math.Sqrt(8.0/3) * 1e200, Min: -1e200, Max: 3e200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StatisticsFromRecords(tt.records)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertStats(t, "go", got, tt.want)
+		})
+	}
+}
+
+// TestStatisticsLargeN compares the engines over enough values for naive summation to drift measurably.
+func TestStatisticsLargeN(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large dataset")
+	}
+	const n = 10_000_000
+	records := make([]Record, n)
+	for i := range records {
+		records[i] = Record{ID: i, Value: 1e6 + float64(i*7919%100003)/100003}
+	}
+
+	goStats, err := StatisticsFromRecords(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbStats, err := StatisticsFromDB(context.Background(), bulkLoadDB(t, records))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range CompareStats(goStats, dbStats, StatTolerance) {
+		t.Errorf("%s: go %v, duckdb %v, relative difference %g", m.Stat, m.A, m.B, floatcmp.RelDiff(m.A, m.B))
+	}
+}
+
+// TestSummationDrift reports how far each summation drifts from DuckDB as N grows for a few distributions, and
+// checks that those expected to be accurate for a distribution stay within StatTolerance. Run with -v to see the
+// drift.
+func TestSummationDrift(t *testing.T) {
+	distributions := []struct {
+		name  string
+		value func(i int) float64
+		// accurate are the summations expected to agree with DuckDB. Alternating signs and magnitudes cancel
+		// catastrophically, which only compensated summation survives.
+		accurate []Summation
+	}{
+		{"uniform", func(i int) float64 { return float64(i*7919%100003) / 100003 }, []Summation{SumPairwise, SumNeumaier}},
+		{"offset", func(i int) float64 { return 1e9 + float64(i*7919%100003)/100003 }, []Summation{SumPairwise, SumNeumaier}},
+		{"magnitudes", func(i int) float64 { return math.Pow(10, float64(i%16)) * float64(1-2*(i/16%2)) }, []Summation{SumNeumaier}},
+	}
+	sizes := []int{1_000, 100_000, 1_000_000}
+	if testing.Short() {
+		sizes = sizes[:2]
+	}
+	for _, dist := range distributions {
+		for _, n := range sizes {
+			t.Run(fmt.Sprintf("%s/%d", dist.name, n), func(t *testing.T) {
+				records := make([]Record, n)
+				for i := range records {
+					records[i] = Record{ID: i, Value: dist.value(i)}
+				}
+				dbStats, err := StatisticsFromDB(context.Background(), bulkLoadDB(t, records))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, sum := range Summations {
+					goStats, err := StatisticsWithSummation(records, sum)
+					if err != nil {
+						t.Fatal(err)
+					}
+					t.Logf("%-8s mean %.3g stddev %.3g", sum,
+						floatcmp.RelDiff(goStats.Mean, dbStats.Mean), floatcmp.RelDiff(goStats.StdDev, dbStats.StdDev))
+					if !slices.Contains(dist.accurate, sum) {
+						continue
+					}
+					for _, m := range CompareStats(goStats, dbStats, StatTolerance) {
+						t.Errorf("%s: %s: go %v, duckdb %v", sum, m.Stat, m.A, m.B)
+					}
+				}
+			})
+		}
+	}
+}
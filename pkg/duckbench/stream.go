@@ -0,0 +1,206 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// DefaultChunkSize is how many records a streamed run generates at a time, see Config.ChunkSize.
+const DefaultChunkSize = 1 << 20
+
+// RecordStream generates the records of GenerateDistribution a chunk at a time, assigned to categories as by
+// AssignCategories if groups is positive and made NULL as by InjectNulls with nullRatio, so that a run over any
+// number of records needs only a chunk of memory.
+//
+// GenerateDistribution pairs each Value2 from where its source stood after drawing every value, so NewRecordStream
+// draws and discards all n values first to find that state, which takes about as long as generating them. Reset
+// then replays the stream without doing so again.
+type RecordStream struct {
+	n, next   int
+	groups    int
+	nullRatio float64
+	record    func(i int) Record
+
+	// values, pair, categories and nulls are the states of each source as Reset restores them
+	values, pair, categories, nulls         rand.PCG
+	valueSrc, pairSrc, categorySrc, nullSrc *rand.PCG
+	pairRand, categoryRand, nullRand        *rand.Rand
+	chunk                                   []Record
+}
+
+func NewRecordStream(n int, dist Distribution, seed uint64, groups int, nullRatio float64) (*RecordStream, error) {
+	if err := checkNullRatio(nullRatio); err != nil {
+		return nil, err
+	}
+	s := &RecordStream{n: n, groups: groups, nullRatio: nullRatio}
+	if dist == "" || dist == DistSequential {
+		// as GenerateRecords numbers them
+		s.values, s.pair = *rand.NewPCG(0, 0), *rand.NewPCG(0, 0)
+	} else {
+		s.values = *rand.NewPCG(seed, seed)
+		skip := s.values
+		next, err := recordSource(n, dist, rand.New(&skip))
+		if err != nil {
+			return nil, err
+		}
+		for i := range n {
+			next(i)
+		}
+		s.pair = skip
+	}
+	s.categories, s.nulls = *rand.NewPCG(seed, ^seed), *rand.NewPCG(^seed, seed)
+
+	s.valueSrc, s.pairSrc, s.categorySrc, s.nullSrc = new(rand.PCG), new(rand.PCG), new(rand.PCG), new(rand.PCG)
+	*s.valueSrc = s.values
+	var err error
+	if s.record, err = recordSource(n, dist, rand.New(s.valueSrc)); err != nil {
+		return nil, err
+	}
+	s.pairRand, s.categoryRand, s.nullRand = rand.New(s.pairSrc), rand.New(s.categorySrc), rand.New(s.nullSrc)
+	s.Reset()
+	return s, nil
+}
+
+// Len returns the number of records the stream generates in all.
+func (s *RecordStream) Len() int { return s.n }
+
+// Reset rewinds the stream to its first record.
+func (s *RecordStream) Reset() {
+	s.next = 0
+	*s.valueSrc, *s.pairSrc, *s.categorySrc, *s.nullSrc = s.values, s.pair, s.categories, s.nulls
+}
+
+// Next returns up to size more records, or none once every record has been generated. The chunk is reused by the
+// next call, so it must not be retained.
+func (s *RecordStream) Next(size int) []Record {
+	size = max(min(size, s.n-s.next), 0)
+	s.chunk = slices.Grow(s.chunk[:0], size)[:size]
+	for j := range s.chunk {
+		r := s.record(s.next + j)
+		r.Value2 = paired(r.Value, s.pairRand)
+		if s.groups > 0 {
+			r.Category = category(s.categoryRand, s.groups)
+		}
+		if s.nullRatio > 0 {
+			nullify(&r, s.nullRatio, s.nullRand)
+		}
+		s.chunk[j] = r
+	}
+	s.next += size
+	return s.chunk
+}
+
+// errStreamed rejects the parts of a run which need every record in memory at once.
+var errStreamed = errors.New("streamed records cannot be replayed, saved, or given to workloads or other engines, which need them all in memory")
+
+// measureChunks times step over every chunk of the stream, as measure times fn: cfg.Warmup times discarded and
+// then cfg.Repeat times, calling setup untimed before each. Only step is timed, not generating the chunks.
+func (cfg *Config) measureChunks(ctx context.Context, name string, stream *RecordStream, setup func() error, step func(chunk []Record) error) (BenchmarkResult, error) {
+	res := BenchmarkResult{Name: name, Rows: stream.Len()}
+	for i := 0; i < cfg.iterations(); i++ {
+		if setup != nil {
+			if err := setup(); err != nil {
+				return res, err
+			}
+		}
+		var elapsed time.Duration
+		stream.Reset()
+		for chunk := stream.Next(cfg.ChunkSize); len(chunk) > 0; chunk = stream.Next(cfg.ChunkSize) {
+			if err := ctx.Err(); err != nil {
+				return res, err
+			}
+			start := time.Now()
+			err := step(chunk)
+			elapsed += time.Since(start)
+			if err != nil {
+				return res, err
+			}
+		}
+		if i >= cfg.Warmup {
+			res.Samples = append(res.Samples, elapsed)
+		}
+	}
+	res.Duration = SummarizeTimings(res.Samples).Median
+	if len(res.Samples) == 1 {
+		res.Samples = nil
+	}
+	return res, nil
+}
+
+// runStream is the rest of Run for cfg.ChunkSize, after the records table has been created.
+func (cfg *Config) runStream(ctx context.Context, db *sql.DB, res *Results) error {
+	_, end := cfg.startPhase(ctx, PhaseGenerate)
+	stream, err := NewRecordStream(cfg.N, cfg.Distribution, cfg.Seed, cfg.Groups, cfg.NullRatio)
+	end()
+	if err != nil {
+		return fmt.Errorf("generating records: %w", err)
+	}
+
+	phaseCtx, end := cfg.startPhase(ctx, PhaseInsert)
+	err = cfg.insertStream(phaseCtx, db, stream, res)
+	end()
+	if err != nil {
+		return err
+	}
+
+	_, end = cfg.startPhase(ctx, PhaseStats)
+	var w Welford
+	goRun, err := cfg.measureChunks(ctx, "go", stream, func() error {
+		w = Welford{}
+		return nil
+	}, func(chunk []Record) error {
+		for _, r := range chunk {
+			w.addRecord(r)
+		}
+		return nil
+	})
+	if err == nil {
+		res.GoStats, err = w.Stats()
+	}
+	res.GoDuration, res.GoSamples = goRun.Duration, goRun.Samples
+	end()
+	if err != nil {
+		return fmt.Errorf("calculating statistics in Go: %w", err)
+	}
+
+	return cfg.query(ctx, db, cfg.N, streamingStatisticsQuery, StreamingStatisticsFromDB, res)
+}
+
+// insertStream is insert for a stream, inserting each chunk with each of cfg.Inserts in turn.
+func (cfg *Config) insertStream(ctx context.Context, db *sql.DB, stream *RecordStream, res *Results) error {
+	reset := freshTable(ctx, db)
+	var want *Checksum
+	for _, strategy := range cfg.Inserts {
+		run, err := cfg.measureChunks(ctx, strategy.Name(), stream, reset, func(chunk []Record) error {
+			return strategy.Insert(ctx, chunk, db)
+		})
+		res.InsertDuration = run.Duration
+		res.Inserts = append(res.Inserts, run)
+		if err != nil {
+			return fmt.Errorf("inserting records with %s: %w", strategy.Name(), err)
+		}
+		if cfg.SkipVerify {
+			continue
+		}
+		if want == nil {
+			want = new(Checksum)
+			stream.Reset()
+			for chunk := stream.Next(cfg.ChunkSize); len(chunk) > 0; chunk = stream.Next(cfg.ChunkSize) {
+				for _, r := range chunk {
+					want.Add(r)
+				}
+			}
+		}
+		if err := verifyChecksum(sqlwrap.Setup(ctx), db, *want); err != nil {
+			return fmt.Errorf("verifying records inserted with %s: %w", strategy.Name(), err)
+		}
+	}
+	return cfg.checkpoint(ctx, db, stream.Len(), res)
+}
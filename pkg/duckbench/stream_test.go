@@ -0,0 +1,65 @@
+package duckbench
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestRecordStream checks that streaming in chunks of any size generates exactly the records of
+// GenerateDistribution, however many times it is replayed.
+func TestRecordStream(t *testing.T) {
+	const n = 2500
+	for _, dist := range Distributions {
+		t.Run(string(dist), func(t *testing.T) {
+			want, err := GenerateDistribution(n, dist, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssignCategories(want, 10, 7)
+			stream, err := NewRecordStream(n, dist, 7, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, size := range []int{1, 999, n, 2 * n} {
+				stream.Reset()
+				var got []Record
+				for chunk := stream.Next(size); len(chunk) > 0; chunk = stream.Next(size) {
+					got = append(got, chunk...)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("chunks of %d: records differ from GenerateDistribution", size)
+				}
+			}
+		})
+	}
+	if _, err := NewRecordStream(n, "cauchy", 1, 0, 0); err == nil {
+		t.Error("streaming an unknown distribution succeeded")
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{N: 10000, ChunkSize: 3000, Distribution: DistNormal, Inserts: []InsertStrategy{InsertAppender, InsertCSV}, Repeat: 2}
+	res, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Inserts) != 2 || res.Inserts[1].Rows != cfg.N || len(res.GoSamples) != 2 {
+		t.Errorf("inserts = %+v, go samples = %v", res.Inserts, res.GoSamples)
+	}
+	assertStats(t, "duckdb", res.DBStats, res.GoStats)
+
+	records, err := GenerateDistribution(cfg.N, cfg.Distribution, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := StreamingStatistics(records)
+	assertStats(t, "go", res.GoStats, want)
+
+	cfg.Workloads = []Workload{Histogram{Bins: 10}}
+	if _, err := Run(ctx, cfg); !errors.Is(err, errStreamed) {
+		t.Errorf("streaming through a workload: err = %v, want errStreamed", err)
+	}
+}
@@ -0,0 +1,139 @@
+package duckbench
+
+import (
+	"fmt"
+	"math"
+)
+
+// Summation selects how the Go engine accumulates sums for the mean and stddev.
+type Summation string
+
+const (
+	// SumNaive adds values in order, so rounding error can grow linearly with N.
+	SumNaive Summation = "naive"
+	// SumPairwise adds halves recursively, bounding rounding error by O(log n) ulps at almost no cost. It is the
+	// default.
+	SumPairwise Summation = "pairwise"
+	// SumNeumaier carries a Neumaier (improved Kahan) compensation term, which makes the error independent of N
+	// for roughly four times the floating point work.
+	SumNeumaier Summation = "neumaier"
+)
+
+// Summations lists the supported summation algorithms.
+var Summations = []Summation{SumNaive, SumPairwise, SumNeumaier}
+
+func (s *Summation) UnmarshalText(text []byte) error {
+	for _, sum := range Summations {
+		if string(text) == string(sum) {
+			*s = sum
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown summation %q, expected one of %v", text, Summations)
+}
+
+// sum returns the sum of values, each first multiplied by scale.
+func (s Summation) sum(values []float64, scale float64) float64 {
+	switch s {
+	case SumNaive:
+		return naiveSum(values, scale)
+	case SumNeumaier:
+		return neumaierSum(values, scale)
+	default:
+		return pairwiseSum(values, scale)
+	}
+}
+
+// squares returns the sum of the squared deviations of values from mean, each first multiplied by scale.
+func (s Summation) squares(values []float64, mean, scale float64) float64 {
+	switch s {
+	case SumNaive:
+		return naiveSquares(values, mean, scale)
+	case SumNeumaier:
+		return neumaierSquares(values, mean, scale)
+	default:
+		return pairwiseSquares(values, mean, scale)
+	}
+}
+
+func naiveSum(values []float64, scale float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v * scale
+	}
+	return sum
+}
+
+func naiveSquares(values []float64, mean, scale float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		d := v*scale - mean
+		sum += d * d
+	}
+	return sum
+}
+
+// pairwiseBlock is the length below which pairwise summation adds naively.
+const pairwiseBlock = 128
+
+func pairwiseSum(values []float64, scale float64) float64 {
+	if len(values) <= pairwiseBlock {
+		return naiveSum(values, scale)
+	}
+	mid := len(values) / 2
+	return pairwiseSum(values[:mid], scale) + pairwiseSum(values[mid:], scale)
+}
+
+func pairwiseSquares(values []float64, mean, scale float64) float64 {
+	if len(values) <= pairwiseBlock {
+		return naiveSquares(values, mean, scale)
+	}
+	mid := len(values) / 2
+	return pairwiseSquares(values[:mid], mean, scale) + pairwiseSquares(values[mid:], mean, scale)
+}
+
+// neumaier accumulates a sum along with the rounding error lost from it so far.
+type neumaier struct {
+	sum, c float64
+}
+
+func (n *neumaier) add(x float64) {
+	t := n.sum + x
+	if math.Abs(n.sum) >= math.Abs(x) {
+		n.c += (n.sum - t) + x
+	} else {
+		n.c += (x - t) + n.sum
+	}
+	n.sum = t
+}
+
+func (n *neumaier) total() float64 { return n.sum + n.c }
+
+func neumaierSum(values []float64, scale float64) float64 {
+	var n neumaier
+	for _, v := range values {
+		n.add(v * scale)
+	}
+	return n.total()
+}
+
+func neumaierSquares(values []float64, mean, scale float64) float64 {
+	var n neumaier
+	for _, v := range values {
+		d := v*scale - mean
+		n.add(d * d)
+	}
+	return n.total()
+}
+
+// sumScale returns the exponent of a power of two which brings magnitudes up to maxAbs below 1, so that neither
+// sums nor sums of squared deviations of hundreds of millions of values can overflow, along with its reciprocal
+// to scale values by. Scaling by a power of two is exact, so results are unchanged wherever they did not overflow.
+// Magnitudes already below 1 are left alone.
+func sumScale(maxAbs float64) (exp int, scale float64) {
+	_, exp = math.Frexp(maxAbs)
+	if exp <= 0 {
+		return 0, 1
+	}
+	return exp, math.Ldexp(1, -exp)
+}
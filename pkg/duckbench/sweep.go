@@ -0,0 +1,99 @@
+package duckbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Settings are DuckDB configuration options to run a benchmark under, each left at DuckDB's default if unset.
+type Settings struct {
+	Threads int
+	// MemoryLimit is a size such as 512MB or 4GB.
+	MemoryLimit string
+}
+
+func (s Settings) String() string {
+	var parts []string
+	if s.Threads > 0 {
+		parts = append(parts, fmt.Sprintf("threads=%d", s.Threads))
+	}
+	if s.MemoryLimit != "" {
+		parts = append(parts, "memory_limit="+s.MemoryLimit)
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s Settings) statements() []string {
+	var stmts []string
+	if s.Threads > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET threads = %d", s.Threads))
+	}
+	if s.MemoryLimit != "" {
+		stmts = append(stmts, fmt.Sprintf("SET memory_limit = '%s'", strings.ReplaceAll(s.MemoryLimit, "'", "''")))
+	}
+	return stmts
+}
+
+// SweepSettings returns every combination of threads and memory limits, either of which may be empty to leave it
+// at DuckDB's default.
+func SweepSettings(threads []int, memoryLimits []string) []Settings {
+	if len(threads) == 0 {
+		threads = []int{0}
+	}
+	if len(memoryLimits) == 0 {
+		memoryLimits = []string{""}
+	}
+	var settings []Settings
+	for _, t := range threads {
+		for _, m := range memoryLimits {
+			settings = append(settings, Settings{Threads: t, MemoryLimit: m})
+		}
+	}
+	return settings
+}
+
+// SweepResult is the run of a sweep under one of its settings.
+type SweepResult struct {
+	Settings Settings
+	Results  Results
+}
+
+// Sweep runs cfg under each of settings in turn, each in a fresh in-memory database. The records are generated
+// once, so every run calculates the statistics of the same records. Only DuckDB is reconfigured, so cfg.Threads
+// must be unset: the Go engine stays single-threaded, as the baseline the settings are compared against.
+func Sweep(ctx context.Context, cfg Config, settings []Settings) ([]SweepResult, error) {
+	if cfg.DB.Path != "" {
+		return nil, errors.New("sweeping settings needs an in-memory database")
+	}
+	if cfg.Threads > 0 {
+		return nil, errors.New("sweeping settings with Config.Threads set would override the threads swept")
+	}
+	if cfg.Records == nil {
+		n := cfg.N
+		if n == 0 {
+			n = DefaultN
+		}
+		records, err := cfg.generate(n)
+		if err != nil {
+			return nil, fmt.Errorf("generating records: %w", err)
+		}
+		cfg.Records = records
+	}
+	var sweep []SweepResult
+	for _, s := range settings {
+		run := cfg
+		run.DB.OnConnect = append(slices.Clip(cfg.DB.OnConnect), s.statements()...)
+		res, err := Run(ctx, run)
+		if err != nil {
+			return sweep, fmt.Errorf("running with %s: %w", s, err)
+		}
+		sweep = append(sweep, SweepResult{Settings: s, Results: res})
+	}
+	return sweep, nil
+}
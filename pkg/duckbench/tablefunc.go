@@ -0,0 +1,100 @@
+package duckbench
+
+import (
+	"sync/atomic"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+// recordsFuncRange is how many records each thread of a scan of RecordsFunc claims at a time, one data chunk.
+var recordsFuncRange = duckdb.GetDataChunkCapacity()
+
+// RecordsFunc is a DuckDB table function of records, for DBOptions.TableFuncs, which DuckDB queries in place, filling
+// its vectors a row at a time on each of its threads, with no insert. RecordsView presents it as the records table.
+func RecordsFunc(records []Record) duckdb.ParallelRowTableFunction {
+	return duckdb.ParallelRowTableFunction{
+		BindArguments: func(map[string]any, ...any) (duckdb.ParallelRowTableSource, error) {
+			return &recordsSource{records: records}, nil
+		},
+	}
+}
+
+// RecordsView returns the statement creating a view named records over the table function registered as name by
+// RecordsFunc, with the records table's columns and its ids numbered as AppenderInsert numbers them. go-duckdb's
+// Row.SetRowValue writes to the wrong vector once a query leaves some columns out, so the function cannot write
+// NULLs: it has a null column marking the NULL values instead, and gives the zero time for a NULL ts, which the view
+// turns back into NULLs.
+func RecordsView(name string) string {
+	return `CREATE VIEW records AS
+		SELECT id, CASE WHEN "null" THEN NULL ELSE value END AS value, value2, category,
+			NULLIF(ts, TIMESTAMP '0001-01-01') AS ts
+		FROM ` + name + "()"
+}
+
+// recordsSource is a scan of the records of RecordsFunc.
+type recordsSource struct {
+	records []Record
+	// claimed is how many records the threads have claimed.
+	claimed atomic.Int64
+}
+
+// recordsRange is the range of records a thread is reading.
+type recordsRange struct {
+	next, end int
+}
+
+func (s *recordsSource) ColumnInfos() []duckdb.ColumnInfo {
+	return []duckdb.ColumnInfo{
+		{Name: "id", T: typeInfo(duckdb.TYPE_INTEGER)},
+		{Name: "value", T: typeInfo(duckdb.TYPE_DOUBLE)},
+		{Name: "value2", T: typeInfo(duckdb.TYPE_DOUBLE)},
+		{Name: "category", T: typeInfo(duckdb.TYPE_VARCHAR)},
+		{Name: "ts", T: typeInfo(duckdb.TYPE_TIMESTAMP)},
+		{Name: "null", T: typeInfo(duckdb.TYPE_BOOLEAN)},
+	}
+}
+
+func (s *recordsSource) Cardinality() *duckdb.CardinalityInfo {
+	return &duckdb.CardinalityInfo{Cardinality: uint(len(s.records)), Exact: true}
+}
+
+func (s *recordsSource) Init() duckdb.ParallelTableSourceInfo {
+	return duckdb.ParallelTableSourceInfo{}
+}
+
+func (s *recordsSource) NewLocalState() any { return &recordsRange{} }
+
+// FillRow fills row from the next record of the thread's range, claiming another once it is read, and returns
+// false once every record has been claimed.
+func (s *recordsSource) FillRow(state any, row duckdb.Row) (bool, error) {
+	rng := state.(*recordsRange)
+	if rng.next == rng.end {
+		end := int(s.claimed.Add(int64(recordsFuncRange)))
+		start := end - recordsFuncRange
+		if start >= len(s.records) {
+			return false, nil
+		}
+		rng.next, rng.end = start, min(end, len(s.records))
+	}
+	r := s.records[rng.next]
+	rng.next++
+
+	// columns the query does not read are skipped
+	err := duckdb.SetRowValue(row, 0, int32(r.ID+1))
+	if err == nil {
+		err = duckdb.SetRowValue(row, 1, r.Value)
+	}
+	if err == nil {
+		err = duckdb.SetRowValue(row, 2, r.Value2)
+	}
+	if err == nil {
+		err = duckdb.SetRowValue(row, 3, r.Category)
+	}
+	if err == nil {
+		err = duckdb.SetRowValue(row, 4, r.Time)
+	}
+	if err == nil {
+		err = duckdb.SetRowValue(row, 5, r.Null)
+	}
+	return err == nil, err
+}
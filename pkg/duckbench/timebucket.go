@@ -0,0 +1,149 @@
+package duckbench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// TimeBuckets is the count and mean of the finite values of the timestamped records in each bucket of Width, such
+// as the per-minute averages of a DistTimeSeries: a map by bucket in Go, and time_bucket with GROUP BY in DuckDB.
+// Buckets are aligned to the Unix epoch in both.
+type TimeBuckets struct {
+	// Width is a minute if unset.
+	Width time.Duration
+}
+
+// BucketMean is the count and mean of the values in the bucket starting at Start.
+type BucketMean struct {
+	Start time.Time
+	Count int64
+	Mean  float64
+}
+
+// BucketMeans is the result of TimeBuckets, in time order.
+type BucketMeans []BucketMean
+
+func (b BucketMeans) LogValue() slog.Value {
+	if len(b) == 0 {
+		return slog.GroupValue(slog.Int("buckets", 0))
+	}
+	return slog.GroupValue(slog.Int("buckets", len(b)), slog.Time("first", b[0].Start), slog.Time("last", b[len(b)-1].Start))
+}
+
+func (t TimeBuckets) width() time.Duration {
+	if t.Width == 0 {
+		return time.Minute
+	}
+	return t.Width
+}
+
+func (t TimeBuckets) Name() string { return fmt.Sprintf("time_bucket/%v", t.width()) }
+
+func (t TimeBuckets) check() error {
+	if w := t.width(); w < time.Microsecond || w%time.Microsecond != 0 {
+		return fmt.Errorf("time bucket width %v is not a positive number of microseconds", w)
+	}
+	return nil
+}
+
+type bucketSum struct {
+	n   int64
+	sum float64
+}
+
+func (t TimeBuckets) Go(records []Record) (WorkloadResult, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+	width := t.width().Microseconds()
+	buckets := make(map[int64]*bucketSum)
+	for _, r := range records {
+		if r.Time.IsZero() || !finite(r.Value) {
+			continue
+		}
+		us := r.Time.UnixMicro()
+		// rounding down, before the epoch too
+		start := us - us%width
+		if us%width < 0 {
+			start -= width
+		}
+		b := buckets[start]
+		if b == nil {
+			b = new(bucketSum)
+			buckets[start] = b
+		}
+		b.n++
+		b.sum += r.Value
+	}
+	if len(buckets) == 0 {
+		return nil, ErrNoValues
+	}
+	means := make(BucketMeans, 0, len(buckets))
+	for start, b := range buckets {
+		means = append(means, BucketMean{Start: time.UnixMicro(start).UTC(), Count: b.n, Mean: b.sum / float64(b.n)})
+	}
+	slices.SortFunc(means, func(a, b BucketMean) int { return a.Start.Compare(b.Start) })
+	return means, nil
+}
+
+func (t TimeBuckets) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT time_bucket(INTERVAL '%d microseconds', ts, TIMESTAMP '1970-01-01') AS bucket, COUNT(*), AVG(value)
+		FROM records
+		WHERE ts IS NOT NULL AND isfinite(value)
+		GROUP BY bucket
+		ORDER BY bucket`, t.width().Microseconds()))
+	if err != nil {
+		return nil, fmt.Errorf("querying time buckets: %w", err)
+	}
+	defer rows.Close()
+	var means BucketMeans
+	for rows.Next() {
+		var b BucketMean
+		if err := rows.Scan(&b.Start, &b.Count, &b.Mean); err != nil {
+			return nil, fmt.Errorf("scanning time buckets: %w", err)
+		}
+		means = append(means, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading time buckets: %w", err)
+	}
+	if len(means) == 0 {
+		return nil, ErrNoValues
+	}
+	return means, nil
+}
+
+// Compare compares the buckets' starts and counts exactly, and their means within tol, up to the first bucket
+// which is missing from one of them.
+func (b BucketMeans) Compare(other WorkloadResult, tol floatcmp.Tolerance) []Mismatch {
+	o, ok := other.(BucketMeans)
+	if !ok {
+		return []Mismatch{{Stat: "type"}}
+	}
+	var mismatches []Mismatch
+	for i := 0; i < min(len(b), len(o)); i++ {
+		name := b[i].Start.Format(time.RFC3339Nano)
+		if !b[i].Start.Equal(o[i].Start) {
+			return append(mismatches, Mismatch{Stat: "bucket " + name, A: float64(b[i].Start.UnixMicro()), B: float64(o[i].Start.UnixMicro())})
+		}
+		if b[i].Count != o[i].Count {
+			mismatches = append(mismatches, Mismatch{Stat: "count of " + name, A: float64(b[i].Count), B: float64(o[i].Count)})
+		}
+		if !tol.Equal(b[i].Mean, o[i].Mean) {
+			mismatches = append(mismatches, Mismatch{Stat: "mean of " + name, A: b[i].Mean, B: o[i].Mean})
+		}
+	}
+	if len(b) != len(o) {
+		mismatches = append(mismatches, Mismatch{Stat: "buckets", A: float64(len(b)), B: float64(len(o))})
+	}
+	return mismatches
+}
@@ -0,0 +1,88 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestTimeBuckets(t *testing.T) {
+	ctx := context.Background()
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.DateTime, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	records := []Record{
+		{Value: 1, Time: at("2024-01-01 00:00:05")},
+		{Value: 3, Time: at("2024-01-01 00:00:59")},
+		{Value: math.NaN(), Time: at("2024-01-01 00:00:30")},
+		{Value: 10, Time: at("2024-01-01 00:02:00")},
+		{Value: 7, Time: at("1969-12-31 23:59:30")},
+		{Value: 100},
+	}
+	want := BucketMeans{
+		{Start: at("1969-12-31 23:59:00"), Count: 1, Mean: 7},
+		{Start: at("2024-01-01 00:00:00"), Count: 2, Mean: 2},
+		{Start: at("2024-01-01 00:02:00"), Count: 1, Mean: 10},
+	}
+	db := loadDB(t, records)
+	for engine, run := range map[string]func() (WorkloadResult, error){
+		"go":     func() (WorkloadResult, error) { return TimeBuckets{}.Go(records) },
+		"duckdb": func() (WorkloadResult, error) { return TimeBuckets{}.DuckDB(ctx, db) },
+	} {
+		got, err := run()
+		if err != nil {
+			t.Fatalf("%s: %v", engine, err)
+		}
+		if m := want.Compare(got, floatcmp.Exact); len(m) > 0 {
+			t.Errorf("%s: %+v, mismatches %+v", engine, got, m)
+		}
+	}
+	if _, err := (TimeBuckets{}).Go(recordsOf(1, 2)); err != ErrNoValues {
+		t.Errorf("no timestamps: err = %v, want ErrNoValues", err)
+	}
+}
+
+func TestRunTimeSeries(t *testing.T) {
+	// an hour of readings, through every insert path
+	res, err := Run(context.Background(), Config{N: 3600, Distribution: DistTimeSeries,
+		Inserts:   []InsertStrategy{InsertStandard, InsertAppender, InsertValues, InsertCSV},
+		Workloads: []Workload{TimeBuckets{Width: time.Minute}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := res.Workloads[0]
+	if got := len(w.GoResult.(BucketMeans)); got != 60 {
+		t.Errorf("%d buckets, want 60", got)
+	}
+	if m := w.Mismatches(floatcmp.Rel(1e-9)); len(m) > 0 {
+		t.Errorf("go and duckdb time buckets differ: %+v", m)
+	}
+}
+
+func TestSaveDatasetTimestamps(t *testing.T) {
+	ctx := context.Background()
+	records := GenerateTimeSeries(100, TimeSeries{Start: time.Date(2024, 2, 29, 23, 59, 59, 123456000, time.UTC), Interval: 1500 * time.Microsecond}, 1)
+	records[3].Time = time.Time{}
+	for _, ext := range []string{".csv", ".parquet"} {
+		path := t.TempDir() + "/records" + ext
+		if err := SaveDataset(ctx, records, path); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadDataset(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range records {
+			if !got[i].Time.Equal(records[i].Time) {
+				t.Fatalf("%s: record %d read back at %v, want %v", ext, i, got[i].Time, records[i].Time)
+			}
+		}
+	}
+}
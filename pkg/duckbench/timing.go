@@ -0,0 +1,90 @@
+package duckbench
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+)
+
+// Timing summarises the samples of a repeated step.
+type Timing struct {
+	Median, StdDev, Min, Max time.Duration
+}
+
+func (t Timing) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Duration("median", t.Median),
+		slog.Duration("stddev", t.StdDev),
+		slog.Duration("min", t.Min),
+		slog.Duration("max", t.Max),
+	)
+}
+
+// SummarizeTimings returns the median, population standard deviation and range of samples.
+func SummarizeTimings(samples []time.Duration) Timing {
+	if len(samples) == 0 {
+		return Timing{}
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	seconds := make([]float64, len(sorted))
+	var mean float64
+	for i, d := range sorted {
+		seconds[i] = d.Seconds()
+		mean += seconds[i]
+	}
+	mean /= float64(len(seconds))
+	var squares float64
+	for _, s := range seconds {
+		squares += (s - mean) * (s - mean)
+	}
+	return Timing{
+		Median: time.Duration(sortedMedian(seconds) * float64(time.Second)),
+		StdDev: time.Duration(math.Sqrt(squares/float64(len(seconds))) * float64(time.Second)),
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// Timing summarises the samples of r, or is just its Duration if it was only measured once.
+func (r BenchmarkResult) Timing() Timing {
+	if len(r.Samples) == 0 {
+		return Timing{Median: r.Duration, Min: r.Duration, Max: r.Duration}
+	}
+	return SummarizeTimings(r.Samples)
+}
+
+// iterations is how many times each step of a run is measured, including the warmup.
+func (cfg *Config) iterations() int {
+	return cfg.Warmup + cfg.Repeat
+}
+
+// measure times fn cfg.Warmup times, discarding the timings, and then cfg.Repeat times, calling setup untimed
+// before every run. The result's Duration is the median of the samples kept. It stops early if ctx is done.
+func (cfg *Config) measure(ctx context.Context, name string, rows int, setup, fn func() error) (BenchmarkResult, error) {
+	res := BenchmarkResult{Name: name, Rows: rows}
+	for i := 0; i < cfg.iterations(); i++ {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if setup != nil {
+			if err := setup(); err != nil {
+				return res, err
+			}
+		}
+		run, err := Measure(name, rows, fn)
+		if err != nil {
+			return res, err
+		}
+		if i >= cfg.Warmup {
+			res.Samples = append(res.Samples, run.Duration)
+		}
+	}
+	res.Duration = SummarizeTimings(res.Samples).Median
+	if len(res.Samples) == 1 {
+		res.Samples = nil
+	}
+	return res, nil
+}
@@ -0,0 +1,143 @@
+package duckbench
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// TopK is the K most frequent finite values, most frequent first and ties broken by the smaller value: a map of
+// counts and a heap of size K in Go, and GROUP BY with ORDER BY and LIMIT in DuckDB.
+type TopK struct {
+	K int
+}
+
+// ValueCount is how many times a value occurs.
+type ValueCount struct {
+	Value float64
+	Count int64
+}
+
+// TopValues is the result of TopK, most frequent first.
+type TopValues []ValueCount
+
+func (t TopValues) LogValue() slog.Value {
+	if len(t) == 0 {
+		return slog.GroupValue(slog.Int("values", 0))
+	}
+	return slog.GroupValue(slog.Int("values", len(t)), slog.Float64("top", t[0].Value), slog.Int64("count", t[0].Count))
+}
+
+func (t TopK) Name() string { return fmt.Sprintf("topk/%d", t.K) }
+
+func (t TopK) check() error {
+	if t.K < 1 {
+		return fmt.Errorf("top-k needs k of at least one, not %d", t.K)
+	}
+	return nil
+}
+
+// before reports whether a ranks before b.
+func (a ValueCount) before(b ValueCount) bool {
+	return a.Count > b.Count || a.Count == b.Count && a.Value < b.Value
+}
+
+// topHeap is a min-heap with the lowest ranked of the values kept so far at its root.
+type topHeap []ValueCount
+
+func (h topHeap) Len() int           { return len(h) }
+func (h topHeap) Less(i, j int) bool { return h[j].before(h[i]) }
+func (h topHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *topHeap) Push(x any)        { *h = append(*h, x.(ValueCount)) }
+func (h *topHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func (t TopK) Go(records []Record) (WorkloadResult, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+	counts := make(map[float64]int64)
+	for _, r := range records {
+		if finite(r.Value) {
+			counts[r.Value]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil, ErrNoValues
+	}
+	h := make(topHeap, 0, t.K+1)
+	for v, n := range counts {
+		vc := ValueCount{Value: v, Count: n}
+		if len(h) < t.K {
+			heap.Push(&h, vc)
+		} else if vc.before(h[0]) {
+			h[0] = vc
+			heap.Fix(&h, 0)
+		}
+	}
+	top := make(TopValues, len(h))
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(&h).(ValueCount)
+	}
+	return top, nil
+}
+
+func (t TopK) DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT value, COUNT(*) AS n
+		FROM records
+		WHERE isfinite(value)
+		GROUP BY value
+		ORDER BY n DESC, value
+		LIMIT ?`, t.K)
+	if err != nil {
+		return nil, fmt.Errorf("querying top values: %w", err)
+	}
+	defer rows.Close()
+	var top TopValues
+	for rows.Next() {
+		var vc ValueCount
+		if err := rows.Scan(&vc.Value, &vc.Count); err != nil {
+			return nil, fmt.Errorf("scanning top values: %w", err)
+		}
+		top = append(top, vc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading top values: %w", err)
+	}
+	if len(top) == 0 {
+		return nil, ErrNoValues
+	}
+	return top, nil
+}
+
+// Compare compares the values at each rank within tol, and their counts exactly.
+func (t TopValues) Compare(other WorkloadResult, tol floatcmp.Tolerance) []Mismatch {
+	o, ok := other.(TopValues)
+	if !ok {
+		return []Mismatch{{Stat: "type"}}
+	}
+	if len(t) != len(o) {
+		return []Mismatch{{Stat: "values", A: float64(len(t)), B: float64(len(o))}}
+	}
+	var mismatches []Mismatch
+	for i := range t {
+		if !tol.Equal(t[i].Value, o[i].Value) {
+			mismatches = append(mismatches, Mismatch{Stat: fmt.Sprintf("value %d", i+1), A: t[i].Value, B: o[i].Value})
+		}
+		if t[i].Count != o[i].Count {
+			mismatches = append(mismatches, Mismatch{Stat: fmt.Sprintf("count %d", i+1), A: float64(t[i].Count), B: float64(o[i].Count)})
+		}
+	}
+	return mismatches
+}
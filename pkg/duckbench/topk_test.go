@@ -0,0 +1,58 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestTopK(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]struct {
+		records []Record
+		k       int
+		want    TopValues
+	}{
+		"frequency": {recordsOf(3, 1, 3, 2, 3, 1, math.NaN(), math.NaN(), math.NaN(), math.NaN()), 2, TopValues{{3, 3}, {1, 2}}},
+		"ties":      {recordsOf(5, 4, 3, 2, 1), 3, TopValues{{1, 1}, {2, 1}, {3, 1}}},
+		"fewer":     {recordsOf(7, 7, 8), 10, TopValues{{7, 2}, {8, 1}}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := TopK{K: tt.k}
+			db := loadDB(t, tt.records)
+			for engine, run := range map[string]func() (WorkloadResult, error){
+				"go":     func() (WorkloadResult, error) { return w.Go(tt.records) },
+				"duckdb": func() (WorkloadResult, error) { return w.DuckDB(ctx, db) },
+			} {
+				got, err := run()
+				if err != nil {
+					t.Fatalf("%s: %v", engine, err)
+				}
+				if m := tt.want.Compare(got, floatcmp.Exact); len(m) > 0 {
+					t.Errorf("%s: %+v, mismatches %+v", engine, got, m)
+				}
+			}
+		})
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	ctx := context.Background()
+	records := recordsOf(1, 2, 2, 3, 3, 3, math.NaN(), math.Inf(1))
+	db := loadDB(t, records)
+	for engine, run := range map[string]func() (WorkloadResult, error){
+		"go":     func() (WorkloadResult, error) { return Distinct{}.Go(records) },
+		"duckdb": func() (WorkloadResult, error) { return Distinct{}.DuckDB(ctx, db) },
+	} {
+		got, err := run()
+		if err != nil {
+			t.Fatalf("%s: %v", engine, err)
+		}
+		if got != DistinctCount(3) {
+			t.Errorf("%s: %v distinct values, want 3", engine, got)
+		}
+	}
+}
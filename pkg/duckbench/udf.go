@@ -0,0 +1,107 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+// registerFuncs registers the scalar and table functions of opts with the database of connector. go-duckdb
+// registers a function through the driver connection of a sql.Conn, which must be its own rather than one wrapped by
+// sqlwrap, so they are registered on a connection opened straight from connector. DuckDB adds functions to the
+// database's catalog, where every connection sees them.
+func registerFuncs(ctx context.Context, connector driver.Connector, opts DBOptions) error {
+	if len(opts.ScalarFuncs) == 0 && len(opts.TableFuncs) == 0 {
+		return nil
+	}
+	// hiding Close keeps closing db from closing the database
+	db := sql.OpenDB(struct{ driver.Connector }{connector})
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("opening connection: %w", err)
+	}
+	defer conn.Close()
+	for name, f := range opts.ScalarFuncs {
+		if err := duckdb.RegisterScalarUDF(conn, name, f); err != nil {
+			return fmt.Errorf("registering function %s: %w", name, err)
+		}
+	}
+	for name, f := range opts.TableFuncs {
+		if err := duckdb.RegisterTableUDF(conn, name, f); err != nil {
+			return fmt.Errorf("registering table function %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// typeInfo returns the TypeInfo of a DuckDB type without parameters, which go-duckdb only fails to create for
+// those with.
+func typeInfo(t duckdb.Type) duckdb.TypeInfo {
+	info, err := duckdb.NewTypeInfo(t)
+	if err != nil {
+		panic(err)
+	}
+	return info
+}
+
+// DoubleFunc is a DuckDB scalar function of a DOUBLE calling fn, for DBOptions.ScalarFuncs. NULLs are passed through
+// without calling it.
+func DoubleFunc(fn func(float64) float64) duckdb.ScalarFunc {
+	return &doubleFunc{fn}
+}
+
+type doubleFunc struct {
+	fn func(float64) float64
+}
+
+func (f *doubleFunc) Config() duckdb.ScalarFuncConfig {
+	double := typeInfo(duckdb.TYPE_DOUBLE)
+	return duckdb.ScalarFuncConfig{InputTypeInfos: []duckdb.TypeInfo{double}, ResultTypeInfo: double}
+}
+
+func (f *doubleFunc) Executor() duckdb.ScalarFuncExecutor {
+	return duckdb.ScalarFuncExecutor{RowExecutor: func(values []driver.Value) (any, error) {
+		return f.fn(values[0].(float64)), nil
+	}}
+}
+
+// SignedLog is the transformation of the UDF example: the logarithm of one more than the magnitude of v, with its
+// sign, which compresses long tails while keeping zero and the order of the values.
+func SignedLog(v float64) float64 {
+	return math.Copysign(math.Log1p(math.Abs(v)), v)
+}
+
+// SignedLogSQL is SignedLog as a DuckDB expression of value.
+const SignedLogSQL = "sign(value) * ln(1 + abs(value))"
+
+// SumOf returns the sum of expr, an expression of the records table's columns, over its rows, skipping NULLs.
+func SumOf(ctx context.Context, db Queryer, expr string) (float64, error) {
+	row, err := sqlscan.One[sumRow](ctx, db, "SELECT SUM("+expr+") AS sum FROM records")
+	if err != nil {
+		return 0, fmt.Errorf("summing %s: %w", expr, err)
+	}
+	return row.Sum.Or(0), nil
+}
+
+type sumRow struct {
+	Sum nullable.Nullable[float64] `db:"sum"`
+}
+
+// SumFunc returns the sum of fn of the values of records in Go, skipping NULLs.
+func SumFunc(records []Record, fn func(float64) float64) float64 {
+	sum := 0.0
+	for _, r := range records {
+		if !r.Null {
+			sum += fn(r.Value)
+		}
+	}
+	return sum
+}
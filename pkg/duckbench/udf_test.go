@@ -0,0 +1,97 @@
+package duckbench
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+)
+
+// TestScalarFunc checks that SignedLog registered as a scalar function, SignedLogSQL and SumFunc agree, on every
+// connection of the pool and with NULLs skipped.
+func TestScalarFunc(t *testing.T) {
+	ctx := context.Background()
+	records, _ := GenerateDistribution(5000, DistNormal, 1)
+	InjectNulls(records, 0.1, 1)
+	db, err := CreateDB(ctx, DBOptions{
+		// a hook wraps the connections, which go-duckdb cannot register functions through
+		Hooks:       []sqlwrap.Hook{sqlwrap.AfterFunc(func(context.Context, *sqlwrap.Event) {})},
+		ScalarFuncs: map[string]duckdb.ScalarFunc{"signed_log": DoubleFunc(SignedLog)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := CreateRecordsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppenderInsert(ctx, records, db); err != nil {
+		t.Fatal(err)
+	}
+
+	want := SumFunc(records, SignedLog)
+	conns := make([]Queryer, 3)
+	for i := range conns {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conns[i] = conn
+	}
+	for i, conn := range conns {
+		for _, expr := range []string{"signed_log(value)", SignedLogSQL} {
+			got, err := SumOf(ctx, conn, expr)
+			if err != nil {
+				t.Fatalf("connection %d: %v", i, err)
+			}
+			if math.Abs(got-want) > 1e-9*math.Abs(want) {
+				t.Errorf("connection %d: SUM(%s) = %v, want %v", i, expr, got, want)
+			}
+		}
+	}
+	if _, err := SumOf(ctx, db, "signed_log(category)"); err == nil {
+		t.Error("signed_log of a VARCHAR succeeded")
+	}
+}
+
+// TestRecordsFunc checks that a view over RecordsFunc holds exactly the records, as an inserted table would, and
+// that DuckDB calculates the same statistics over it as Go does.
+func TestRecordsFunc(t *testing.T) {
+	ctx := context.Background()
+	// more records than a data chunk, so several threads claim ranges of them
+	records, _ := GenerateDistribution(20000, DistTimeSeries, 1)
+	AssignCategories(records, 5, 1)
+	InjectNulls(records, 0.1, 1)
+	db, err := CreateDB(ctx, DBOptions{TableFuncs: map[string]duckdb.ParallelRowTableFunction{
+		"go_records": RecordsFunc(records),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, RecordsView("go_records")); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := VerifyIngestion(ctx, db, records); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := StatisticsFromRecords(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := StatisticsFromDB(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStats(t, "go_records", got, want)
+	if got.Nulls != want.Nulls {
+		t.Errorf("counted %d NULLs, want %d", got.Nulls, want.Nulls)
+	}
+}
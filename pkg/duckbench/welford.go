@@ -0,0 +1,70 @@
+package duckbench
+
+import "math"
+
+// Welford accumulates the mean, standard deviation, min and max of values one at a time, with Welford's single-pass
+// algorithm, so the values need never be held in memory. Without them the median and percentiles cannot be found,
+// and unlike StatisticsWithSummation nothing is scaled, so the sum of squares overflows for magnitudes beyond about
+// 1e154. The zero value has seen no values.
+type Welford struct {
+	n, nonFinite, nulls int64
+	mean, m2            float64
+	min, max            float64
+}
+
+// Add accumulates v, counting it in NonFinite instead if it is NaN or infinite.
+func (w *Welford) Add(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		w.nonFinite++
+		return
+	}
+	w.n++
+	if w.n == 1 {
+		w.min, w.max = v, v
+	}
+	delta := v - w.mean
+	w.mean += delta / float64(w.n)
+	w.m2 += delta * (v - w.mean)
+	w.min, w.max = math.Min(w.min, v), math.Max(w.max, v)
+}
+
+// AddNull counts a NULL value, which is otherwise skipped.
+func (w *Welford) AddNull() { w.nulls++ }
+
+// addRecord adds the value of r, or counts it as NULL.
+func (w *Welford) addRecord(r Record) {
+	if r.Null {
+		w.AddNull()
+		return
+	}
+	w.Add(r.Value)
+}
+
+// Count returns how many finite values have been added.
+func (w *Welford) Count() int64 { return w.n }
+
+// Stats returns the statistics of the values added so far. The median cannot be calculated in a single pass, so
+// is NaN.
+func (w *Welford) Stats() (Stats, error) {
+	if w.n == 0 {
+		return Stats{NonFinite: w.nonFinite, Nulls: w.nulls}, ErrNoValues
+	}
+	return Stats{
+		Mean:      w.mean,
+		Median:    math.NaN(),
+		StdDev:    math.Sqrt(w.m2 / float64(w.n)),
+		Min:       w.min,
+		Max:       w.max,
+		NonFinite: w.nonFinite,
+		Nulls:     w.nulls,
+	}, nil
+}
+
+// StreamingStatistics is the single-pass alternative to StatisticsFromRecords, accumulating records with Welford.
+func StreamingStatistics(records []Record) (Stats, error) {
+	var w Welford
+	for _, r := range records {
+		w.addRecord(r)
+	}
+	return w.Stats()
+}
@@ -0,0 +1,99 @@
+package duckbench
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// A Workload is an analysis beyond the summary statistics, run both in Go on the records in memory and in DuckDB on
+// the records table, so their timings and their results can be compared.
+type Workload interface {
+	Name() string
+	Go(records []Record) (WorkloadResult, error)
+	DuckDB(ctx context.Context, db Queryer) (WorkloadResult, error)
+}
+
+// WorkloadResult is what one engine calculated for a Workload.
+type WorkloadResult interface {
+	// Compare returns the values on which other, the result of the same workload from another engine, differs by
+	// more than tol.
+	Compare(other WorkloadResult, tol floatcmp.Tolerance) []Mismatch
+}
+
+// An Approximation is a Workload whose engines only estimate the result, such as with a sketch, so that each
+// estimate is checked against the exact result rather than against the other engine's. The results of its engines
+// must be Estimates.
+type Approximation interface {
+	Workload
+	// Exact calculates the exact result, untimed.
+	Exact(records []Record) (WorkloadResult, error)
+	// Accuracy is the largest Error of an estimate which is acceptable.
+	Accuracy() float64
+}
+
+// An Estimate is the result of an engine running an Approximation.
+type Estimate interface {
+	WorkloadResult
+	// Error is how far the estimate is from the exact result, in whatever terms suit the workload.
+	Error(exact WorkloadResult) float64
+}
+
+// WorkloadRun is the timings and results of both engines running one of Config.Workloads.
+type WorkloadRun struct {
+	Name               string
+	Go, DuckDB         BenchmarkResult
+	GoResult, DBResult WorkloadResult
+	// Exact, for an Approximation, is the exact result, with the Error of each engine's estimate from it and the
+	// Accuracy they are allowed.
+	Exact            WorkloadResult
+	GoError, DBError float64
+	Accuracy         float64
+}
+
+// Mismatches returns the values on which the engines differ by more than tol or, for an Approximation, the engines
+// whose estimates are less accurate than it allows.
+func (w WorkloadRun) Mismatches(tol floatcmp.Tolerance) []Mismatch {
+	if w.Exact == nil {
+		return w.GoResult.Compare(w.DBResult, tol)
+	}
+	var mismatches []Mismatch
+	if w.GoError > w.Accuracy {
+		mismatches = append(mismatches, Mismatch{Stat: "go error", A: w.GoError, B: w.Accuracy})
+	}
+	if w.DBError > w.Accuracy {
+		mismatches = append(mismatches, Mismatch{Stat: "duckdb error", A: w.DBError, B: w.Accuracy})
+	}
+	return mismatches
+}
+
+// workload times w in Go and then in DuckDB.
+func (cfg *Config) workload(ctx context.Context, db *sql.DB, records []Record, w Workload) (WorkloadRun, error) {
+	run := WorkloadRun{Name: w.Name()}
+	var err error
+	run.Go, err = cfg.measure(ctx, "go", len(records), nil, func() (err error) {
+		run.GoResult, err = w.Go(records)
+		return err
+	})
+	if err != nil {
+		return run, fmt.Errorf("in Go: %w", err)
+	}
+	run.DuckDB, err = cfg.measure(ctx, "duckdb", len(records), nil, func() (err error) {
+		run.DBResult, err = w.DuckDB(ctx, db)
+		return err
+	})
+	if err != nil {
+		return run, fmt.Errorf("in DuckDB: %w", err)
+	}
+	if a, ok := w.(Approximation); ok {
+		if run.Exact, err = a.Exact(records); err != nil {
+			return run, fmt.Errorf("exactly: %w", err)
+		}
+		run.GoError = run.GoResult.(Estimate).Error(run.Exact)
+		run.DBError = run.DBResult.(Estimate).Error(run.Exact)
+		run.Accuracy = a.Accuracy()
+	}
+	return run, nil
+}
@@ -0,0 +1,171 @@
+// Package flamegraph turns CPU profiles into folded stacks and flame graph SVGs, optionally split by the value of a
+// pprof label such as the benchmark phase.
+package flamegraph
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+// Stacks maps a folded stack, outermost frame first and separated by semicolons, to its total sample value.
+type Stacks map[string]int64
+
+// Fold folds the samples of p by the value of their label, using the last sample type (CPU time, for a CPU
+// profile). Samples without the label are grouped under "". With an empty label every sample is in one group.
+func Fold(p *profile.Profile, label string) map[string]Stacks {
+	groups := make(map[string]Stacks)
+	valueIndex := len(p.SampleType) - 1
+	for _, s := range p.Sample {
+		group := ""
+		if label != "" {
+			if values := s.Label[label]; len(values) > 0 {
+				group = values[0]
+			}
+		}
+		var frames []string
+		for i := len(s.Location) - 1; i >= 0; i-- {
+			lines := s.Location[i].Line
+			// the last line is the function the preceding ones were inlined into
+			for j := len(lines) - 1; j >= 0; j-- {
+				name := "?"
+				if lines[j].Function != nil {
+					name = lines[j].Function.Name
+				}
+				frames = append(frames, strings.ReplaceAll(name, ";", ":"))
+			}
+		}
+		if len(frames) == 0 {
+			continue
+		}
+		if groups[group] == nil {
+			groups[group] = make(Stacks)
+		}
+		groups[group][strings.Join(frames, ";")] += s.Value[valueIndex]
+	}
+	return groups
+}
+
+// Total returns the sum of all sample values.
+func (s Stacks) Total() int64 {
+	var total int64
+	for _, v := range s {
+		total += v
+	}
+	return total
+}
+
+// WriteFolded writes one "stack value" line per stack, the input format of flamegraph.pl and most other tools.
+func (s Stacks) WriteFolded(w io.Writer) error {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s %d\n", k, s[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type node struct {
+	name     string
+	value    int64
+	children map[string]*node
+}
+
+func (s Stacks) tree() *node {
+	root := &node{name: "all", children: make(map[string]*node)}
+	for stack, v := range s {
+		n := root
+		n.value += v
+		for _, frame := range strings.Split(stack, ";") {
+			child, ok := n.children[frame]
+			if !ok {
+				child = &node{name: frame, children: make(map[string]*node)}
+				n.children[frame] = child
+			}
+			child.value += v
+			n = child
+		}
+	}
+	return root
+}
+
+func (n *node) depth() int {
+	d := 0
+	for _, c := range n.children {
+		d = max(d, c.depth())
+	}
+	return d + 1
+}
+
+const (
+	svgWidth    = 1200.0
+	frameHeight = 16.0
+	titleHeight = 24.0
+	minWidth    = 0.5
+)
+
+// WriteSVG renders the stacks as a self-contained flame graph, with the root at the bottom and a tooltip on each
+// frame giving its share of the total.
+func (s Stacks) WriteSVG(w io.Writer, title string) error {
+	root := s.tree()
+	height := titleHeight + float64(root.depth())*frameHeight
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" font-family="monospace" font-size="11">`+"\n", svgWidth, height)
+	fmt.Fprintf(&b, `<text x="%g" y="16" text-anchor="middle" font-size="14">%s</text>`+"\n", svgWidth/2, html.EscapeString(title))
+	if root.value > 0 {
+		scale := svgWidth / float64(root.value)
+		var draw func(n *node, x float64, level int)
+		draw = func(n *node, x float64, level int) {
+			width := float64(n.value) * scale
+			if width < minWidth {
+				return
+			}
+			y := height - float64(level+1)*frameHeight
+			pct := 100 * float64(n.value) / float64(root.value)
+			name := html.EscapeString(n.name)
+			fmt.Fprintf(&b, `<g><title>%s (%.2f%%)</title><rect x="%.2f" y="%.2f" width="%.2f" height="%g" fill="%s" rx="2"/>`,
+				name, pct, x, y, width, frameHeight-1, color(n.name))
+			if chars := int(width / 7); chars >= 3 {
+				label := n.name
+				if len(label) > chars {
+					label = label[:chars-2] + ".."
+				}
+				fmt.Fprintf(&b, `<text x="%.2f" y="%.2f">%s</text>`, x+3, y+frameHeight-4, html.EscapeString(label))
+			}
+			b.WriteString("</g>\n")
+
+			names := make([]string, 0, len(n.children))
+			for name := range n.children {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				child := n.children[name]
+				draw(child, x, level+1)
+				x += float64(child.value) * scale
+			}
+		}
+		draw(root, 0, 0)
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// color picks a stable warm colour per function name, as flamegraph.pl does.
+func color(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	v := h.Sum32()
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+v%50, 80+(v>>8)%130, (v>>16)%55)
+}
@@ -0,0 +1,70 @@
+// Package floatcmp compares floating point results with absolute, relative and ULP-based tolerances.
+package floatcmp
+
+import "math"
+
+// Tolerance says how far apart two values may be and still be considered equal. Values are equal if they are
+// within any of the tolerances which are set, so the zero Tolerance only accepts identical values. An absolute
+// tolerance suits results near zero, where relative error is meaningless; a relative or ULP tolerance suits results
+// whose error grows with their magnitude.
+type Tolerance struct {
+	Abs float64
+	Rel float64
+	ULP uint64
+}
+
+// Exact accepts only identical values (and -0 for 0).
+var Exact = Tolerance{}
+
+func Abs(eps float64) Tolerance { return Tolerance{Abs: eps} }
+func Rel(eps float64) Tolerance { return Tolerance{Rel: eps} }
+func ULPs(n uint64) Tolerance   { return Tolerance{ULP: n} }
+
+// Equal reports whether a and b are within t of each other. NaN is equal to NaN, and infinities only to
+// themselves, so results from engines which agree on propagating non-finite values compare equal.
+func (t Tolerance) Equal(a, b float64) bool {
+	switch {
+	case a == b:
+		return true
+	case math.IsNaN(a) || math.IsNaN(b):
+		return math.IsNaN(a) && math.IsNaN(b)
+	case math.IsInf(a, 0) || math.IsInf(b, 0):
+		return false
+	}
+	return AbsDiff(a, b) <= t.Abs || RelDiff(a, b) <= t.Rel || ULPDiff(a, b) <= t.ULP
+}
+
+// AbsDiff returns |a - b|.
+func AbsDiff(a, b float64) float64 {
+	return math.Abs(a - b)
+}
+
+// RelDiff returns |a - b| relative to the larger magnitude of a and b, or 0 if both are 0.
+func RelDiff(a, b float64) float64 {
+	if a == b {
+		return 0
+	}
+	return math.Abs(a-b) / math.Max(math.Abs(a), math.Abs(b))
+}
+
+// ULPDiff returns the number of representable float64 values between a and b. It is the maximum uint64 if either
+// is NaN.
+func ULPDiff(a, b float64) uint64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.MaxUint64
+	}
+	x, y := ordered(a), ordered(b)
+	if x < y {
+		x, y = y, x
+	}
+	return uint64(x) - uint64(y)
+}
+
+// ordered maps the bits of f to an integer which orders the same way as f does, with -0 and 0 both mapped to 0.
+func ordered(f float64) int64 {
+	b := int64(math.Float64bits(f))
+	if b < 0 {
+		b = math.MinInt64 - b
+	}
+	return b
+}
@@ -0,0 +1,104 @@
+package floatcmp
+
+import (
+	"math"
+	"testing"
+)
+
+var (
+	nan         = math.NaN()
+	inf         = math.Inf(1)
+	negZero     = math.Copysign(0, -1)
+	subnormal   = math.SmallestNonzeroFloat64
+	nextAfter1  = math.Nextafter(1, 2)
+	twoAfter1   = math.Nextafter(nextAfter1, 2)
+	maxSubnorm  = math.Float64frombits(1<<52 - 1)
+	minNormal   = math.Float64frombits(1 << 52)
+	onePlusNano = 1 + 1e-9
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		tol  Tolerance
+		a, b float64
+		want bool
+	}{
+		{"identical", Exact, 1.5, 1.5, true},
+		{"different", Exact, 1, nextAfter1, false},
+		{"zero and negative zero", Exact, 0, negZero, true},
+		{"NaN and NaN", Exact, nan, nan, true},
+		{"NaN and a number", Abs(inf), nan, 1, false},
+		{"NaN and infinity", Rel(1), nan, inf, false},
+		{"infinity and itself", Exact, inf, inf, true},
+		{"infinity and negative infinity", Abs(inf), inf, -inf, false},
+		{"infinity and the largest float", Rel(1), inf, math.MaxFloat64, false},
+
+		{"abs within", Abs(1e-3), 1e-12, -1e-12, true},
+		{"abs outside", Abs(1e-3), 0, 2e-3, false},
+		{"abs subnormal", Abs(1e-300), subnormal, 0, true},
+		{"abs opposite sign", Abs(1), 0.4, -0.4, true},
+		{"abs opposite sign outside", Abs(1), 0.6, -0.6, false},
+
+		{"rel within", Rel(1e-9), 1e9, 1e9 + 1, true},
+		{"rel outside", Rel(1e-9), 1, 1 + 1e-8, false},
+		{"rel at the boundary", Rel(1e-9), 1, onePlusNano, RelDiff(1, onePlusNano) <= 1e-9},
+		// relative to the larger magnitude, a subnormal is as far from zero as 1 is
+		{"rel subnormal and zero", Rel(0.5), subnormal, 0, false},
+		{"rel subnormals", Rel(1e-15), maxSubnorm, minNormal, true},
+		{"rel opposite sign", Rel(1), 1, -1, false},
+		{"rel opposite sign within", Rel(2), 1, -1, true},
+
+		{"ulp next", ULPs(1), 1, nextAfter1, true},
+		{"ulp two apart", ULPs(1), 1, twoAfter1, false},
+		{"ulp subnormal and zero", ULPs(1), subnormal, 0, true},
+		{"ulp subnormal and negative zero", ULPs(1), negZero, subnormal, true},
+		{"ulp across zero", ULPs(1), -subnormal, subnormal, false},
+		{"ulp across zero within", ULPs(2), -subnormal, subnormal, true},
+		{"ulp largest subnormal to smallest normal", ULPs(1), maxSubnorm, minNormal, true},
+
+		{"any tolerance accepts", Tolerance{Abs: 1e-20, ULP: 1}, 1, nextAfter1, true},
+	}
+	for _, tt := range tests {
+		if got := tt.tol.Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: %+v.Equal(%v, %v) = %t, want %t", tt.name, tt.tol, tt.a, tt.b, got, tt.want)
+		}
+		if got := tt.tol.Equal(tt.b, tt.a); got != tt.want {
+			t.Errorf("%s: %+v.Equal(%v, %v) = %t, want %t as the other way round", tt.name, tt.tol, tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestDiffs(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     float64
+		abs, rel float64
+		ulp      uint64
+	}{
+		{"equal", 2, 2, 0, 0, 0},
+		{"zeros", 0, negZero, 0, 0, 0},
+		{"next", 1, nextAfter1, nextAfter1 - 1, (nextAfter1 - 1) / nextAfter1, 1},
+		{"subnormal", subnormal, 0, subnormal, 1, 1},
+		{"opposite sign", 1, -1, 2, 2, 2 * math.Float64bits(1)},
+		{"opposite sign subnormals", -subnormal, subnormal, 2 * subnormal, 2, 2},
+		{"largest floats", -math.MaxFloat64, math.MaxFloat64, inf, inf, 2 * math.Float64bits(math.MaxFloat64)},
+	}
+	for _, tt := range tests {
+		if got := AbsDiff(tt.a, tt.b); got != tt.abs {
+			t.Errorf("%s: AbsDiff(%v, %v) = %v, want %v", tt.name, tt.a, tt.b, got, tt.abs)
+		}
+		if got := RelDiff(tt.a, tt.b); got != tt.rel {
+			t.Errorf("%s: RelDiff(%v, %v) = %v, want %v", tt.name, tt.a, tt.b, got, tt.rel)
+		}
+		if got := ULPDiff(tt.a, tt.b); got != tt.ulp {
+			t.Errorf("%s: ULPDiff(%v, %v) = %d, want %d", tt.name, tt.a, tt.b, got, tt.ulp)
+		}
+	}
+	if got := ULPDiff(nan, 1); got != math.MaxUint64 {
+		t.Errorf("ULPDiff(NaN, 1) = %d, want the maximum", got)
+	}
+	if got := RelDiff(nan, 1); !math.IsNaN(got) {
+		t.Errorf("RelDiff(NaN, 1) = %v, want NaN", got)
+	}
+}
@@ -0,0 +1,45 @@
+// Package logging configures log/slog consistently for the commands in this repository.
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+// Args are the logging flags shared by every command, for embedding in its go-arg arguments, or adding to a Cobra
+// command's flags with AddFlags.
+type Args struct {
+	LogFormat string     `arg:"--log-format" default:"text" help:"log output format: text or json"`
+	LogLevel  slog.Level `arg:"--log-level" default:"info" help:"minimum level to log: debug, info, warn or error"`
+}
+
+// AddFlags adds the flags to fs, with the same defaults as the go-arg tags.
+func (a *Args) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&a.LogFormat, "log-format", "text", "log output format: text or json")
+	fs.TextVar(&a.LogLevel, "log-level", slog.LevelInfo, "minimum `level` to log: debug, info, warn or error")
+}
+
+// Setup installs the default slog logger, writing to stderr in the configured format.
+func (a Args) Setup() error {
+	opts := &slog.HandlerOptions{Level: a.LogLevel}
+	var h slog.Handler
+	switch a.LogFormat {
+	case "text", "":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q, expected text or json", a.LogFormat)
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// Fatal logs err at error level and exits.
+func Fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+// WriteLineProtocol writes every metric as an InfluxDB line protocol point stamped with ts, which InfluxDB and
+// VictoriaMetrics both accept. Counters and gauges have a single value field; histograms have count, sum and
+// estimated p50, p95 and p99 fields. Extra tags, e.g. identifying the run, are added to every point. Line protocol
+// has no NaN or infinite floats, which would fail the whole write, so those fields are left out, along with a
+// gauge's point when it has none left.
+func (r *Registry) WriteLineProtocol(w io.Writer, ts time.Time, tags Labels) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := r.families[name]
+		for _, s := range f.sortedSeries() {
+			var fields string
+			switch m := s.metric.(type) {
+			case *Counter:
+				fields = fmt.Sprintf("value=%di", m.Value())
+			case *Gauge:
+				if fields = floatFields([]string{"value"}, m.Value()); fields == "" {
+					continue
+				}
+			case *Histogram:
+				if m.Count() == 0 {
+					continue
+				}
+				fields = fmt.Sprintf("count=%di", m.Count())
+				if more := floatFields([]string{"sum", "p50", "p95", "p99"},
+					m.Sum(), m.Quantile(.5), m.Quantile(.95), m.Quantile(.99)); more != "" {
+					fields += "," + more
+				}
+			}
+			line := measurementEscaper.Replace(name) + lineTags(s.labels, tags) + " " + fields + " " + fmt.Sprint(ts.UnixNano())
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// floatFields renders each of values as a float field of the matching name, skipping the non-finite ones.
+func floatFields(names []string, values ...float64) string {
+	var fields []string
+	for i, v := range values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			fields = append(fields, fmt.Sprintf("%s=%g", names[i], v))
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// lineTags renders the tags of a point, sorted by key as InfluxDB recommends.
+func lineTags(sets ...Labels) string {
+	all := make(map[string]string)
+	for _, set := range sets {
+		for k, v := range set {
+			all[k] = v
+		}
+	}
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		if all[k] != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, ",%s=%s", tagEscaper.Replace(k), tagEscaper.Replace(all[k]))
+	}
+	return b.String()
+}
+
+// PushLineProtocol POSTs the registry in line protocol to url, such as an InfluxDB v2 /api/v2/write endpoint or
+// VictoriaMetrics' /write. A non-empty token is sent as an InfluxDB "Authorization: Token" header.
+func (r *Registry) PushLineProtocol(ctx context.Context, url, token string, ts time.Time, tags Labels) error {
+	var body bytes.Buffer
+	if err := r.WriteLineProtocol(&body, ts, tags); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("pushing metrics: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteLineProtocol(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("rows total", "", Labels{"table name": "a,b=c", "empty": ""}).Add(7)
+	r.Gauge("gauge", "", Labels{"v": "finite"}).Set(2.5)
+	r.Gauge("gauge", "", Labels{"v": "nan"}).Set(math.NaN())
+	r.Gauge("gauge", "", Labels{"v": "inf"}).Set(math.Inf(-1))
+	h := r.Histogram("latency", "", []float64{1, 2}, nil)
+	h.Observe(0.5)
+	h.Observe(1.5)
+	r.Histogram("latency", "", nil, Labels{"op": "unused"})
+	inf := r.Histogram("overflow", "", []float64{1}, nil)
+	inf.Observe(math.Inf(1))
+
+	var b strings.Builder
+	ts := time.Unix(1700000000, 5)
+	if err := r.WriteLineProtocol(&b, ts, Labels{"run": "r 1"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `gauge,run=r\ 1,v=finite value=2.5 1700000000000000005
+latency,run=r\ 1 count=2i,sum=2,p50=1,p95=1.9,p99=1.98 1700000000000000005
+overflow,run=r\ 1 count=1i,p50=1,p95=1,p99=1 1700000000000000005
+rows\ total,run=r\ 1,table\ name=a\,b\=c value=7i 1700000000000000005
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteLineProtocol =\n%s\nwant\n%s", got, want)
+	}
+}
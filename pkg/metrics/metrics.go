@@ -0,0 +1,265 @@
+// Package metrics is a small, dependency-free metrics registry of counters, gauges and histograms which can be
+// rendered in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Default is the registry used by the driver instrumentation and served by the --metrics-addr endpoints.
+var Default = NewRegistry()
+
+// DefBuckets are latency buckets in seconds suited to statements ranging from a single-row insert to a full scan.
+var DefBuckets = []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5, 10, 30}
+
+// Labels are the label values distinguishing the series of a metric.
+type Labels map[string]string
+
+var (
+	// the exposition format escapes only these, unlike Go's %q, which would also escape any non-ASCII character
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, k, labelEscaper.Replace(l[k]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+type kind string
+
+const (
+	kindCounter   kind = "counter"
+	kindGauge     kind = "gauge"
+	kindHistogram kind = "histogram"
+)
+
+type family struct {
+	name, help string
+	kind       kind
+	buckets    []float64
+	series     map[string]series
+}
+
+type series struct {
+	key    string
+	labels Labels
+	metric any
+}
+
+func (f *family) sortedSeries() []series {
+	all := make([]series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].key < all[j].key })
+	return all
+}
+
+// get returns the series for labels, creating it with newMetric on first use.
+func (f *family) get(labels Labels, newMetric func() any) any {
+	key := labels.String()
+	s, ok := f.series[key]
+	if !ok {
+		s = series{key: key, labels: maps.Clone(labels), metric: newMetric()}
+		f.series[key] = s
+	}
+	return s.metric
+}
+
+// Registry holds named metric families. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+func (r *Registry) family(name, help string, k kind, buckets []float64) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, kind: k, buckets: buckets, series: make(map[string]series)}
+		r.families[name] = f
+	}
+	if f.kind != k {
+		panic(fmt.Sprintf("metrics: %s registered as %s, not %s", name, f.kind, k))
+	}
+	if f.help == "" {
+		f.help = help
+	}
+	return f
+}
+
+// Counter returns the counter with the given name and labels, creating it on first use.
+func (r *Registry) Counter(name, help string, labels Labels) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.family(name, help, kindCounter, nil)
+	return f.get(labels, func() any { return &Counter{} }).(*Counter)
+}
+
+// Gauge returns the gauge with the given name and labels, creating it on first use.
+func (r *Registry) Gauge(name, help string, labels Labels) *Gauge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.family(name, help, kindGauge, nil)
+	return f.get(labels, func() any { return &Gauge{} }).(*Gauge)
+}
+
+// Histogram returns the histogram with the given name and labels, creating it on first use. The buckets of the
+// first call for a name are used for all of its series.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels Labels) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.family(name, help, kindHistogram, buckets)
+	return f.get(labels, func() any {
+		return &Histogram{upper: f.buckets, counts: make([]atomic.Uint64, len(f.buckets))}
+	}).(*Histogram)
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := r.families[name]
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, helpEscaper.Replace(f.help), f.name, f.kind); err != nil {
+			return err
+		}
+		for _, s := range f.sortedSeries() {
+			var err error
+			switch m := s.metric.(type) {
+			case *Counter:
+				_, err = fmt.Fprintf(w, "%s%s %d\n", f.name, s.key, m.Value())
+			case *Gauge:
+				_, err = fmt.Fprintf(w, "%s%s %g\n", f.name, s.key, m.Value())
+			case *Histogram:
+				err = m.write(w, f.name, s.key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the registry in the Prometheus text format, so it can be mounted as a /metrics handler.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WritePrometheus(w)
+}
+
+// Counter is a monotonically increasing count.
+type Counter struct {
+	v atomic.Uint64
+}
+
+func (c *Counter) Inc()          { c.v.Add(1) }
+func (c *Counter) Add(n uint64)  { c.v.Add(n) }
+func (c *Counter) Value() uint64 { return c.v.Load() }
+
+// Gauge is a value which can go up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+func (g *Gauge) Set(v float64)  { g.bits.Store(math.Float64bits(v)) }
+func (g *Gauge) Value() float64 { return math.Float64frombits(g.bits.Load()) }
+
+// Histogram counts observations into cumulative buckets, keeping the total count and sum.
+type Histogram struct {
+	upper  []float64
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Uint64 // float64 bits
+}
+
+func (h *Histogram) Observe(v float64) {
+	if i, _ := slices.BinarySearch(h.upper, v); i < len(h.counts) {
+		h.counts[i].Add(1)
+	}
+	h.count.Add(1)
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Quantile estimates the q-quantile by linear interpolation within the bucket it falls in, as Prometheus'
+// histogram_quantile does. Observations above the highest bucket are reported as its upper bound.
+func (h *Histogram) Quantile(q float64) float64 {
+	total := h.Count()
+	if total == 0 {
+		return math.NaN()
+	}
+	rank := q * float64(total)
+	var cumulative uint64
+	lower := 0.0
+	for i, upper := range h.upper {
+		n := h.counts[i].Load()
+		if float64(cumulative+n) >= rank {
+			if n == 0 {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-float64(cumulative))/float64(n)
+		}
+		cumulative += n
+		lower = upper
+	}
+	return lower
+}
+
+func (h *Histogram) Count() uint64 { return h.count.Load() }
+func (h *Histogram) Sum() float64  { return math.Float64frombits(h.sum.Load()) }
+
+func (h *Histogram) write(w io.Writer, name, key string) error {
+	var cumulative uint64
+	for i, upper := range h.upper {
+		cumulative += h.counts[i].Load()
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(key, "le", fmt.Sprint(upper)), cumulative); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %g\n%s_count%s %d\n",
+		name, withLabel(key, "le", "+Inf"), h.Count(), name, key, h.Sum(), name, key, h.Count())
+	return err
+}
+
+// withLabel appends a label to an already rendered label set.
+func withLabel(key, name, value string) string {
+	label := fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(value))
+	if key == "" {
+		return "{" + label + "}"
+	}
+	return strings.TrimSuffix(key, "}") + "," + label + "}"
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("requests_total", "Requests served.", Labels{"path": "a\"b\\c\nd", "host": "münchen"}).Add(3)
+	r.Counter("requests_total", "", nil).Inc()
+	r.Gauge("temperature", "Temperature, \\ in degrees\nCelsius.", Labels{"sensor": "nan"}).Set(math.NaN())
+	r.Gauge("temperature", "", Labels{"sensor": "hot"}).Set(math.Inf(1))
+	r.Gauge("temperature", "", Labels{"sensor": "cold"}).Set(-1.5)
+	h := r.Histogram("latency_seconds", "Latency.", []float64{1, 2}, Labels{"op": "exec"})
+	h.Observe(0.5)
+	h.Observe(3)
+
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="exec",le="1"} 1
+latency_seconds_bucket{op="exec",le="2"} 1
+latency_seconds_bucket{op="exec",le="+Inf"} 2
+latency_seconds_sum{op="exec"} 3.5
+latency_seconds_count{op="exec"} 2
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total 1
+requests_total{host="münchen",path="a\"b\\c\nd"} 3
+# HELP temperature Temperature, \\ in degrees\nCelsius.
+# TYPE temperature gauge
+temperature{sensor="cold"} -1.5
+temperature{sensor="hot"} +Inf
+temperature{sensor="nan"} NaN
+`
+	if got := b.String(); got != want {
+		t.Errorf("WritePrometheus =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistryKinds(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("n", "", Labels{"a": "1"})
+	if r.Counter("n", "", Labels{"a": "1"}) != c || r.Counter("n", "", Labels{"a": "2"}) == c {
+		t.Error("Counter does not return one series per label set")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a counter as a gauge did not panic")
+		}
+	}()
+	r.Gauge("n", "", nil)
+}
+
+func TestQuantile(t *testing.T) {
+	h := NewRegistry().Histogram("h", "", []float64{1, 2, 4}, nil)
+	if q := h.Quantile(.5); !math.IsNaN(q) {
+		t.Errorf("Quantile of an empty histogram = %g, want NaN", q)
+	}
+	// two observations in each of the first two buckets, four in the third and two above the highest
+	for _, v := range []float64{0.5, 0.5, 1.5, 2, 3, 3, 3, 4, 10, 20} {
+		h.Observe(v)
+	}
+	for _, tt := range []struct{ q, want float64 }{
+		{.1, 0.5},
+		{.2, 1},
+		{.3, 1.5},
+		{.5, 2.5},
+		{.8, 4},
+		// above the highest bucket, which is all Quantile can tell
+		{.95, 4},
+		{1, 4},
+	} {
+		if got := h.Quantile(tt.q); got != tt.want {
+			t.Errorf("Quantile(%g) = %g, want %g", tt.q, got, tt.want)
+		}
+	}
+	if h.Count() != 10 || h.Sum() != 47.5 {
+		t.Errorf("count %d and sum %g, want 10 and 47.5", h.Count(), h.Sum())
+	}
+}
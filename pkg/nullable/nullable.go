@@ -0,0 +1,124 @@
+// Package nullable provides a generic wrapper for values that may be NULL in DuckDB, so results can be scanned
+// without a separate sql.NullXxx type per column and rendered cleanly as JSON or CSV.
+package nullable
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Nullable holds a value of type T which may be NULL. The zero value is NULL.
+type Nullable[T any] struct {
+	V     T
+	Valid bool
+}
+
+// From returns a valid Nullable holding v.
+func From[T any](v T) Nullable[T] {
+	return Nullable[T]{V: v, Valid: true}
+}
+
+// FromPtr returns a Nullable holding *p, or NULL if p is nil.
+func FromPtr[T any](p *T) Nullable[T] {
+	if p == nil {
+		return Nullable[T]{}
+	}
+	return From(*p)
+}
+
+// FromNull converts one of the database/sql null types into a Nullable.
+func FromNull[T any](n sql.Null[T]) Nullable[T] {
+	return Nullable[T]{V: n.V, Valid: n.Valid}
+}
+
+// Ptr returns a pointer to the value, or nil if it is NULL.
+func (n Nullable[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
+
+// Or returns the value, or def if it is NULL.
+func (n Nullable[T]) Or(def T) T {
+	if !n.Valid {
+		return def
+	}
+	return n.V
+}
+
+// Scan implements sql.Scanner.
+func (n *Nullable[T]) Scan(src any) error {
+	var s sql.Null[T]
+	if err := s.Scan(src); err != nil {
+		return err
+	}
+	n.V, n.Valid = s.V, s.Valid
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (n Nullable[T]) Value() (driver.Value, error) {
+	return sql.Null[T]{V: n.V, Valid: n.Valid}.Value()
+}
+
+// MarshalJSON renders NULL as the JSON null literal.
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON treats the JSON null literal as NULL.
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*n = Nullable[T]{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// String renders NULL as the empty string, which is how DuckDB's read_csv and COPY interpret missing values.
+func (n Nullable[T]) String() string {
+	if !n.Valid {
+		return ""
+	}
+	return fmt.Sprint(n.V)
+}
+
+// Map applies f to the value of n, propagating NULL.
+func Map[T, U any](n Nullable[T], f func(T) U) Nullable[U] {
+	if !n.Valid {
+		return Nullable[U]{}
+	}
+	return From(f(n.V))
+}
+
+// Values returns the non-NULL values of ns, in order.
+func Values[T any](ns []Nullable[T]) []T {
+	values := make([]T, 0, len(ns))
+	for _, n := range ns {
+		if n.Valid {
+			values = append(values, n.V)
+		}
+	}
+	return values
+}
+
+// Strings renders each element of ns with String, for use as a CSV record.
+func Strings[T any](ns []Nullable[T]) []string {
+	record := make([]string, len(ns))
+	for i, n := range ns {
+		record[i] = n.String()
+	}
+	return record
+}
@@ -0,0 +1,81 @@
+package nullable
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    Nullable[float64]
+		wantErr bool
+	}{
+		{"nil", nil, Nullable[float64]{}, false},
+		{"matching type", 1.5, From(1.5), false},
+		// database/sql converts between numeric types as it would for a plain float64
+		{"converted", int64(2), From(2.0), false},
+		{"mismatched type", "not a number", Nullable[float64]{}, true},
+	}
+	for _, tt := range tests {
+		// a previous value must not survive scanning a NULL
+		n := From(-1.0)
+		err := n.Scan(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Scan(%#v) err = %v, want an error: %t", tt.name, tt.src, err, tt.wantErr)
+			continue
+		}
+		if err == nil && n != tt.want {
+			t.Errorf("%s: Scan(%#v) = %+v, want %+v", tt.name, tt.src, n, tt.want)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		name string
+		n    Nullable[int64]
+		want any
+	}{
+		{"valid", From[int64](3), int64(3)},
+		{"invalid", Nullable[int64]{}, nil},
+		// an invalid value is NULL whatever V holds
+		{"invalid with a value", Nullable[int64]{V: 3}, nil},
+	}
+	for _, tt := range tests {
+		got, err := tt.n.Value()
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Value() = %#v, %v, want %#v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type doc struct {
+		A Nullable[float64] `json:"a"`
+		B Nullable[string]  `json:"b"`
+	}
+	tests := []struct {
+		json string
+		doc  doc
+	}{
+		{`{"a":null,"b":null}`, doc{}},
+		{`{"a":1.5,"b":"x"}`, doc{From(1.5), From("x")}},
+		{`{"a":0,"b":""}`, doc{From(0.0), From("")}},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.doc)
+		if err != nil || string(got) != tt.json {
+			t.Errorf("Marshal(%+v) = %s, %v, want %s", tt.doc, got, err, tt.json)
+		}
+		d := doc{From(-1.0), From("previous")}
+		if err := json.Unmarshal([]byte(tt.json), &d); err != nil || d != tt.doc {
+			t.Errorf("Unmarshal(%s) = %+v, %v, want %+v", tt.json, d, err, tt.doc)
+		}
+	}
+	var n Nullable[float64]
+	if err := json.Unmarshal([]byte(`"x"`), &n); err == nil || n.Valid {
+		t.Errorf("Unmarshal of a string into Nullable[float64] = %+v, %v, want an error", n, err)
+	}
+}
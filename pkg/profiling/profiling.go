@@ -0,0 +1,157 @@
+// Package profiling captures pprof profiles and execution traces around a section of a program, typically a single
+// benchmark phase.
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	httppprof "net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// Options holds the paths profiles are written to. Empty paths disable the corresponding profile.
+type Options struct {
+	CPU   string
+	Mem   string
+	Block string
+	// Trace is written by runtime/trace, for inspection with go tool trace.
+	Trace string
+}
+
+// Profiler captures any of a CPU, heap and block profile and an execution trace into files. Files are created up
+// front by New, so a bad path is reported before any work is done rather than once the profiled section ends.
+type Profiler struct {
+	cpu, mem, block, trace *os.File
+	running                bool
+}
+
+// New creates a profiler writing to the paths in opts.
+func New(opts Options) (*Profiler, error) {
+	p := &Profiler{}
+	for _, f := range []struct {
+		path string
+		dst  **os.File
+	}{{opts.CPU, &p.cpu}, {opts.Mem, &p.mem}, {opts.Block, &p.block}, {opts.Trace, &p.trace}} {
+		if f.path == "" {
+			continue
+		}
+		file, err := os.Create(f.path)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		*f.dst = file
+	}
+	return p, nil
+}
+
+// Enabled reports whether any profile is being captured.
+func (p *Profiler) Enabled() bool {
+	return p.cpu != nil || p.mem != nil || p.block != nil || p.trace != nil
+}
+
+// Tracing reports whether an execution trace is being captured.
+func (p *Profiler) Tracing() bool {
+	return p.trace != nil
+}
+
+// Start begins capturing the CPU and block profiles and the execution trace.
+func (p *Profiler) Start() error {
+	if p.running {
+		return errors.New("profiling: already started")
+	}
+	if p.cpu != nil {
+		if err := pprof.StartCPUProfile(p.cpu); err != nil {
+			return err
+		}
+	}
+	if p.block != nil {
+		runtime.SetBlockProfileRate(1)
+	}
+	if p.trace != nil {
+		if err := trace.Start(p.trace); err != nil {
+			if p.cpu != nil {
+				pprof.StopCPUProfile()
+			}
+			return err
+		}
+	}
+	p.running = true
+	return nil
+}
+
+// Stop ends the CPU profile and writes the heap and block profiles as of now. Profiles can only be captured once.
+func (p *Profiler) Stop() error {
+	if !p.running {
+		return errors.New("profiling: not started")
+	}
+	p.running = false
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+	}
+	if p.trace != nil {
+		trace.Stop()
+	}
+	var errs []error
+	if p.mem != nil {
+		runtime.GC()
+		errs = append(errs, writeProfile("heap", p.mem))
+	}
+	if p.block != nil {
+		errs = append(errs, writeProfile("block", p.block))
+		runtime.SetBlockProfileRate(0)
+	}
+	return errors.Join(append(errs, p.Close())...)
+}
+
+// Close closes any profile files without writing to them.
+func (p *Profiler) Close() error {
+	var errs []error
+	for _, f := range []**os.File{&p.cpu, &p.mem, &p.block, &p.trace} {
+		if *f != nil {
+			errs = append(errs, (*f).Close())
+			*f = nil
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func writeProfile(name string, f *os.File) error {
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		return fmt.Errorf("writing %s profile: %w", name, err)
+	}
+	return nil
+}
+
+// Handler serves the runtime's profiles on /debug/pprof/, as net/http/pprof does on the default mux, so a long run
+// can be profiled with go tool pprof while it is in progress.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", httppprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", httppprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", httppprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", httppprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", httppprof.Trace)
+	return mux
+}
+
+// Task marks a section of the program as a user task in the execution trace, so it shows up by name in go tool
+// trace. It is cheap when no trace is being captured.
+func Task(ctx context.Context, name string) (context.Context, func()) {
+	ctx, task := trace.NewTask(ctx, name)
+	return ctx, task.End
+}
+
+// Label attaches a pprof label to the samples taken while the calling goroutine runs the section, so a profile of
+// a whole run can later be split by it (see package flamegraph). The returned function restores the previous
+// labels.
+func Label(ctx context.Context, key, value string) (context.Context, func()) {
+	labelled := pprof.WithLabels(ctx, pprof.Labels(key, value))
+	pprof.SetGoroutineLabels(labelled)
+	return labelled, func() { pprof.SetGoroutineLabels(ctx) }
+}
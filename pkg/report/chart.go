@@ -0,0 +1,67 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// bar is one bar of a chart. Alt bars are drawn in a second colour, to tell Go and DuckDB apart in pairs.
+type bar struct {
+	Label string
+	Value float64
+	Alt   bool
+}
+
+const (
+	chartWidth  = 720.0
+	labelWidth  = 220.0
+	valueWidth  = 100.0
+	barHeight   = 18.0
+	barGap      = 6.0
+	barColor    = "#4e79a7"
+	altBarColor = "#f28e2b"
+)
+
+// barScale returns the factor scaling the bars so that the longest is 1.
+func barScale(bars []bar) float64 {
+	var longest float64
+	for _, b := range bars {
+		longest = max(longest, b.length(1))
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 / longest
+}
+
+// length is the length of the bar at scale, or zero for a value which is not finite, such as the rate of a step
+// too fast to time.
+func (b bar) length(scale float64) float64 {
+	if math.IsInf(b.Value, 0) || math.IsNaN(b.Value) || b.Value < 0 {
+		return 0
+	}
+	return b.Value * scale
+}
+
+// barChart renders bars as a horizontal bar chart in SVG, each labelled with its value in unit.
+func barChart(bars []bar, unit string) string {
+	height := float64(len(bars))*(barHeight+barGap) + barGap
+	scale := barScale(bars) * (chartWidth - labelWidth - valueWidth)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" font-family="sans-serif" font-size="12">`+"\n", chartWidth, height)
+	for i, bar := range bars {
+		y := barGap + float64(i)*(barHeight+barGap)
+		fill := barColor
+		if bar.Alt {
+			fill = altBarColor
+		}
+		width := bar.length(scale)
+		fmt.Fprintf(&b, `<text x="%g" y="%.1f" text-anchor="end">%s</text>`, labelWidth-6, y+barHeight-5, html.EscapeString(bar.Label))
+		fmt.Fprintf(&b, `<rect x="%g" y="%.1f" width="%.1f" height="%g" fill="%s"/>`, labelWidth, y, width, barHeight, fill)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f">%.4g %s</text>`+"\n", labelWidth+width+4, y+barHeight-5, bar.Value, html.EscapeString(unit))
+	}
+	b.WriteString("</svg>")
+	return b.String()
+}
@@ -0,0 +1,182 @@
+// Package report renders the results of a benchmark run as a self-contained Markdown or HTML document, to attach to
+// a pull request or a blog post.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+// section is a table of the report, with the bars charted from one of its columns.
+type section struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+	Bars    []bar
+	// Unit labels the bars' values.
+	Unit string
+}
+
+// sections lays out r: insert throughput, the statistics timings and then those of each workload in both engines.
+func sections(r duckbench.Report) []section {
+	var sections []section
+	if len(r.Inserts) > 0 {
+		s := section{Title: "Inserts", Columns: []string{"Strategy", "Rows", "Duration", "Rows/s"}, Unit: "rows/s"}
+		for _, ins := range r.Inserts {
+			s.Rows = append(s.Rows, []string{ins.Name, fmt.Sprint(ins.Rows), duration(ins.Duration), rate(ins.RowsPerSecond())})
+			s.Bars = append(s.Bars, bar{Label: ins.Name, Value: ins.RowsPerSecond()})
+		}
+		sections = append(sections, s)
+	}
+	if len(r.Statistics) > 0 {
+		s := section{Title: "Statistics", Columns: []string{"Engine", "Duration", "Rows/s", "Mean", "StdDev"}, Unit: "ms"}
+		for _, st := range r.Statistics {
+			mean, stddev := "", ""
+			if st.Stats != nil {
+				mean, stddev = fmt.Sprintf("%.6g", st.Stats.Mean), fmt.Sprintf("%.6g", st.Stats.StdDev)
+			}
+			s.Rows = append(s.Rows, []string{st.Name, duration(st.Duration), rate(st.RowsPerSecond()), mean, stddev})
+			s.Bars = append(s.Bars, bar{Label: st.Name, Value: milliseconds(st.Duration)})
+		}
+		sections = append(sections, s)
+	}
+	if len(r.Workloads) > 0 {
+		s := section{Title: "Workloads", Columns: []string{"Workload", "Go", "DuckDB", "Go/DuckDB"}, Unit: "ms"}
+		for _, w := range r.Workloads {
+			s.Rows = append(s.Rows, []string{w.Name, duration(w.Go.Duration), duration(w.DuckDB.Duration),
+				fmt.Sprintf("%.2f", w.Go.Duration.Seconds()/w.DuckDB.Duration.Seconds())})
+			s.Bars = append(s.Bars,
+				bar{Label: w.Name + " go", Value: milliseconds(w.Go.Duration)},
+				bar{Label: w.Name + " duckdb", Value: milliseconds(w.DuckDB.Duration), Alt: true})
+		}
+		sections = append(sections, s)
+	}
+	if len(r.Memory) > 0 {
+		s := section{Title: "Memory", Columns: []string{"Phase", "Go peak heap", "Go allocated", "GC cycles", "DuckDB peak"}, Unit: "MiB"}
+		phases := make([]string, 0, len(r.Memory))
+		for phase := range r.Memory {
+			phases = append(phases, string(phase))
+		}
+		sort.Slice(phases, func(i, j int) bool { return phaseOrder(phases[i]) < phaseOrder(phases[j]) })
+		for _, phase := range phases {
+			m := r.Memory[duckbench.Phase(phase)]
+			s.Rows = append(s.Rows, []string{phase, bytes(int64(m.PeakHeapAlloc)), bytes(int64(m.TotalAlloc)),
+				fmt.Sprint(m.NumGC), bytes(m.PeakDuckDBMemory)})
+			s.Bars = append(s.Bars,
+				bar{Label: phase + " go", Value: mebibytes(int64(m.PeakHeapAlloc))},
+				bar{Label: phase + " duckdb", Value: mebibytes(m.PeakDuckDBMemory), Alt: true})
+		}
+		sections = append(sections, s)
+	}
+	return sections
+}
+
+func phaseOrder(phase string) int {
+	for i, p := range duckbench.Phases {
+		if string(p) == phase {
+			return i
+		}
+	}
+	return len(duckbench.Phases)
+}
+
+func duration(d time.Duration) string { return d.Round(time.Microsecond).String() }
+
+func milliseconds(d time.Duration) float64 { return d.Seconds() * 1e3 }
+
+func rate(r float64) string { return fmt.Sprintf("%.0f", r) }
+
+func mebibytes(n int64) float64 { return float64(n) / (1 << 20) }
+
+func bytes(n int64) string { return fmt.Sprintf("%.1f MiB", mebibytes(n)) }
+
+// markdownBarWidth is the length in characters of the longest bar of a Markdown table, which has no images of its
+// own to chart with.
+const markdownBarWidth = 30
+
+// WriteMarkdown renders r as Markdown tables under title, charting each with a column of text bars so that it
+// renders anywhere, without images.
+func WriteMarkdown(w io.Writer, r duckbench.Report, title string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%d rows, started %s.\n", title, r.Rows, r.Started.Format(time.RFC3339))
+	for _, s := range sections(r) {
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Title)
+		fmt.Fprintf(&b, "| %s |\n|%s\n", strings.Join(s.Columns, " | "), strings.Repeat(" --- |", len(s.Columns)))
+		for _, row := range s.Rows {
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(escapeMarkdown(row), " | "))
+		}
+		b.WriteString("\n```\n")
+		scale := barScale(s.Bars)
+		width := 0
+		for _, bar := range s.Bars {
+			width = max(width, len(bar.Label))
+		}
+		for _, bar := range s.Bars {
+			fmt.Fprintf(&b, "%-*s %s %.4g %s\n", width, bar.Label, strings.Repeat("█", int(math.Round(bar.length(scale*markdownBarWidth)))),
+				bar.Value, s.Unit)
+		}
+		b.WriteString("```\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escapeMarkdown(row []string) []string {
+	escaped := make([]string, len(row))
+	for i, cell := range row {
+		escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+	}
+	return escaped
+}
+
+var page = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 2em auto; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Rows}} rows, started {{.Started}}.</p>
+{{range .Sections}}
+<h2>{{.Title}}</h2>
+<table>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{.Chart}}
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders r as a single HTML page under title, with its tables and an inline SVG bar chart of each.
+func WriteHTML(w io.Writer, r duckbench.Report, title string) error {
+	type htmlSection struct {
+		section
+		Chart template.HTML
+	}
+	data := struct {
+		Title    string
+		Rows     int
+		Started  string
+		Sections []htmlSection
+	}{Title: title, Rows: r.Rows, Started: r.Started.Format(time.RFC3339)}
+	for _, s := range sections(r) {
+		data.Sections = append(data.Sections, htmlSection{s, template.HTML(barChart(s.Bars, s.Unit))})
+	}
+	return page.Execute(w, data)
+}
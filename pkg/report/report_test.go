@@ -0,0 +1,71 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+func testReport() duckbench.Report {
+	return duckbench.Report{
+		Rows: 1000,
+		Inserts: []duckbench.BenchmarkResult{
+			{Name: "appender", Rows: 1000, Duration: time.Millisecond},
+			// too fast to time, so its rate is infinite
+			{Name: "<instant>|", Rows: 1000},
+		},
+		Statistics: []duckbench.BenchmarkResult{
+			{Name: "go", Rows: 1000, Duration: 2 * time.Millisecond, Stats: &duckbench.Stats{Mean: 1.5}},
+			{Name: "duckdb", Rows: 1000, Duration: 4 * time.Millisecond},
+		},
+		Workloads: []duckbench.WorkloadReport{{Name: "histogram/20",
+			Go:     duckbench.BenchmarkResult{Duration: time.Millisecond},
+			DuckDB: duckbench.BenchmarkResult{Duration: 3 * time.Millisecond}}},
+		Memory: map[duckbench.Phase]duckbench.MemoryUsage{
+			duckbench.PhaseQuery: {PeakDuckDBMemory: 2 << 20},
+			duckbench.PhaseStats: {PeakHeapAlloc: 1 << 20},
+		},
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMarkdown(&b, testReport(), "test run"); err != nil {
+		t.Fatal(err)
+	}
+	md := b.String()
+	for _, want := range []string{
+		"# test run\n",
+		"| appender | 1000 | 1ms | 1000000 |\n",
+		`| <instant>\| | 1000 |`,
+		"duckdb ██████████████████████████████ 4 ms\n",
+		"go     ███████████████ 2 ms\n",
+		"| histogram/20 | 1ms | 3ms | 0.33 |\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown does not contain %q:\n%s", want, md)
+		}
+	}
+	if stats, query := strings.Index(md, "| stats |"), strings.Index(md, "| query |"); stats < 0 || stats > query {
+		t.Errorf("memory phases are not in the order they ran:\n%s", md)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var b strings.Builder
+	if err := WriteHTML(&b, testReport(), "test <run>"); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	if strings.Contains(page, "<instant>") || strings.Contains(page, "<run>") {
+		t.Error("names are not escaped")
+	}
+	if n := strings.Count(page, "<svg "); n != 4 {
+		t.Errorf("%d charts, want one per table", n)
+	}
+	if !strings.Contains(page, `width="400.0" height="18" fill="#4e79a7"/><text x="624.0" y="19.0">1e+06 rows/s</text>`) {
+		t.Errorf("the appender's bar is not the longest:\n%s", page)
+	}
+}
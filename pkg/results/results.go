@@ -0,0 +1,301 @@
+// Package results persists benchmark results into a DuckDB database file, so runs can be compared over time.
+//
+// The schema is versioned: schema_version records how many of the migrations below have been applied, and Open
+// applies any newer ones in place. New result columns must therefore be added as a new migration, never by editing
+// an existing one, so that databases written by older versions of the harness keep working.
+package results
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	_ "github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+)
+
+// migrations[i] upgrades the schema from version i to version i+1.
+var migrations = []string{
+	`CREATE SEQUENCE seq_runs_id START 1;
+	CREATE TABLE runs (
+		id INTEGER PRIMARY KEY DEFAULT nextval('seq_runs_id'),
+		started_at TIMESTAMP NOT NULL,
+		n BIGINT NOT NULL,
+		insert_seconds DOUBLE NOT NULL,
+		go_seconds DOUBLE NOT NULL,
+		db_seconds DOUBLE NOT NULL,
+		go_mean DOUBLE, go_median DOUBLE, go_stddev DOUBLE, go_min DOUBLE, go_max DOUBLE,
+		db_mean DOUBLE, db_median DOUBLE, db_stddev DOUBLE, db_min DOUBLE, db_max DOUBLE
+	)`,
+	// the timings of every step, with those of the runs saved before them carried over under their own step names
+	`ALTER TABLE runs ADD COLUMN git_sha VARCHAR;
+	ALTER TABLE runs ADD COLUMN parameters VARCHAR;
+	CREATE TABLE timings (
+		run_id INTEGER NOT NULL,
+		step VARCHAR NOT NULL,
+		name VARCHAR NOT NULL,
+		seconds DOUBLE NOT NULL
+	);
+	INSERT INTO timings
+		SELECT id, 'insert', 'insert', insert_seconds FROM runs
+		UNION ALL SELECT id, 'stats', 'go', go_seconds FROM runs
+		UNION ALL SELECT id, 'query', 'duckdb', db_seconds FROM runs`,
+}
+
+// SchemaVersion is the schema version written by this version of the package.
+var SchemaVersion = len(migrations)
+
+// ErrNewerSchema is returned when opening a results database written by a newer version of the harness.
+var ErrNewerSchema = errors.New("results database has a newer schema than this version of the harness supports")
+
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens (creating if necessary) the results database at path and upgrades its schema to SchemaVersion.
+func Open(ctx context.Context, path string) (*Store, error) {
+	db, err := sql.Open("duckdb", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("upgrading results schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Version returns the schema version of the database.
+func (s *Store) Version(ctx context.Context) (int, error) {
+	return version(ctx, s.db)
+}
+
+func version(ctx context.Context, q interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}) (int, error) {
+	var v int
+	err := q.QueryRowContext(ctx, `SELECT coalesce(max(version), 0) FROM schema_version`).Scan(&v)
+	return v, err
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+	current, err := version(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("%w: version %d, supported %d", ErrNewerSchema, current, len(migrations))
+	}
+	for v := current; v < len(migrations); v++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[v]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration to version %d: %w", v+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_version VALUES (?)`, v+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run describes what a run benchmarked, beyond its number of records. Only the timings of runs with the same
+// parameters are compared with each other by Trends.
+type Run struct {
+	GitSHA     string
+	Parameters map[string]string
+}
+
+// Save appends the results of a run, and the timing of each of its steps.
+func (s *Store) Save(ctx context.Context, res duckbench.Results, run Run) error {
+	var parameters []byte
+	if len(run.Parameters) > 0 {
+		// map keys are marshalled in order, so equal parameters are equal strings
+		var err error
+		if parameters, err = json.Marshal(run.Parameters); err != nil {
+			return err
+		}
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	g, d := res.GoStats, res.DBStats
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO runs (started_at, n, insert_seconds, go_seconds, db_seconds,
+			go_mean, go_median, go_stddev, go_min, go_max,
+			db_mean, db_median, db_stddev, db_min, db_max, git_sha, parameters)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, res.Started, res.N, res.InsertDuration.Seconds(), res.GoDuration.Seconds(), res.DBDuration.Seconds(),
+		g.Mean, g.Median, g.StdDev, g.Min, g.Max,
+		d.Mean, d.Median, d.StdDev, d.Min, d.Max, nullString(run.GitSHA), nullString(string(parameters))).Scan(&id)
+	if err != nil {
+		return err
+	}
+	for _, t := range timings(res) {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO timings VALUES (?, ?, ?, ?)`, id, t.step, t.name, t.seconds); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+type timing struct {
+	step, name string
+	seconds    float64
+}
+
+// timings flattens the steps of res, named as the phases they ran in.
+func timings(res duckbench.Results) []timing {
+	var ts []timing
+	for _, r := range res.Inserts {
+		ts = append(ts, timing{string(duckbench.PhaseInsert), r.Name, r.Duration.Seconds()})
+	}
+	ts = append(ts,
+		timing{string(duckbench.PhaseStats), "go", res.GoDuration.Seconds()},
+		timing{string(duckbench.PhaseQuery), "duckdb", res.DBDuration.Seconds()})
+	for _, r := range res.Engines {
+		ts = append(ts, timing{string(duckbench.PhaseStats), r.Name, r.Duration.Seconds()})
+	}
+	for _, w := range res.Workloads {
+		ts = append(ts,
+			timing{"workload", w.Name + "/go", w.Go.Duration.Seconds()},
+			timing{"workload", w.Name + "/duckdb", w.DuckDB.Duration.Seconds()})
+	}
+	return ts
+}
+
+// Trend is the history of one step's timing over the runs of the same number of records and parameters.
+type Trend struct {
+	Step, Name string
+	N          int64
+	Parameters string
+	Runs       int
+	// Latest is the latest run's timing, and Baseline the median of up to the window of runs before it, or zero if
+	// there were none.
+	Latest, Baseline time.Duration
+	LatestSHA        string
+	LatestStarted    time.Time
+}
+
+// Change is how much slower the latest run was than the baseline, e.g. 0.1 for 10% slower, or NaN without a
+// baseline.
+func (t Trend) Change() float64 {
+	if t.Runs < 2 {
+		return math.NaN()
+	}
+	return t.Latest.Seconds()/t.Baseline.Seconds() - 1
+}
+
+// Regressed reports whether the latest run was slower than the baseline by more than threshold.
+func (t Trend) Regressed(threshold float64) bool {
+	return t.Change() > threshold
+}
+
+// Trends compares the latest timing of every step with the median of the window runs before it.
+func (s *Store) Trends(ctx context.Context, window int) ([]Trend, error) {
+	if window < 1 {
+		return nil, fmt.Errorf("window %d is not positive", window)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		WITH history AS (
+			SELECT t.step, t.name, r.n, coalesce(r.parameters, '') AS parameters, t.seconds, r.git_sha, r.started_at,
+				row_number() OVER (PARTITION BY t.step, t.name, r.n, coalesce(r.parameters, '')
+					ORDER BY r.started_at DESC, r.id DESC) AS age
+			FROM timings t JOIN runs r ON r.id = t.run_id
+		)
+		SELECT step, name, n, parameters, count(*),
+			max(seconds) FILTER (WHERE age = 1),
+			median(seconds) FILTER (WHERE age BETWEEN 2 AND ? + 1),
+			coalesce(max(git_sha) FILTER (WHERE age = 1), ''),
+			max(started_at) FILTER (WHERE age = 1)
+		FROM history
+		WHERE age <= ? + 1
+		GROUP BY step, name, n, parameters
+		ORDER BY n, parameters, step, name
+	`, window, window)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var trends []Trend
+	for rows.Next() {
+		var t Trend
+		var latest float64
+		var baseline nullable.Nullable[float64]
+		if err := rows.Scan(&t.Step, &t.Name, &t.N, &t.Parameters, &t.Runs, &latest, &baseline, &t.LatestSHA, &t.LatestStarted); err != nil {
+			return nil, err
+		}
+		t.Latest, t.Baseline = seconds(latest), seconds(baseline.Or(0))
+		trends = append(trends, t)
+	}
+	return trends, rows.Err()
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// GitSHA returns the commit the binary was built from, as stamped by go build, or else the commit checked out in
+// the working directory, with a -dirty suffix if there are uncommitted changes. It is empty if neither is known.
+func GitSHA() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		var sha, modified string
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				sha = s.Value
+			case "vcs.modified":
+				modified = s.Value
+			}
+		}
+		if sha != "" {
+			if modified == "true" {
+				sha += "-dirty"
+			}
+			return sha
+		}
+	}
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	sha := strings.TrimSpace(string(out))
+	if status, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output(); err == nil && len(status) > 0 {
+		sha += "-dirty"
+	}
+	return sha
+}
@@ -0,0 +1,95 @@
+package results
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+)
+
+func TestTrends(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(ctx, filepath.Join(t.TempDir(), "results.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := Run{GitSHA: "abc", Parameters: map[string]string{"dist": "normal"}}
+	for i, goSeconds := range []time.Duration{time.Second, 3 * time.Second, 2 * time.Second, 4 * time.Second} {
+		res := duckbench.Results{
+			Started:    start.Add(time.Duration(i) * time.Hour),
+			N:          100,
+			Inserts:    []duckbench.BenchmarkResult{{Name: "appender", Duration: time.Second}},
+			GoDuration: goSeconds,
+			DBDuration: time.Second,
+		}
+		if err := store.Save(ctx, res, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a run of other parameters is a separate trend
+	if err := store.Save(ctx, duckbench.Results{Started: start, N: 100, GoDuration: time.Second, DBDuration: time.Second}, Run{}); err != nil {
+		t.Fatal(err)
+	}
+
+	trends, err := store.Trends(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byStep := make(map[string]Trend)
+	for _, trend := range trends {
+		if trend.Parameters == `{"dist":"normal"}` {
+			byStep[trend.Step+"/"+trend.Name] = trend
+		}
+	}
+	if len(trends) != 5 || len(byStep) != 3 {
+		t.Fatalf("trends = %+v, want 3 for the runs of normal values and 2 for the other", trends)
+	}
+	// the latest 4s against the median of the previous two, 3s and 2s
+	if g := byStep["stats/go"]; g.Runs != 3 || g.Latest != 4*time.Second || g.Baseline != 2500*time.Millisecond || g.LatestSHA != "abc" {
+		t.Errorf("go trend = %+v", g)
+	}
+	if !byStep["stats/go"].Regressed(0.5) || byStep["stats/go"].Regressed(0.7) {
+		t.Errorf("go changed by %v, want 0.6", byStep["stats/go"].Change())
+	}
+	if a := byStep["insert/appender"]; a.Change() != 0 || a.Regressed(0) {
+		t.Errorf("appender trend = %+v, want no change", a)
+	}
+}
+
+// TestMigrateTimings opens a database written before timings were stored per step, whose runs must carry over.
+func TestMigrateTimings(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "results.db")
+	db, err := sql.Open("duckdb", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE TABLE schema_version (version INTEGER NOT NULL); INSERT INTO schema_version VALUES (1);`+
+		migrations[0]+`; INSERT INTO runs (started_at, n, insert_seconds, go_seconds, db_seconds) VALUES ('2024-01-01', 10, 1, 2, 3)`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if v, err := store.Version(ctx); err != nil || v != SchemaVersion {
+		t.Fatalf("version = %d, %v, want %d", v, err, SchemaVersion)
+	}
+	trends, err := store.Trends(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trends) != 3 || trends[0].Step != "insert" || trends[0].Latest != time.Second || trends[0].Runs != 1 {
+		t.Errorf("trends = %+v, want the insert, stats and query of the old run", trends)
+	}
+}
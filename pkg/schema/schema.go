@@ -0,0 +1,127 @@
+// Package schema lists the tables and columns of a DuckDB database, using duckdb_tables() for the catalog and
+// information_schema for column details.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/sqlscan"
+)
+
+type Table struct {
+	Database string `json:"database"`
+	Schema   string `json:"schema"`
+	Name     string `json:"name"`
+	// EstimatedRows is DuckDB's estimate of the row count, which is cheap to obtain but not exact.
+	EstimatedRows int64    `json:"estimated_rows"`
+	Columns       []Column `json:"columns"`
+}
+
+type Column struct {
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// Tables lists the user tables of every attached database, including their columns.
+func Tables(ctx context.Context, db sqlscan.Queryer) ([]Table, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT database_name, schema_name, table_name, estimated_size
+		FROM duckdb_tables()
+		WHERE NOT internal
+		ORDER BY database_name, schema_name, table_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []Table
+	for rows.Next() {
+		var t Table
+		if err := rows.Scan(&t.Database, &t.Schema, &t.Name, &t.EstimatedRows); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range tables {
+		t := &tables[i]
+		if t.Columns, err = columns(ctx, db, t.Database, t.Schema, t.Name); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// Describe returns a single table of the default database by name, optionally qualified as schema.table.
+func Describe(ctx context.Context, db sqlscan.Queryer, name string) (Table, error) {
+	var t Table
+	err := queryRow(ctx, db, `
+		SELECT database_name, schema_name, table_name, estimated_size
+		FROM duckdb_tables()
+		WHERE database_name = current_database()
+			AND (table_name = $1 OR schema_name || '.' || table_name = $1)
+		ORDER BY schema_name = current_schema() DESC
+		LIMIT 1
+	`, []any{name}, &t.Database, &t.Schema, &t.Name, &t.EstimatedRows)
+	if err != nil {
+		return t, fmt.Errorf("describing table %s: %w", name, err)
+	}
+	t.Columns, err = columns(ctx, db, t.Database, t.Schema, t.Name)
+	return t, err
+}
+
+// Columns returns the columns of a table in the default database, in ordinal order.
+func Columns(ctx context.Context, db sqlscan.Queryer, schema, table string) ([]Column, error) {
+	var database string
+	if err := queryRow(ctx, db, `SELECT current_database()`, nil, &database); err != nil {
+		return nil, err
+	}
+	return columns(ctx, db, database, schema, table)
+}
+
+func columns(ctx context.Context, db sqlscan.Queryer, database, schema, table string) ([]Column, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT column_name, ordinal_position, data_type, is_nullable = 'YES'
+		FROM information_schema.columns
+		WHERE table_catalog = $1 AND table_schema = $2 AND table_name = $3
+		ORDER BY ordinal_position
+	`, database, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []Column
+	for rows.Next() {
+		var c Column
+		if err := rows.Scan(&c.Name, &c.Position, &c.Type, &c.Nullable); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+func queryRow(ctx context.Context, db sqlscan.Queryer, query string, args []any, dest ...any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	return rows.Scan(dest...)
+}
@@ -0,0 +1,65 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/schema"
+)
+
+func TestRecordsTable(t *testing.T) {
+	ctx := context.Background()
+	db, err := duckbench.CreateDB(ctx, duckbench.DBOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := duckbench.CreateRecordsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []schema.Column{
+		{"id", 1, "INTEGER", true},
+		{"value", 2, "DOUBLE", true},
+		{"value2", 3, "DOUBLE", true},
+		{"category", 4, "VARCHAR", false},
+		{"ts", 5, "TIMESTAMP", true},
+	}
+	check := func(what string, cols []schema.Column) {
+		if len(cols) != len(want) {
+			t.Fatalf("%s: %d columns %+v, want %d", what, len(cols), cols, len(want))
+		}
+		for i, c := range cols {
+			if c != want[i] {
+				t.Errorf("%s: column %d = %+v, want %+v", what, i, c, want[i])
+			}
+		}
+	}
+
+	tables, err := schema.Tables(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0].Name != "records" || tables[0].Schema != "main" {
+		t.Fatalf("Tables = %+v, want main.records only", tables)
+	}
+	check("Tables", tables[0].Columns)
+
+	for _, name := range []string{"records", "main.records"} {
+		table, err := schema.Describe(ctx, db, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check("Describe "+name, table.Columns)
+	}
+	if _, err := schema.Describe(ctx, db, "missing"); err == nil {
+		t.Error("Describe of a missing table succeeded")
+	}
+
+	cols, err := schema.Columns(ctx, db, "main", "records")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Columns", cols)
+}
@@ -0,0 +1,80 @@
+// Package sketch has probabilistic summaries of a stream of values, which answer approximately in a fixed amount of
+// memory however many values they have seen: HyperLogLog for the number of distinct values, and a t-digest for
+// quantiles.
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// DefaultPrecision gives a HyperLogLog 16384 registers, for a standard error of about 0.8%.
+const DefaultPrecision = 14
+
+// HyperLogLog estimates the number of distinct values added to it, with a standard error of about
+// 1.04/sqrt(2^precision), from the longest run of leading zeros of their hashes in each of 2^precision registers.
+type HyperLogLog struct {
+	p         uint8
+	registers []uint8
+}
+
+// NewHyperLogLog returns an empty HyperLogLog with 2^precision registers. It panics if precision is outside
+// [4, 18].
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < 4 || precision > 18 {
+		panic(fmt.Sprintf("sketch: HyperLogLog precision %d is outside [4, 18]", precision))
+	}
+	return &HyperLogLog{p: precision, registers: make([]uint8, 1<<precision)}
+}
+
+// Add adds a value by its hash, whose bits must be uniformly distributed, such as from Hash64.
+func (h *HyperLogLog) Add(hash uint64) {
+	i := hash >> (64 - h.p)
+	// the bit below the remaining 64-p bits bounds the run of zeros when they are all zero
+	rank := uint8(bits.LeadingZeros64(hash<<h.p|1<<(h.p-1))) + 1
+	if rank > h.registers[i] {
+		h.registers[i] = rank
+	}
+}
+
+// Estimate returns the estimated number of distinct values added, falling back to linear counting of the empty
+// registers while few have been filled.
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+	var sum float64
+	var empty int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			empty++
+		}
+	}
+	var alpha float64
+	switch h.p {
+	case 4:
+		alpha = 0.673
+	case 5:
+		alpha = 0.697
+	case 6:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && empty > 0 {
+		estimate = m * math.Log(m/float64(empty))
+	}
+	return uint64(math.Round(estimate))
+}
+
+// Hash64 mixes the bits of x, with the finalizer of SplitMix64, so that similar values such as consecutive
+// integers or nearby floats have independent hashes.
+func Hash64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,48 @@
+package sketch
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000, 1000000} {
+		h := NewHyperLogLog(DefaultPrecision)
+		for i := range n {
+			// every value twice, which must not be counted again
+			h.Add(Hash64(uint64(i)))
+			h.Add(Hash64(uint64(i)))
+		}
+		got := h.Estimate()
+		if err := math.Abs(float64(got)-float64(n)) / math.Max(float64(n), 1); err > 0.03 {
+			t.Errorf("%d distinct values estimated as %d, error %.3g", n, got, err)
+		}
+	}
+}
+
+func TestTDigest(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 1))
+	values := make([]float64, 100000)
+	d := NewTDigest(DefaultCompression)
+	for i := range values {
+		values[i] = r.ExpFloat64()
+		d.Add(values[i])
+	}
+	slices.Sort(values)
+	for _, q := range []float64{0.001, 0.01, 0.25, 0.5, 0.75, 0.99, 0.999} {
+		got := d.Quantile(q)
+		i, _ := slices.BinarySearch(values, got)
+		rank := float64(i) / float64(len(values))
+		if math.Abs(rank-q) > 0.005 {
+			t.Errorf("quantile %g estimated as %g, at rank %g", q, got, rank)
+		}
+	}
+	if got := d.Quantile(0); got != values[0] {
+		t.Errorf("quantile 0 = %g, want the min %g", got, values[0])
+	}
+	if got := NewTDigest(DefaultCompression).Quantile(0.5); !math.IsNaN(got) {
+		t.Errorf("median of nothing = %g, want NaN", got)
+	}
+}
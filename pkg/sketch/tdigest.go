@@ -0,0 +1,105 @@
+package sketch
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// DefaultCompression is the usual compression of a TDigest, keeping at most a few hundred centroids.
+const DefaultCompression = 100
+
+// TDigest estimates quantiles of the values added to it from clusters of nearby values, called centroids, which are
+// kept smallest at the tails so that the extreme quantiles are the most accurate. It is the merging variant:
+// values are buffered and merged into the centroids in sorted batches.
+type TDigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	n           float64
+	min, max    float64
+}
+
+type centroid struct {
+	mean, weight float64
+}
+
+// NewTDigest returns an empty TDigest. Its compression bounds the number of centroids, trading memory for
+// accuracy.
+func NewTDigest(compression float64) *TDigest {
+	return &TDigest{
+		compression: compression,
+		buffer:      make([]centroid, 0, 5*int(math.Ceil(compression))),
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Add adds v, which must not be NaN.
+func (t *TDigest) Add(v float64) {
+	t.buffer = append(t.buffer, centroid{mean: v, weight: 1})
+	t.n++
+	t.min, t.max = math.Min(t.min, v), math.Max(t.max, v)
+	if len(t.buffer) == cap(t.buffer) {
+		t.merge()
+	}
+}
+
+// k is the scale function k1 of the t-digest paper, under which each centroid may span at most a unit: the
+// centroids of quantiles near 0 and 1 are then much smaller than those near the median.
+func (t *TDigest) k(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// merge merges the buffered values into the centroids.
+func (t *TDigest) merge() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.buffer, t.centroids...)
+	slices.SortFunc(all, func(a, b centroid) int { return cmp.Compare(a.mean, b.mean) })
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	var before float64
+	kLeft := t.k(0)
+	for _, c := range all[1:] {
+		if t.k((before+cur.weight+c.weight)/t.n)-kLeft <= 1 {
+			cur.weight += c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / cur.weight
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.weight
+		kLeft = t.k(before / t.n)
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+	t.buffer = t.buffer[:0]
+}
+
+// Quantile returns the estimated qth quantile, interpolating between the centres of the centroids either side of
+// it, or NaN if no values have been added.
+func (t *TDigest) Quantile(q float64) float64 {
+	t.merge()
+	switch {
+	case t.n == 0:
+		return math.NaN()
+	case q <= 0:
+		return t.min
+	case q >= 1:
+		return t.max
+	}
+	target := q * t.n
+	// the min and max are the centres of weightless centroids at either end
+	prevCentre, prevMean := 0.0, t.min
+	var before float64
+	for _, c := range t.centroids {
+		centre := before + c.weight/2
+		if target < centre {
+			return prevMean + (target-prevCentre)/(centre-prevCentre)*(c.mean-prevMean)
+		}
+		prevCentre, prevMean = centre, c.mean
+		before += c.weight
+	}
+	return prevMean + (target-prevCentre)/(t.n-prevCentre)*(t.max-prevMean)
+}
@@ -0,0 +1,73 @@
+// Package sqlscan scans query results into structs by column name rather than position, so reordering a SELECT
+// list (or the fields of a struct) cannot silently put values in the wrong place.
+package sqlscan
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Queryer is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Scan scans the current row of rows into the struct dest points to. Each column is stored in the field whose db
+// tag names it; fields without a db tag are ignored. It is an error for a column to have no field, a tagged field
+// to have no column, or a column's DuckDB type not to suit its field (e.g. a VARCHAR column for a float64 field),
+// so a query and the struct it fills cannot drift apart unnoticed.
+func Scan(rows *sql.Rows, dest any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sqlscan: dest must be a pointer to a struct, not %T", dest)
+	}
+	p, err := newPlan(rows, v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return p.scan(rows, v.Elem())
+}
+
+// One runs query and scans its only row into a T, returning sql.ErrNoRows if there is no row.
+func One[T any](ctx context.Context, db Queryer, query string, args ...any) (T, error) {
+	var t T
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return t, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return t, err
+		}
+		return t, sql.ErrNoRows
+	}
+	if err := Scan(rows, &t); err != nil {
+		return t, err
+	}
+	return t, rows.Close()
+}
+
+// All runs query and scans every row into a T.
+func All[T any](ctx context.Context, db Queryer, query string, args ...any) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	p, err := newPlan(rows, reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	var all []T
+	for rows.Next() {
+		var t T
+		if err := p.scan(rows, reflect.ValueOf(&t).Elem()); err != nil {
+			return nil, err
+		}
+		all = append(all, t)
+	}
+	return all, rows.Err()
+}
@@ -0,0 +1,145 @@
+package sqlscan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/marcboeker/go-duckdb"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/nullable"
+)
+
+type minMax struct {
+	Min     float64 `db:"min"`
+	Max     float64 `db:"max"`
+	Ignored string
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestOneByName(t *testing.T) {
+	db := openDB(t)
+	// however the SELECT list is ordered, each value lands in the field named for it
+	for _, query := range []string{
+		"SELECT 1.0::DOUBLE AS min, 9.0::DOUBLE AS max",
+		"SELECT 9.0::DOUBLE AS max, 1.0::DOUBLE AS min",
+	} {
+		got, err := One[minMax](context.Background(), db, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Min != 1 || got.Max != 9 {
+			t.Errorf("%s: got %+v, want min 1 and max 9", query, got)
+		}
+	}
+}
+
+func TestMismatchedColumns(t *testing.T) {
+	db := openDB(t)
+	tests := map[string]string{
+		"SELECT 1.0::DOUBLE AS min":                                      `field for "max"`,
+		"SELECT 1.0::DOUBLE AS min, 9.0::DOUBLE AS max, 5 AS median":     `column "median"`,
+		"SELECT 1.0::DOUBLE AS min, 9.0::DOUBLE AS maximum":              `column "maximum"`,
+		"SELECT 1.0::DOUBLE AS minimum, 9.0::DOUBLE, 2.0::DOUBLE AS max": `column "minimum"`,
+	}
+	for query, want := range tests {
+		_, err := One[minMax](context.Background(), db, query)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want it to mention %s", query, err, want)
+		}
+	}
+}
+
+func TestOneNoRows(t *testing.T) {
+	_, err := One[minMax](context.Background(), openDB(t), "SELECT 1.0::DOUBLE AS min, 2.0::DOUBLE AS max WHERE false")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestAll(t *testing.T) {
+	got, err := All[minMax](context.Background(), openDB(t), "SELECT i::DOUBLE AS max, -i::DOUBLE AS min FROM range(3) t(i)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[2].Min != -2 || got[2].Max != 2 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestScanNotStruct(t *testing.T) {
+	rows, err := openDB(t).Query("SELECT 1 AS min")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	rows.Next()
+	var v float64
+	if err := Scan(rows, &v); err == nil {
+		t.Error("scanning into a float64 succeeded")
+	}
+}
+
+type typed struct {
+	F  float64                `db:"f"`
+	I  int64                  `db:"i"`
+	S  string                 `db:"s"`
+	T  time.Time              `db:"t"`
+	N  sql.NullFloat64        `db:"n"`
+	G  sql.Null[string]       `db:"g"`
+	NN nullable.Nullable[int] `db:"nn"`
+	A  any                    `db:"a"`
+}
+
+func TestValidateTypes(t *testing.T) {
+	db := openDB(t)
+	const columns = "1.5::DOUBLE AS f, 2::BIGINT AS i, 'x' AS s, now() AS t, NULL::DOUBLE AS n, 'g' AS g, 3::INTEGER AS nn, [1] AS a"
+	got, err := One[typed](context.Background(), db, "SELECT "+columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.F != 1.5 || got.I != 2 || got.S != "x" || got.N.Valid || got.G.V != "g" || got.NN.V != 3 {
+		t.Errorf("got %+v", got)
+	}
+
+	tests := map[string]string{
+		"'1.5' AS f":             `column "f" is VARCHAR, but field F`,
+		"1.5::DOUBLE AS i":       `column "i" is DOUBLE, but field I`,
+		"1.5::DECIMAL(4,1) AS f": `column "f" is DECIMAL(4,1)`,
+		"42 AS s":                `column "s" is INTEGER`,
+		"'2024-01-01' AS t":      `column "t" is VARCHAR`,
+		"'x' AS n":               `column "n" is VARCHAR, but field N`,
+		"1.5::DOUBLE AS nn":      `column "nn" is DOUBLE, but field NN`,
+	}
+	for replacement, want := range tests {
+		name := replacement[strings.LastIndex(replacement, " ")+1:]
+		var cols []string
+		for _, c := range strings.Split(columns, ", ") {
+			if strings.HasSuffix(c, " AS "+name) {
+				c = replacement
+			}
+			cols = append(cols, c)
+		}
+		rows, err := db.Query("SELECT " + strings.Join(cols, ", "))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Validate[typed](rows)
+		rows.Close()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want it to mention %s", replacement, err, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package sqlscan
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// DuckDB's type names first, then the other spellings MySQL and Postgres drivers report
+	intTypes = []string{"TINYINT", "SMALLINT", "INTEGER", "BIGINT", "UTINYINT", "USMALLINT", "UINTEGER", "UBIGINT",
+		"INT", "MEDIUMINT", "INT2", "INT4", "INT8"}
+	floatTypes = append([]string{"DOUBLE", "FLOAT", "REAL", "FLOAT4", "FLOAT8"}, intTypes...)
+	timeTypes  = []string{"DATE", "TIME", "TIMESTAMP", "TIMESTAMPTZ", "TIMESTAMP_S", "TIMESTAMP_MS", "TIMESTAMP_NS"}
+
+	timeType  = reflect.TypeFor[time.Time]()
+	bytesType = reflect.TypeFor[[]byte]()
+	boolType  = reflect.TypeFor[bool]()
+)
+
+// accepts returns the DuckDB types which scan cleanly into a field of type t, or nil if any type may, e.g. for
+// interfaces and custom sql.Scanners. Null wrappers (sql.Null[T], sql.NullFloat64, nullable.Nullable[T], ...)
+// accept the types of the value they wrap.
+func accepts(t reflect.Type) []string {
+	if t == timeType {
+		return timeTypes
+	}
+	if t == bytesType {
+		return []string{"BLOB"}
+	}
+	if inner, ok := nullWrapped(t); ok {
+		return accepts(inner)
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return floatTypes
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return intTypes
+	case reflect.String:
+		return []string{"VARCHAR", "ENUM"}
+	case reflect.Bool:
+		return []string{"BOOLEAN"}
+	}
+	return nil
+}
+
+// nullWrapped returns the type wrapped by a null wrapper: a struct of a value and a Valid bool.
+func nullWrapped(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || t.NumField() != 2 {
+		return nil, false
+	}
+	if valid := t.Field(1); valid.Name != "Valid" || valid.Type != boolType {
+		return nil, false
+	}
+	return t.Field(0).Type, true
+}
+
+// baseType strips the parameters from a DuckDB type name, e.g. DECIMAL(18,3) becomes DECIMAL.
+func baseType(name string) string {
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// plan maps the columns of a result set to the fields of a struct type which they scan into.
+type plan struct {
+	typ    reflect.Type
+	fields []int
+}
+
+// newPlan matches the columns of rows to the db tagged fields of t by name, and checks each column's DuckDB type
+// suits its field.
+func newPlan(rows *sql.Rows, t reflect.Type) (*plan, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sqlscan: can only scan into structs, not %s", t)
+	}
+	fields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := t.Field(i).Tag.Lookup("db"); ok && name != "-" {
+			fields[name] = i
+		}
+	}
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	p := &plan{typ: t, fields: make([]int, len(columns))}
+	for i, column := range columns {
+		field, ok := fields[column.Name()]
+		if !ok {
+			return nil, fmt.Errorf("sqlscan: column %q has no field in %s", column.Name(), t)
+		}
+		delete(fields, column.Name())
+		p.fields[i] = field
+
+		f := t.Field(field)
+		dbType := column.DatabaseTypeName()
+		// drivers other than DuckDB's may not know the type of an expression, which leaves nothing to check
+		if want := accepts(f.Type); want != nil && dbType != "" && !slices.Contains(want, baseType(dbType)) {
+			return nil, fmt.Errorf("sqlscan: column %q is %s, but field %s of %s is %s, which scans %s",
+				column.Name(), dbType, f.Name, t, f.Type, strings.Join(want, ", "))
+		}
+	}
+	for name := range fields {
+		return nil, fmt.Errorf("sqlscan: field for %q in %s has no column", name, t)
+	}
+	return p, nil
+}
+
+func (p *plan) scan(rows *sql.Rows, v reflect.Value) error {
+	ptrs := make([]any, len(p.fields))
+	for i, field := range p.fields {
+		ptrs[i] = v.Field(field).Addr().Interface()
+	}
+	return rows.Scan(ptrs...)
+}
+
+// Validate checks the columns of rows against the db tagged fields of T, as Scan does before scanning, so that a
+// drifting schema can be reported before any rows are read.
+func Validate[T any](rows *sql.Rows) error {
+	_, err := newPlan(rows, reflect.TypeFor[T]())
+	return err
+}
@@ -0,0 +1,93 @@
+package sqlwrap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// AuditEntry is one line of an audit log. RowsAffected is omitted when it is unknown.
+type AuditEntry struct {
+	Seq          int64     `json:"seq"`
+	Time         time.Time `json:"time"`
+	Op           Op        `json:"op"`
+	Query        string    `json:"query,omitempty"`
+	Args         []any     `json:"args,omitempty"`
+	DurationNS   int64     `json:"duration_ns"`
+	RowsAffected *int64    `json:"rows_affected,omitempty"`
+	Err          string    `json:"error,omitempty"`
+	Setup        bool      `json:"setup,omitempty"`
+}
+
+// Audit is a hook which writes an AuditEntry for every completed operation to a writer as a line of JSON, so a run
+// can be reconstructed after the fact. Statements and arguments are redacted as by Logger. It is safe for
+// concurrent use.
+type Audit struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	seq int64
+	err error
+}
+
+func NewAudit(w io.Writer) *Audit {
+	return &Audit{enc: json.NewEncoder(w)}
+}
+
+func (a *Audit) Before(ctx context.Context, _ *Event) context.Context { return ctx }
+
+func (a *Audit) After(_ context.Context, e *Event) {
+	query, args := Redact(e.Query, e.Args)
+	for i, arg := range args {
+		args[i] = auditValue(arg)
+	}
+	entry := AuditEntry{
+		Time:       e.Start,
+		Op:         e.Op,
+		Query:      query,
+		Args:       args,
+		DurationNS: e.Duration.Nanoseconds(),
+		Setup:      e.Setup,
+	}
+	if e.RowsAffected >= 0 {
+		rows := e.RowsAffected
+		entry.RowsAffected = &rows
+	}
+	if e.Err != nil {
+		entry.Err = e.Err.Error()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seq++
+	entry.Seq = a.seq
+	if err := a.enc.Encode(entry); err != nil && a.err == nil {
+		a.err = err
+	}
+}
+
+// Err returns the first error writing the log, if any.
+func (a *Audit) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// auditValue makes v representable in JSON. Non-finite floats are written as the strings DuckDB casts back to
+// them, e.g. 'NaN' and 'Infinity'.
+func auditValue(v any) any {
+	f, ok := v.(float64)
+	switch {
+	case !ok:
+		return v
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return v
+}
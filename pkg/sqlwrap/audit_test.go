@@ -0,0 +1,71 @@
+package sqlwrap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	audit := NewAudit(&buf)
+	db := openDuckDB(t, audit)
+	for _, stmt := range []struct {
+		query string
+		args  []any
+	}{
+		{"CREATE TABLE t (v DOUBLE)", nil},
+		{"INSERT INTO t VALUES (?), (?), (?)", []any{1.5, math.NaN(), math.Inf(-1)}},
+		{"SELECT 'abc' AS password", nil},
+	} {
+		if _, err := db.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO missing VALUES (1)"); err == nil {
+		t.Fatal("insert into a missing table succeeded")
+	}
+	if err := audit.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []AuditEntry
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e AuditEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("%d entries, want 4: %+v", len(entries), entries)
+	}
+	for i, e := range entries {
+		if e.Seq != int64(i+1) || e.Op != OpExec || e.Time.IsZero() || e.DurationNS <= 0 {
+			t.Errorf("entry %d = %+v, want seq %d of an exec with its time and duration", i, e, i+1)
+		}
+	}
+	insert := entries[1]
+	if insert.RowsAffected == nil || *insert.RowsAffected != 3 {
+		t.Errorf("insert rows_affected = %v, want 3", insert.RowsAffected)
+	}
+	want := []any{1.5, "NaN", "-Infinity"}
+	if len(insert.Args) != len(want) {
+		t.Fatalf("insert args = %v, want %v", insert.Args, want)
+	}
+	for i := range want {
+		if insert.Args[i] != want[i] {
+			t.Errorf("insert args = %v, want %v", insert.Args, want)
+		}
+	}
+	if q := entries[2].Query; q != "SELECT '***' AS password" {
+		t.Errorf("secret query = %q, want it redacted", q)
+	}
+	if failed := entries[3]; failed.Err == "" || failed.RowsAffected != nil {
+		t.Errorf("failed entry = %+v, want its error and no rows", failed)
+	}
+}
@@ -0,0 +1,65 @@
+package sqlwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "***"
+
+var (
+	// credential names such as s3_secret_access_key, password or api_key, but not a bare key, so PRIMARY KEY and a
+	// column called key are left alone
+	sensitiveName = regexp.MustCompile(`(?i)secret|password|passwd|token|credential|(?:access|api|private|session)_?key`)
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+)
+
+// Logger returns a hook which logs every completed operation to l with its duration, rows affected and arguments.
+// String literals in statements which look like they carry credentials (e.g. SET s3_secret_access_key) and
+// arguments bound to such statements or to sensitively named parameters are redacted.
+func Logger(l *slog.Logger) Hook {
+	return AfterFunc(func(ctx context.Context, e *Event) {
+		query, args := Redact(e.Query, e.Args)
+		attrs := []slog.Attr{
+			slog.String("op", string(e.Op)),
+			slog.String("query", query),
+			slog.Duration("duration", e.Duration),
+		}
+		if len(args) > 0 {
+			attrs = append(attrs, slog.Any("args", args))
+		}
+		if e.Setup {
+			attrs = append(attrs, slog.Bool("setup", true))
+		}
+		if e.RowsAffected >= 0 {
+			attrs = append(attrs, slog.Int64("rows", e.RowsAffected))
+		}
+		level := slog.LevelInfo
+		if e.Err != nil {
+			level = slog.LevelWarn
+			attrs = append(attrs, slog.Any("err", e.Err))
+		}
+		l.LogAttrs(ctx, level, "sql", attrs...)
+	})
+}
+
+// Redact returns query collapsed onto a single line, with credentials in string literals and arguments masked.
+func Redact(query string, args []driver.NamedValue) (string, []any) {
+	query = strings.Join(strings.Fields(query), " ")
+	sensitive := sensitiveName.MatchString(query)
+	if sensitive {
+		query = stringLiteral.ReplaceAllString(query, "'"+redacted+"'")
+	}
+	values := make([]any, len(args))
+	for i, arg := range args {
+		if sensitive || (arg.Name != "" && sensitiveName.MatchString(arg.Name)) {
+			values[i] = redacted
+		} else {
+			values[i] = arg.Value
+		}
+	}
+	return query, values
+}
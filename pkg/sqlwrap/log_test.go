@@ -0,0 +1,52 @@
+package sqlwrap
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		args  []driver.NamedValue
+		want  string
+		vals  []any
+	}{
+		{"secret setting", "SET s3_secret_access_key = 'abc'", nil, "SET s3_secret_access_key = '***'", nil},
+		{"access key id", "SET s3_access_key_id = 'AKIA'", nil, "SET s3_access_key_id = '***'", nil},
+		{"secret with parameters", "CREATE SECRET (TYPE S3, KEY_ID ?, SECRET ?)",
+			[]driver.NamedValue{{Ordinal: 1, Value: "id"}, {Ordinal: 2, Value: "s"}},
+			"CREATE SECRET (TYPE S3, KEY_ID ?, SECRET ?)", []any{redacted, redacted}},
+		{"password parameter", "SELECT * FROM users WHERE name = $name AND password = $password",
+			[]driver.NamedValue{{Name: "name", Value: "bob"}, {Name: "password", Value: "hunter2"}},
+			"SELECT * FROM users WHERE name = $name AND password = $password", []any{redacted, redacted}},
+		{"sensitive parameter name", "SELECT $1, $token",
+			[]driver.NamedValue{{Ordinal: 1, Value: 1}, {Name: "token", Value: "t"}},
+			"SELECT $1, $token", []any{redacted, redacted}},
+
+		{"primary key", "CREATE TABLE t (id INTEGER PRIMARY KEY, name VARCHAR DEFAULT 'none')", nil,
+			"CREATE TABLE t (id INTEGER PRIMARY KEY, name VARCHAR DEFAULT 'none')", nil},
+		{"key column", "SELECT value FROM kv WHERE key = 'a' AND key = ?",
+			[]driver.NamedValue{{Ordinal: 1, Value: "b"}},
+			"SELECT value FROM kv WHERE key = 'a' AND key = ?", []any{"b"}},
+		{"key parameter name", "SELECT $key", []driver.NamedValue{{Name: "key", Value: 1}}, "SELECT $key", []any{1}},
+		{"collapsed", "SELECT 1\n\tFROM   t", nil, "SELECT 1 FROM t", nil},
+	}
+	for _, tt := range tests {
+		query, vals := Redact(tt.query, tt.args)
+		if query != tt.want {
+			t.Errorf("%s: query = %q, want %q", tt.name, query, tt.want)
+		}
+		if len(vals) != len(tt.vals) {
+			t.Errorf("%s: args = %v, want %v", tt.name, vals, tt.vals)
+			continue
+		}
+		for i := range vals {
+			if vals[i] != tt.vals[i] {
+				t.Errorf("%s: args = %v, want %v", tt.name, vals, tt.vals)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package sqlwrap
+
+import (
+	"context"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/metrics"
+)
+
+// Metrics returns a hook which records a latency histogram, a statement counter, an error counter and the number
+// of rows affected into r, labelled by operation.
+func Metrics(r *metrics.Registry) Hook {
+	return AfterFunc(func(_ context.Context, e *Event) {
+		labels := metrics.Labels{"op": string(e.Op)}
+		r.Counter("duckdb_statements_total", "Statements executed through the driver.", labels).Inc()
+		r.Histogram("duckdb_statement_duration_seconds", "Statement latency.", metrics.DefBuckets, labels).Observe(e.Duration.Seconds())
+		if e.Err != nil {
+			r.Counter("duckdb_statement_errors_total", "Statements which returned an error.", labels).Inc()
+		}
+		if e.RowsAffected > 0 {
+			r.Counter("duckdb_rows_affected_total", "Rows affected by exec statements.", labels).Add(uint64(e.RowsAffected))
+		}
+	})
+}
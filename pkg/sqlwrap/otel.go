@@ -0,0 +1,44 @@
+package sqlwrap
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/rpep/duckdb-go-experiments/pkg/sqlwrap"
+
+// Tracing returns a hook which records every operation as an OpenTelemetry client span, nested under any span in
+// the caller's context. Statements are redacted as for Logger, and arguments are not recorded.
+func Tracing(tp trace.TracerProvider) Hook {
+	return tracingHook{tracer: tp.Tracer(tracerName)}
+}
+
+type tracingHook struct {
+	tracer trace.Tracer
+}
+
+func (h tracingHook) Before(ctx context.Context, e *Event) context.Context {
+	query, _ := Redact(e.Query, nil)
+	ctx, _ = h.tracer.Start(ctx, "duckdb."+string(e.Op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "duckdb"),
+			attribute.String("db.statement", query),
+		))
+	return ctx
+}
+
+func (h tracingHook) After(ctx context.Context, e *Event) {
+	span := trace.SpanFromContext(ctx)
+	if e.RowsAffected >= 0 {
+		span.SetAttributes(attribute.Int64("db.rows_affected", e.RowsAffected))
+	}
+	if e.Err != nil {
+		span.RecordError(e.Err)
+		span.SetStatus(codes.Error, e.Err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,99 @@
+package sqlwrap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/metrics"
+)
+
+var (
+	numberLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?(?:[eE][-+]?\d+)?\b`)
+	placeholder   = regexp.MustCompile(`\$\d+|\$[A-Za-z_]\w*|\?`)
+	repeatedList  = regexp.MustCompile(`\?(?:\s*,\s*\?)+`)
+	repeatedRows  = regexp.MustCompile(`\(\?(?:, \.\.\.)?\)(?:\s*,\s*\(\?(?:, \.\.\.)?\))+`)
+)
+
+// Normalize reduces a statement to its shape: whitespace is collapsed, literals and placeholders become ?, and
+// repeated lists such as multi-row VALUES are folded, so statements differing only in their values share a shape.
+func Normalize(query string) string {
+	q := strings.Join(strings.Fields(query), " ")
+	q = placeholder.ReplaceAllString(q, "?")
+	q = stringLiteral.ReplaceAllString(q, "?")
+	q = numberLiteral.ReplaceAllString(q, "?")
+	q = repeatedList.ReplaceAllString(q, "?, ...")
+	q = repeatedRows.ReplaceAllString(q, "(?, ...), ...")
+	return q
+}
+
+// Shapes records a latency histogram per statement shape (see Normalize), into a registry and for its own
+// ServeHTTP summary, so an embedded DuckDB can be observed like a database server.
+type Shapes struct {
+	registry *metrics.Registry
+
+	mu     sync.Mutex
+	shapes map[string]*metrics.Histogram
+}
+
+func NewShapes(r *metrics.Registry) *Shapes {
+	return &Shapes{registry: r, shapes: make(map[string]*metrics.Histogram)}
+}
+
+func (s *Shapes) Before(ctx context.Context, _ *Event) context.Context { return ctx }
+
+func (s *Shapes) After(_ context.Context, e *Event) {
+	if e.Op != OpExec && e.Op != OpQuery {
+		return
+	}
+	shape := Normalize(e.Query)
+	s.mu.Lock()
+	h, ok := s.shapes[shape]
+	if !ok {
+		h = s.registry.Histogram("duckdb_query_shape_duration_seconds", "Statement latency by normalized statement.",
+			metrics.DefBuckets, metrics.Labels{"shape": shape})
+		s.shapes[shape] = h
+	}
+	s.mu.Unlock()
+	h.Observe(e.Duration.Seconds())
+}
+
+// ServeHTTP renders a plain-text table of every shape seen, ordered by total time spent, with quantiles estimated
+// from the histogram buckets.
+func (s *Shapes) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	type row struct {
+		shape string
+		h     *metrics.Histogram
+	}
+	s.mu.Lock()
+	rows := make([]row, 0, len(s.shapes))
+	for shape, h := range s.shapes {
+		rows = append(rows, row{shape, h})
+	}
+	s.mu.Unlock()
+	sort.Slice(rows, func(i, j int) bool { return rows[i].h.Sum() > rows[j].h.Sum() })
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COUNT\tTOTAL\tMEAN\tP50\tP95\tP99\tSHAPE")
+	for _, r := range rows {
+		count := r.h.Count()
+		mean := 0.0
+		if count > 0 {
+			mean = r.h.Sum() / float64(count)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", count, secs(r.h.Sum()), secs(mean),
+			secs(r.h.Quantile(.5)), secs(r.h.Quantile(.95)), secs(r.h.Quantile(.99)), r.shape)
+	}
+	tw.Flush()
+}
+
+func secs(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Microsecond)
+}
@@ -0,0 +1,20 @@
+package sqlwrap
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM t WHERE id = 42 AND name = 'x'":    "SELECT * FROM t WHERE id = ? AND name = ?",
+		"SELECT\n\tvalue2,  1.5e3\nFROM t":                "SELECT value2, ? FROM t",
+		"SELECT $1, $name, ?":                             "SELECT ?, ...",
+		"INSERT INTO t VALUES (?, ?, ?)":                  "INSERT INTO t VALUES (?, ...)",
+		"INSERT INTO t VALUES ($1, $2), ($3, $4), (5, 6)": "INSERT INTO t VALUES (?, ...), ...",
+		"INSERT INTO t VALUES (1), (2)":                   "INSERT INTO t VALUES (?, ...), ...",
+		"SELECT 'it''s'":                                  "SELECT ?",
+	}
+	for query, want := range tests {
+		if got := Normalize(query); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package sqlwrap wraps a database/sql driver.Connector so every statement run through the resulting *sql.DB can
+// be observed by hooks (logging, metrics, ...) without changing any call sites.
+package sqlwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// Op identifies the kind of driver operation an Event describes.
+type Op string
+
+const (
+	OpExec     Op = "exec"
+	OpQuery    Op = "query"
+	OpBegin    Op = "begin"
+	OpCommit   Op = "commit"
+	OpRollback Op = "rollback"
+)
+
+// Event describes a single driver operation. Duration, RowsAffected and Err are only set once the operation has
+// completed; RowsAffected is -1 when it is unknown, e.g. for queries.
+type Event struct {
+	Op           Op
+	Query        string
+	Args         []driver.NamedValue
+	Start        time.Time
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+	// Setup is set for operations run with a Setup context, rather than being what is measured.
+	Setup bool
+}
+
+// A Hook observes operations run through a wrapped connector. Before is called before the operation starts and
+// may return a derived context, which is passed on to the driver and to After.
+type Hook interface {
+	Before(ctx context.Context, e *Event) context.Context
+	After(ctx context.Context, e *Event)
+}
+
+// AfterFunc adapts a function to a Hook which is only interested in completed operations.
+type AfterFunc func(ctx context.Context, e *Event)
+
+func (AfterFunc) Before(ctx context.Context, _ *Event) context.Context { return ctx }
+func (f AfterFunc) After(ctx context.Context, e *Event)                { f(ctx, e) }
+
+// Wrap returns a connector whose connections report every operation to hooks. With no hooks, c is returned as is.
+func Wrap(c driver.Connector, hooks ...Hook) driver.Connector {
+	if len(hooks) == 0 {
+		return c
+	}
+	return &connector{Connector: c, hooks: hooks}
+}
+
+// Unwrap returns the driver connection underneath a wrapped connection, for use with driver-specific APIs such as
+// the DuckDB appender from within sql.Conn.Raw. Connections which are not wrapped are returned unchanged.
+func Unwrap(c any) any {
+	if w, ok := c.(*conn); ok {
+		return w.Conn
+	}
+	return c
+}
+
+type (
+	quietKey struct{}
+	setupKey struct{}
+)
+
+// Quiet returns a context whose operations are not reported to hooks, for the polling of resource samplers, which
+// would otherwise drown out the statements being observed. Everything else should be reported, if need be as Setup.
+func Quiet(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietKey{}, true)
+}
+
+// Setup returns a context whose operations are reported with Event.Setup set, for statements preparing or checking
+// what is measured, such as recreating a table between inserts or verifying what was inserted.
+func Setup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, setupKey{}, true)
+}
+
+type connector struct {
+	driver.Connector
+	hooks []Hook
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, hooks: c.hooks}, nil
+}
+
+func (c *connector) Close() error {
+	if closer, ok := c.Connector.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+type observer struct {
+	hooks []Hook
+}
+
+// observe runs fn between the Before and After calls of every hook.
+func (o observer) observe(ctx context.Context, op Op, query string, args []driver.NamedValue, fn func(ctx context.Context) (driver.Result, error)) error {
+	if ctx.Value(quietKey{}) != nil {
+		_, err := fn(ctx)
+		return err
+	}
+	e := &Event{Op: op, Query: query, Args: args, RowsAffected: -1, Setup: ctx.Value(setupKey{}) != nil}
+	for _, h := range o.hooks {
+		ctx = h.Before(ctx, e)
+	}
+	e.Start = time.Now()
+	res, err := fn(ctx)
+	e.Duration = time.Since(e.Start)
+	e.Err = err
+	if res != nil {
+		if n, err := res.RowsAffected(); err == nil {
+			e.RowsAffected = n
+		}
+	}
+	for i := len(o.hooks) - 1; i >= 0; i-- {
+		o.hooks[i].After(ctx, e)
+	}
+	return err
+}
+
+type conn struct {
+	driver.Conn
+	hooks []Hook
+}
+
+func (c *conn) obs() observer { return observer{hooks: c.hooks} }
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	var res driver.Result
+	err := c.obs().observe(ctx, OpExec, query, args, func(ctx context.Context) (driver.Result, error) {
+		var err error
+		res, err = execer.ExecContext(ctx, query, args)
+		return res, err
+	})
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	var rows driver.Rows
+	err := c.obs().observe(ctx, OpQuery, query, args, func(ctx context.Context) (driver.Result, error) {
+		var err error
+		rows, err = queryer.QueryContext(ctx, query, args)
+		return nil, err
+	})
+	return rows, err
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		s   driver.Stmt
+		err error
+	)
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = preparer.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, query: query, obs: c.obs()}, nil
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var t driver.Tx
+	err := c.obs().observe(ctx, OpBegin, "BEGIN", nil, func(ctx context.Context) (driver.Result, error) {
+		var err error
+		if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+			t, err = beginner.BeginTx(ctx, opts)
+		} else {
+			t, err = c.Conn.Begin()
+		}
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, ctx: ctx, obs: c.obs()}, nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+type stmt struct {
+	driver.Stmt
+	query string
+	obs   observer
+}
+
+// ExecContext and QueryContext cannot return driver.ErrSkip, which database/sql only honours for connections, so a
+// statement without the context methods falls back to Exec and Query, as database/sql would do for it.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+	err := s.obs.observe(ctx, OpExec, s.query, args, func(ctx context.Context) (driver.Result, error) {
+		var err error
+		if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+			res, err = execer.ExecContext(ctx, args)
+			return res, err
+		}
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		res, err = s.Stmt.Exec(values)
+		return res, err
+	})
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	err := s.obs.observe(ctx, OpQuery, s.query, args, func(ctx context.Context) (driver.Result, error) {
+		var err error
+		if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			rows, err = queryer.QueryContext(ctx, args)
+			return nil, err
+		}
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		rows, err = s.Stmt.Query(values)
+		return nil, err
+	})
+	return rows, err
+}
+
+// namedValues converts args for Stmt.Exec and Stmt.Query, which take no names.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sqlwrap: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+type tx struct {
+	driver.Tx
+	ctx context.Context
+	obs observer
+}
+
+func (t *tx) Commit() error {
+	return t.obs.observe(t.ctx, OpCommit, "COMMIT", nil, func(context.Context) (driver.Result, error) {
+		return nil, t.Tx.Commit()
+	})
+}
+
+func (t *tx) Rollback() error {
+	return t.obs.observe(t.ctx, OpRollback, "ROLLBACK", nil, func(context.Context) (driver.Result, error) {
+		return nil, t.Tx.Rollback()
+	})
+}
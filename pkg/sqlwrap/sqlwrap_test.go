@@ -0,0 +1,221 @@
+package sqlwrap
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+// recorder is a hook keeping every completed event.
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) Before(ctx context.Context, _ *Event) context.Context { return ctx }
+
+func (r *recorder) After(_ context.Context, e *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *e)
+}
+
+func (r *recorder) ops() []Op {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ops := make([]Op, len(r.events))
+	for i, e := range r.events {
+		ops[i] = e.Op
+	}
+	return ops
+}
+
+func openDuckDB(t *testing.T, hooks ...Hook) *sql.DB {
+	t.Helper()
+	connector, err := duckdb.NewConnector("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(Wrap(connector, hooks...))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestWrap(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	db := openDuckDB(t, rec)
+	if _, err := db.ExecContext(ctx, "CREATE TABLE t (i INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO t VALUES (?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if _, err := stmt.ExecContext(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stmt.Close()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM t WHERE i >= $1", 0).Scan(&n); err != nil || n != 2 {
+		t.Fatalf("count = %d, %v, want 2", n, err)
+	}
+	// housekeeping is not reported
+	if err := db.QueryRowContext(Quiet(ctx), "SELECT count(*) FROM t").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Op{OpExec, OpBegin, OpExec, OpExec, OpCommit, OpQuery}
+	got := rec.ops()
+	if len(got) != len(want) {
+		t.Fatalf("ops = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ops = %v, want %v", got, want)
+		}
+	}
+	insert := rec.events[2]
+	if insert.Query != "INSERT INTO t VALUES (?)" || len(insert.Args) != 1 || insert.Args[0].Value != int64(0) {
+		t.Errorf("insert event = %+v, want its query and argument", insert)
+	}
+	if insert.RowsAffected != 1 {
+		t.Errorf("insert RowsAffected = %d, want 1", insert.RowsAffected)
+	}
+	if q := rec.events[5]; q.RowsAffected != -1 || q.Err != nil {
+		t.Errorf("query event = %+v, want unknown rows and no error", q)
+	}
+
+	if _, err := db.ExecContext(ctx, "INSERT INTO missing VALUES (1)"); err == nil {
+		t.Fatal("insert into a missing table succeeded")
+	}
+	if e := rec.events[len(rec.events)-1]; e.Err == nil || e.Setup {
+		t.Errorf("failed exec event = %+v, want its error", e)
+	}
+	// setup is reported, but marked
+	if _, err := db.ExecContext(Setup(ctx), "DROP TABLE t"); err != nil {
+		t.Fatal(err)
+	}
+	if e := rec.events[len(rec.events)-1]; e.Query != "DROP TABLE t" || !e.Setup {
+		t.Errorf("setup event = %+v, want the DROP marked as setup", e)
+	}
+}
+
+func TestWrapNoHooks(t *testing.T) {
+	connector, err := duckdb.NewConnector("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connector.Close()
+	if Wrap(connector) != driver.Connector(connector) {
+		t.Error("Wrap with no hooks wrapped the connector")
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	ctx := context.Background()
+	db := openDuckDB(t, &recorder{})
+	c, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	err = c.Raw(func(driverConn any) error {
+		if _, ok := Unwrap(driverConn).(*conn); ok {
+			t.Error("Unwrap returned the wrapped connection")
+		}
+		if _, err := duckdb.NewAppenderFromConn(Unwrap(driverConn).(driver.Conn), "", "missing"); err == nil {
+			t.Error("appender on a missing table succeeded")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// legacy is a driver whose connections and statements implement none of the context interfaces, so every
+// statement goes through Prepare, Stmt.Exec and Stmt.Query.
+type legacy struct{ args []driver.Value }
+
+func (l *legacy) Connect(context.Context) (driver.Conn, error) { return legacyConn{l}, nil }
+func (l *legacy) Driver() driver.Driver                        { return nil }
+
+type legacyConn struct{ l *legacy }
+
+func (c legacyConn) Prepare(string) (driver.Stmt, error) { return legacyStmt(c), nil }
+func (legacyConn) Close() error                          { return nil }
+func (legacyConn) Begin() (driver.Tx, error)             { return nil, driver.ErrSkip }
+
+type legacyStmt struct{ l *legacy }
+
+func (legacyStmt) Close() error  { return nil }
+func (legacyStmt) NumInput() int { return -1 }
+
+func (s legacyStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.l.args = args
+	return driver.RowsAffected(len(args)), nil
+}
+
+func (s legacyStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.l.args = args
+	return &legacyRows{}, nil
+}
+
+type legacyRows struct{ done bool }
+
+func (*legacyRows) Columns() []string { return []string{"n"} }
+func (*legacyRows) Close() error      { return nil }
+
+func (r *legacyRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(7)
+	return nil
+}
+
+func TestLegacyStmt(t *testing.T) {
+	ctx := context.Background()
+	l := &legacy{}
+	rec := &recorder{}
+	db := sql.OpenDB(Wrap(l, rec))
+	defer db.Close()
+
+	res, err := db.ExecContext(ctx, "INSERT INTO t VALUES (?, ?)", 1, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 || len(l.args) != 2 || l.args[1] != "a" {
+		t.Errorf("exec affected %d rows with %v, want 2 with the arguments", n, l.args)
+	}
+	var n int64
+	if err := db.QueryRowContext(ctx, "SELECT n FROM t WHERE i = ?", 3).Scan(&n); err != nil || n != 7 {
+		t.Errorf("query = %d, %v, want 7", n, err)
+	}
+	if len(l.args) != 1 || l.args[0] != int64(3) {
+		t.Errorf("query args = %v, want [3]", l.args)
+	}
+	if got := rec.ops(); len(got) != 2 || got[0] != OpExec || got[1] != OpQuery {
+		t.Errorf("ops = %v, want an exec and a query", got)
+	}
+	// the legacy interfaces have no names to bind
+	if _, err := db.ExecContext(ctx, "INSERT INTO t VALUES ($a)", sql.Named("a", 1)); err == nil {
+		t.Error("exec with a named argument succeeded")
+	}
+}
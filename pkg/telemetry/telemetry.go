@@ -0,0 +1,37 @@
+// Package telemetry sets up OpenTelemetry tracing, exporting spans over OTLP/HTTP.
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const tracerName = "github.com/rpep/duckdb-go-experiments"
+
+// Setup installs a global tracer provider which batches spans to an OTLP/HTTP collector. The endpoint and headers
+// are taken from the standard OTEL_EXPORTER_OTLP_* environment variables, defaulting to localhost:4318. The
+// returned function flushes any pending spans and must be called before exiting.
+func Setup(ctx context.Context, service string) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(service)))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Span starts a span using the global tracer provider, returning a function which ends it.
+func Span(ctx context.Context, name string) (context.Context, func()) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name)
+	return ctx, func() { span.End() }
+}
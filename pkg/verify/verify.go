@@ -0,0 +1,75 @@
+// Package verify checks that engines calculating the same statistics agree, so a divergence fails a run instead of
+// waiting to be spotted in its output.
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+// ErrDivergence matches every DivergenceError.
+var ErrDivergence = errors.New("statistics diverge")
+
+// DivergenceError lists the statistics on which two engines disagree.
+type DivergenceError struct {
+	A, B       string
+	Tolerance  floatcmp.Tolerance
+	Mismatches []duckbench.Mismatch
+}
+
+func (e *DivergenceError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s and %s disagree beyond %+v:", e.A, e.B, e.Tolerance)
+	for i, m := range e.Mismatches {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, " %s %v vs %v (rel diff %.3g)", m.Stat, m.A, m.B, floatcmp.RelDiff(m.A, m.B))
+	}
+	return b.String()
+}
+
+func (e *DivergenceError) Is(target error) bool { return target == ErrDivergence }
+
+// Stats returns a *DivergenceError if any statistic calculated by both engine a and engine b differs by more than
+// tol, or they skipped a different number of non-finite or NULL values.
+func Stats(nameA string, a duckbench.Stats, nameB string, b duckbench.Stats, tol floatcmp.Tolerance) error {
+	mismatches := duckbench.CompareStats(a, b, tol)
+	if a.NonFinite != b.NonFinite {
+		mismatches = append(mismatches,
+			duckbench.Mismatch{Stat: "non_finite", A: float64(a.NonFinite), B: float64(b.NonFinite)})
+	}
+	if a.Nulls != b.Nulls {
+		mismatches = append(mismatches, duckbench.Mismatch{Stat: "nulls", A: float64(a.Nulls), B: float64(b.Nulls)})
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return &DivergenceError{A: nameA, B: nameB, Tolerance: tol, Mismatches: mismatches}
+}
+
+// Workload returns a *DivergenceError if the Go and DuckDB results of a workload differ by more than tol.
+func Workload(w duckbench.WorkloadRun, tol floatcmp.Tolerance) error {
+	mismatches := w.Mismatches(tol)
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return &DivergenceError{A: "go " + w.Name, B: "duckdb " + w.Name, Tolerance: tol, Mismatches: mismatches}
+}
+
+// Results checks the DuckDB statistics of a benchmark run, and those of any other engines, agree with the Go
+// statistics within tol, and that both engines agree on each workload.
+func Results(res duckbench.Results, tol floatcmp.Tolerance) error {
+	errs := []error{Stats("go", res.GoStats, "duckdb", res.DBStats, tol)}
+	for _, e := range res.Engines {
+		errs = append(errs, Stats("go", res.GoStats, e.Name, *e.Stats, tol))
+	}
+	for _, w := range res.Workloads {
+		errs = append(errs, Workload(w, tol))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package verify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rpep/duckdb-go-experiments/pkg/duckbench"
+	"github.com/rpep/duckdb-go-experiments/pkg/floatcmp"
+)
+
+func TestStats(t *testing.T) {
+	want := duckbench.Stats{Mean: 1, Median: 1, StdDev: 0.5, Min: 0, Max: 2,
+		Percentiles: []duckbench.Percentile{{P: 0.5, Value: 1}}}
+	tol := floatcmp.Rel(1e-9)
+
+	near := want
+	near.Mean = 1 + 1e-12
+	if err := Stats("a", want, "b", near, tol); err != nil {
+		t.Errorf("statistics within the tolerance: %v", err)
+	}
+
+	tests := map[string]func(*duckbench.Stats){
+		"mean":       func(s *duckbench.Stats) { s.Mean = 1.1 },
+		"max":        func(s *duckbench.Stats) { s.Min, s.Max = s.Max, s.Min },
+		"p50":        func(s *duckbench.Stats) { s.Percentiles = []duckbench.Percentile{{P: 0.5, Value: 2}} },
+		"non_finite": func(s *duckbench.Stats) { s.NonFinite = 3 },
+	}
+	for stat, diverge := range tests {
+		t.Run(stat, func(t *testing.T) {
+			got := want
+			diverge(&got)
+			err := Stats("go", want, "duckdb", got, tol)
+			if !errors.Is(err, ErrDivergence) {
+				t.Fatalf("err = %v, want ErrDivergence", err)
+			}
+			if !strings.Contains(err.Error(), stat) {
+				t.Errorf("err = %q, which doesn't name %s", err, stat)
+			}
+		})
+	}
+}
+
+func TestResults(t *testing.T) {
+	want := duckbench.Stats{Mean: 1, Median: 1, StdDev: 0.5, Min: 0, Max: 2}
+	wrong := want
+	wrong.StdDev = 0.6
+	res := duckbench.Results{GoStats: want, DBStats: want, Engines: []duckbench.BenchmarkResult{
+		{Name: "gonum", Stats: &want},
+		{Name: "other", Stats: &wrong},
+	}}
+	err := Results(res, floatcmp.Rel(1e-9))
+	if !errors.Is(err, ErrDivergence) || !strings.Contains(err.Error(), "other") || strings.Contains(err.Error(), "gonum") {
+		t.Errorf("err = %v, want only the other engine to diverge", err)
+	}
+}
+
+func TestWorkload(t *testing.T) {
+	counts := duckbench.HistogramCounts{Min: 0, Max: 1, Counts: []int64{2, 3}}
+	off := duckbench.HistogramCounts{Min: 0, Max: 1, Counts: []int64{2, 4}}
+	if err := Workload(duckbench.WorkloadRun{Name: "histogram/2", GoResult: counts, DBResult: counts}, floatcmp.Exact); err != nil {
+		t.Errorf("identical histograms: %v", err)
+	}
+	err := Workload(duckbench.WorkloadRun{Name: "histogram/2", GoResult: counts, DBResult: off}, floatcmp.Exact)
+	if !errors.Is(err, ErrDivergence) || !strings.Contains(err.Error(), "bin 1") {
+		t.Errorf("err = %v, want bin 1 to diverge", err)
+	}
+}